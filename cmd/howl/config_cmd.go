@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ai-screams/howl/internal"
+)
+
+// runConfig handles "howl config show|validate" and returns the exit code.
+//
+//	show      prints every config layer, each effective value with the layer
+//	          it came from, and any problems found while loading
+//	validate  prints every problem and exits 1 if there are any
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: howl config show|validate [--project DIR]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	project := fs.String("project", "", "project directory for .claude/howl.json (default: current directory)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	dir := *project
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	report := internal.InspectConfig(dir)
+	switch args[0] {
+	case "show":
+		printConfigReport(stdout, report)
+		return 0
+	case "validate":
+		return validateConfig(stdout, report)
+	default:
+		fmt.Fprintf(stderr, "howl config: unknown command %q (want show or validate)\n", args[0])
+		return 2
+	}
+}
+
+func printConfigReport(w io.Writer, r internal.ConfigReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Layers (lowest precedence first):")
+	fmt.Fprintln(tw, "  default\t(built in)\tloaded")
+	for _, l := range r.Layers {
+		path := l.Path
+		if path == "" {
+			path = "-"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", l.Source, path, l.Status)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "Values:")
+	for _, v := range r.Values {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", v.Key, v.Origin, v.Value)
+	}
+	tw.Flush()
+
+	if len(r.Problems) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Problems:")
+		for _, p := range r.Problems {
+			fmt.Fprintf(w, "  %s\n", p)
+		}
+	}
+}
+
+func validateConfig(w io.Writer, r internal.ConfigReport) int {
+	for _, l := range r.Layers {
+		if l.Path != "" && l.Status != "not found" {
+			fmt.Fprintf(w, "checked %s config: %s\n", l.Source, l.Path)
+		}
+	}
+	if len(r.Problems) == 0 {
+		fmt.Fprintln(w, "config OK")
+		return 0
+	}
+	for _, p := range r.Problems {
+		fmt.Fprintf(w, "  %s\n", p)
+	}
+	fmt.Fprintf(w, "%d problem(s) found\n", len(r.Problems))
+	return 1
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ai-screams/howl/internal"
+)
+
+// runDoctor handles "howl doctor" and returns the exit code: 1 when any
+// check failed, 0 otherwise (warnings do not fail).
+func runDoctor(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts := internal.DoctorOptions{Version: version}
+	fs.StringVar(&opts.PluginRoot, "plugin-root", "", "plugin directory containing .claude-plugin/plugin.json")
+	fs.StringVar(&opts.ProjectDir, "project", "", "project directory for .claude/howl.json (default: current directory)")
+	fs.StringVar(&opts.TranscriptPath, "transcript", "", "transcript to tail (default: newest under ~/.claude/projects)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if opts.ProjectDir == "" {
+		opts.ProjectDir, _ = os.Getwd()
+	}
+	opts.BinaryPath, _ = os.Executable()
+
+	results := internal.RunDoctor(opts)
+	fmt.Fprintf(stdout, "howl doctor (%s)\n\n", version)
+
+	marks := map[internal.CheckStatus]string{
+		internal.CheckPass: "✓",
+		internal.CheckWarn: "!",
+		internal.CheckFail: "✗",
+	}
+	counts := make(map[internal.CheckStatus]int)
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		counts[r.Status]++
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\n", marks[r.Status], r.Status, r.Name, r.Detail)
+		if r.Hint != "" {
+			fmt.Fprintf(tw, "\t\t→ %s\n", r.Hint)
+		}
+	}
+	tw.Flush()
+
+	fmt.Fprintf(stdout, "\n%d passed, %d warning(s), %d failed\n",
+		counts[internal.CheckPass], counts[internal.CheckWarn], counts[internal.CheckFail])
+	if counts[internal.CheckFail] > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ai-screams/howl/internal"
+)
+
+// runLedger handles "howl ledger": summarizes the spend recorded across
+// sessions by day, project, model or account.
+func runLedger(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ledger", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	by := fs.String("by", "day", "group by: "+strings.Join(internal.LedgerGroups, ", "))
+	days := fs.Int("days", 30, "include the last N days, today included (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *days < 0 {
+		fmt.Fprintln(stderr, "howl ledger: --days must be >= 0")
+		return 2
+	}
+
+	now := time.Now()
+	var since time.Time
+	from, period := "", "all time"
+	if *days > 0 {
+		y, m, d := now.Date()
+		since = time.Date(y, m, d-(*days-1), 0, 0, 0, 0, now.Location())
+		from, period = since.Format("2006-01-02"), fmt.Sprintf("last %d days", *days)
+	}
+	sessions, err := internal.LoadLedger(internal.LedgerDir(), since)
+	if err != nil {
+		fmt.Fprintf(stderr, "howl ledger: %v\n", err)
+		return 1
+	}
+	rows, err := internal.SummarizeLedger(sessions, *by, from)
+	if err != nil {
+		fmt.Fprintf(stderr, "howl ledger: %v\n", err)
+		return 2
+	}
+
+	fmt.Fprintf(stdout, "Spend by %s, %s\n\n", *by, period)
+	if len(rows) == 0 {
+		fmt.Fprintln(stdout, "No spend recorded.")
+		return 0
+	}
+	total := internal.LedgerTotal(sessions, from)
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCOST\tSESSIONS\n", strings.ToUpper(*by))
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t$%.2f\t%d\n", r.Key, r.CostUSD, r.Sessions)
+	}
+	fmt.Fprintf(tw, "TOTAL\t$%.2f\t%d\n", total.CostUSD, total.Sessions)
+	tw.Flush()
+	return 0
+}
@@ -4,9 +4,11 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ai-screams/howl/internal"
 )
@@ -24,43 +26,162 @@ func main() {
 			os.Exit(0)
 		case "-h", "--help":
 			fmt.Fprintln(os.Stderr, "howl: Claude Code statusline HUD. Reads JSON from stdin.")
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, "Flags:")
+			fmt.Fprintln(os.Stderr, "  --format text|json|tmux|zellij        ANSI statusline (default), metrics as JSON, or a status-bar line")
+			fmt.Fprintln(os.Stderr, "  --session ID|latest [--dir DIR]       render the session's last stored stdin instead of reading stdin")
+			fmt.Fprintln(os.Stderr, "  --line N                              tmux/zellij: only line N (default: all lines joined)")
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, "Commands:")
+			fmt.Fprintln(os.Stderr, "  howl config show [--project DIR]      show merged config and where each value came from")
+			fmt.Fprintln(os.Stderr, "  howl config validate [--project DIR]  report config problems, exit 1 if any")
+			fmt.Fprintln(os.Stderr, "  howl doctor                           check the installation and environment")
+			fmt.Fprintln(os.Stderr, "  howl preview [--scenario S|all] [--preset X] [--config FILE] [--columns N]")
+			fmt.Fprintln(os.Stderr, "                                        render a synthetic session with your config")
+			fmt.Fprintln(os.Stderr, "  howl ledger [--by day|project|model|account] [--days N]")
+			fmt.Fprintln(os.Stderr, "                                        summarize spend across sessions")
 			os.Exit(0)
+		case "config":
+			os.Exit(runConfig(os.Args[2:], os.Stdout, os.Stderr))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:], os.Stdout, os.Stderr))
+		case "preview":
+			os.Exit(runPreview(os.Args[2:], os.Stdout, os.Stderr))
+		case "ledger":
+			os.Exit(runLedger(os.Args[2:], os.Stdout, os.Stderr))
 		}
 	}
 
-	var data internal.StdinData
-	if err := json.NewDecoder(os.Stdin).Decode(&data); err != nil {
-		fmt.Fprint(os.Stderr, "howl: stdin parse error")
-		os.Exit(1)
+	fs := flag.NewFlagSet("howl", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text, json, tmux or zellij")
+	session := fs.String("session", "", "render a stored session snapshot (session id or latest) instead of stdin")
+	snapshotDir := fs.String("dir", "", "with --session latest: newest session whose project contains DIR")
+	line := fs.Int("line", 0, "tmux/zellij: render only line N (default: all lines joined)")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	muxFormats := map[string]internal.MuxFormat{"tmux": internal.MuxTmux, "zellij": internal.MuxZellij}
+	if _, isMux := muxFormats[*format]; !isMux && *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "howl: unknown format %q (want text, json, tmux or zellij)\n", *format)
+		os.Exit(2)
 	}
 
-	cfg := internal.LoadConfig()
-	metrics := internal.ComputeMetrics(&data)
+	var data internal.StdinData
+	if *session != "" {
+		// tmux and zellij run howl on their own schedule, so read the last
+		// stdin Claude Code piped for the session instead.
+		d, err := loadSessionSnapshot(*session, *snapshotDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "howl: %v\n", err)
+			os.Exit(1)
+		}
+		data = *d
+	} else {
+		if err := json.NewDecoder(os.Stdin).Decode(&data); err != nil {
+			fmt.Fprint(os.Stderr, "howl: stdin parse error")
+			os.Exit(1)
+		}
+		if *format == "text" && data.SessionID != "" {
+			// Best effort: a failed write only affects tmux/zellij readers.
+			_ = internal.SaveSnapshot(internal.SnapshotDir(), &data)
+		}
+	}
 
 	dir := data.Workspace.ProjectDir
 	if dir == "" {
 		dir = data.CWD
 	}
+
+	cfg, problems := internal.LoadConfigForProject(dir)
+
+	// Only the live statusline records history; other readers just look.
+	live := *format == "text" && *session == ""
+	var history *internal.SessionState
+	if data.SessionID != "" {
+		history, _ = internal.LoadState(internal.StateDir(), data.SessionID)
+	}
+	// A cost estimate continues the one in history, so it runs before the
+	// sample is recorded and before anything reads the cost.
+	costEstimate := internal.EstimateCost(&data, history, cfg.Pricing)
+	if live && history != nil {
+		_ = history.Record(internal.StateDir(), &data, costEstimate, time.Now())
+	}
+	metrics := internal.ComputeMetrics(&data)
+	internal.ApplyCostEstimate(&metrics, &data, costEstimate)
+	internal.ApplyHistory(&metrics, history, cfg.Thresholds)
+	internal.ApplyCacheSavings(&metrics, &data, history, cfg.Pricing)
+
 	git := internal.GetGitInfo(dir)
 
-	// Quota comes directly from stdin rate_limits (optional, subscriber-only)
+	// Get account info (optional)
+	account := internal.GetAccountInfo()
+
+	// Quota comes directly from stdin rate_limits (optional, subscriber-only).
+	// Its exhaustion projection uses the history all of the account's
+	// sessions share.
 	usage := internal.UsageFromRateLimits(data.RateLimits)
+	if usage != nil {
+		now := time.Now()
+		key := internal.QuotaAccountKey(account)
+		var quota *internal.QuotaHistory
+		if live {
+			quota, _ = internal.RecordQuota(internal.QuotaDir(), key, data.RateLimits, now)
+		} else {
+			quota, _ = internal.LoadQuotaHistory(internal.QuotaDir(), key)
+		}
+		internal.ProjectQuota(usage, quota, now)
+	}
 
 	// Parse transcript for tools/agents (optional)
-	toolInfo := internal.ParseTranscript(data.TranscriptPath)
+	toolInfo := internal.ParseTranscript(internal.TranscriptIndexDir(), data.TranscriptPath)
+	internal.ApplyCompactions(&metrics, history, toolInfo)
 
-	// Get account info (optional)
-	account := internal.GetAccountInfo()
+	// The live statusline records spend for the cross-session ledger.
+	if live && data.SessionID != "" {
+		var email string
+		if account != nil {
+			email = account.EmailAddress
+		}
+		_ = internal.RecordLedger(internal.LedgerDir(), &data, costEstimate, email, time.Now())
+	}
+	var ledger *internal.LedgerTotals
+	if cfg.NeedsLedger() {
+		if totals, err := internal.LoadLedgerTotals(internal.LedgerDir(), time.Now(), dir); err == nil {
+			ledger = &totals
+		}
+	}
 
-	lines := internal.Render(internal.RenderContext{
-		Data:    &data,
-		Metrics: metrics,
-		Git:     git,
-		Usage:   usage,
-		Tools:   toolInfo,
-		Account: account,
-		Config:  cfg,
-	})
+	rc := internal.RenderContext{
+		Data:           &data,
+		Metrics:        metrics,
+		Git:            git,
+		Usage:          usage,
+		Tools:          toolInfo,
+		Account:        account,
+		Config:         cfg,
+		ConfigProblems: problems,
+		History:        history,
+		Ledger:         ledger,
+		CostEstimate:   costEstimate,
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(internal.BuildStatusReport(rc, time.Now())); err != nil {
+			fmt.Fprintf(os.Stderr, "howl: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	lines := internal.Render(rc)
+
+	// Multiplexer status bars take inline #[...] styles and show spaces as-is.
+	if mf, ok := muxFormats[*format]; ok {
+		fmt.Println(internal.MuxStatus(lines, mf, *line))
+		return
+	}
 
 	// Output each line individually with:
 	// 1. RESET prefix to clear ANSI state
@@ -70,3 +191,13 @@ func main() {
 		fmt.Println(internal.Reset + line)
 	}
 }
+
+// loadSessionSnapshot resolves --session: a session id, or "latest" for the
+// most recently updated session (limited to dir when set).
+func loadSessionSnapshot(session, dir string) (*internal.StdinData, error) {
+	snapDir := internal.SnapshotDir()
+	if session == "latest" {
+		return internal.LatestSnapshot(snapDir, dir)
+	}
+	return internal.LoadSnapshot(snapDir, session)
+}
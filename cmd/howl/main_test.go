@@ -2,10 +2,12 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -33,11 +35,19 @@ func TestMain(m *testing.M) {
 }
 
 func runBinary(t *testing.T, stdin string, args ...string) (stdout, stderr string, exitCode int) {
+	t.Helper()
+	// Isolate HOME to prevent loading real config
+	return runBinaryEnv(t, []string{"HOME=" + t.TempDir()}, stdin, args...)
+}
+
+// runBinaryEnv runs the binary with extra environment variables, which take
+// precedence over the inherited environment.
+func runBinaryEnv(t *testing.T, env []string, stdin string, args ...string) (stdout, stderr string, exitCode int) {
 	t.Helper()
 	cmd := exec.Command(binaryPath, args...)
 	cmd.Stdin = strings.NewReader(stdin)
-	// Isolate HOME to prevent loading real config
-	cmd.Env = append(os.Environ(), "HOME="+t.TempDir())
+	cmd.Env = append(os.Environ(), "XDG_CONFIG_HOME=", "HOWL_CONFIG=")
+	cmd.Env = append(cmd.Env, env...)
 
 	var outBuf, errBuf bytes.Buffer
 	cmd.Stdout = &outBuf
@@ -335,3 +345,459 @@ func TestE2E_EmptyJSON(t *testing.T) {
 		t.Error("stdout is empty, want some output (no panic)")
 	}
 }
+
+// writeHomeConfig creates a HOME with ~/.claude/hud/config.json set to content.
+func writeHomeConfig(t *testing.T, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	configDir := filepath.Join(home, ".claude", "hud")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestE2E_ConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		config   string
+		wantExit int
+		wantOut  []string
+	}{
+		{"valid", `{"preset":"developer"}`, 0, []string{"config OK"}},
+		{"syntax error", "{\n  \"preset\": \"full\",\n}", 1, []string{"line 3, column 1", "1 problem(s)"}},
+		{"unknown key and clamp", `{"presett":"full","thresholds":{"context_danger":150}}`, 1, []string{`unknown key "presett"`, "clamped to 100", "2 problem(s)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			home := writeHomeConfig(t, tt.config)
+			stdout, _, exitCode := runBinaryEnv(t, []string{"HOME=" + home}, "", "config", "validate", "--project", t.TempDir())
+
+			if exitCode != tt.wantExit {
+				t.Errorf("exitCode = %d, want %d\n%s", exitCode, tt.wantExit, stdout)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("stdout missing %q:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestE2E_ConfigShow(t *testing.T) {
+	t.Parallel()
+
+	home := writeHomeConfig(t, `{"preset":"developer","thresholds":{"context_danger":90}}`)
+	stdout, _, exitCode := runBinaryEnv(t, []string{"HOME=" + home, "HOWL_FEATURES=quota"}, "", "config", "show", "--project", t.TempDir())
+
+	if exitCode != 0 {
+		t.Fatalf("exitCode = %d, want 0", exitCode)
+	}
+	for _, re := range []string{
+		`preset\s+user\s+"developer"`,
+		`features\.quota\s+env\s+true`,
+		`features\.git\s+preset:developer\s+true`,
+		`thresholds\.context_danger\s+user\s+90`,
+		`thresholds\.context_warning\s+default\s+70`,
+	} {
+		if !regexp.MustCompile(re).MatchString(stdout) {
+			t.Errorf("stdout should match %s:\n%s", re, stdout)
+		}
+	}
+}
+
+func TestE2E_ConfigUsage(t *testing.T) {
+	t.Parallel()
+
+	_, stderr, exitCode := runBinary(t, "", "config")
+	if exitCode != 2 || !strings.Contains(stderr, "usage") {
+		t.Errorf("bare config: exit=%d stderr=%q, want 2 and usage", exitCode, stderr)
+	}
+
+	_, stderr, exitCode = runBinary(t, "", "config", "lint")
+	if exitCode != 2 || !strings.Contains(stderr, "unknown command") {
+		t.Errorf("unknown subcommand: exit=%d stderr=%q, want 2 and error", exitCode, stderr)
+	}
+}
+
+func TestE2E_ConfigWarningSegment(t *testing.T) {
+	t.Parallel()
+
+	input := `{"model": {"display_name": "Sonnet"}, "context_window": {"context_window_size": 200000}}`
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"malformed", `{"preset": "full",}`, "⚙!"},
+		{"corrected threshold", `{"thresholds": {"context_danger": 150}}`, "⚙!"},
+		{"typo'd key", `{"features": {"acount": true}}`, "⚙?"},
+		{"valid", `{"preset": "developer"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			home := writeHomeConfig(t, tt.config)
+			stdout, _, exitCode := runBinaryEnv(t, []string{"HOME=" + home}, input)
+			if exitCode != 0 {
+				t.Fatalf("exitCode = %d, want 0", exitCode)
+			}
+			firstLine, _, _ := strings.Cut(stdout, "\n")
+			if tt.want == "" {
+				if strings.Contains(firstLine, "⚙") {
+					t.Errorf("line 1 should have no config warning: %q", firstLine)
+				}
+			} else if !strings.Contains(firstLine, tt.want) {
+				t.Errorf("line 1 missing %q: %q", tt.want, firstLine)
+			}
+		})
+	}
+}
+
+func TestE2E_MissingExplicitConfig(t *testing.T) {
+	t.Parallel()
+
+	env := []string{"HOME=" + t.TempDir(), "HOWL_CONFIG=" + filepath.Join(t.TempDir(), "missing.json")}
+	stdout, _, exitCode := runBinaryEnv(t, env, "", "config", "validate")
+	if exitCode != 1 || !strings.Contains(stdout, "HOWL_CONFIG: file not found") {
+		t.Errorf("validate exit %d, want 1 with the missing file reported:\n%s", exitCode, stdout)
+	}
+
+	input := `{"model": {"display_name": "Sonnet"}, "context_window": {"context_window_size": 200000}}`
+	stdout, _, _ = runBinaryEnv(t, env, input)
+	if firstLine, _, _ := strings.Cut(stdout, "\n"); !strings.Contains(firstLine, "⚙!") {
+		t.Errorf("line 1 should show ⚙!: %q", firstLine)
+	}
+}
+
+func TestE2E_Doctor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings string
+		wantExit int
+		wantOut  []string
+	}{
+		{
+			name:     "healthy install",
+			settings: fmt.Sprintf(`{"statusLine": {"type": "command", "command": %q}}`, binaryPath),
+			wantExit: 0,
+			wantOut:  []string{"✓ pass  statusline", "! warn  version", "development build"},
+		},
+		{
+			name:     "missing statusLine",
+			settings: `{}`,
+			wantExit: 1,
+			wantOut:  []string{"✗ fail  statusline", "→ add \"statusLine\""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			home := writeHomeConfig(t, `{"preset":"full"}`)
+			if err := os.WriteFile(filepath.Join(home, ".claude", "settings.json"), []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+			pluginRoot := filepath.Join(home, "plugin")
+			os.MkdirAll(filepath.Join(pluginRoot, ".claude-plugin"), 0755)
+			os.WriteFile(filepath.Join(pluginRoot, ".claude-plugin", "plugin.json"), []byte(`{"version":"1.9.0"}`), 0644)
+
+			stdout, _, exitCode := runBinaryEnv(t, []string{"HOME=" + home, "COLUMNS=120"}, "", "doctor", "--plugin-root", pluginRoot, "--project", t.TempDir())
+			if exitCode != tt.wantExit {
+				t.Errorf("exitCode = %d, want %d\n%s", exitCode, tt.wantExit, stdout)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("stdout missing %q:\n%s", want, stdout)
+				}
+			}
+			if !strings.Contains(stdout, "✓ pass  config") || !strings.Contains(stdout, "✓ pass  columns") {
+				t.Errorf("config and columns should pass:\n%s", stdout)
+			}
+		})
+	}
+}
+
+func TestE2E_Preview(t *testing.T) {
+	t.Parallel()
+
+	t.Run("default scenario", func(t *testing.T) {
+		t.Parallel()
+		stdout, _, exitCode := runBinary(t, "", "preview", "--project", t.TempDir())
+		if exitCode != 0 {
+			t.Fatalf("exitCode = %d, want 0", exitCode)
+		}
+		if lines := countNonEmptyLines(stdout); lines != 4 {
+			t.Errorf("normal scenario with full preset should render 4 lines, got %d:\n%s", lines, stdout)
+		}
+		if strings.Contains(stdout, "\u00A0") {
+			t.Errorf("preview is for terminals and should not use NBSP")
+		}
+	})
+
+	t.Run("preset flag", func(t *testing.T) {
+		t.Parallel()
+		stdout, _, _ := runBinary(t, "", "preview", "--preset", "minimal", "--project", t.TempDir())
+		if strings.Contains(stdout, "user@example.com") || strings.Contains(stdout, "Read") {
+			t.Errorf("minimal preset should hide account and tools:\n%s", stdout)
+		}
+	})
+
+	t.Run("config flag", func(t *testing.T) {
+		t.Parallel()
+		config := filepath.Join(t.TempDir(), "try.json")
+		os.WriteFile(config, []byte(`{"preset":"minimal","layout":{"normal":[["cost","model"]]}}`), 0644)
+		stdout, _, _ := runBinary(t, "", "preview", "--config", config, "--project", t.TempDir())
+		if countNonEmptyLines(stdout) != 1 || !strings.Contains(stdout, "$1.84") {
+			t.Errorf("config file layout should apply:\n%s", stdout)
+		}
+	})
+
+	t.Run("all scenarios", func(t *testing.T) {
+		t.Parallel()
+		stdout, _, _ := runBinary(t, "", "preview", "--scenario", "all", "--columns", "100", "--project", t.TempDir())
+		for _, name := range []string{"normal", "danger", "quota-low", "no-git", "1m-context"} {
+			if !strings.Contains(stdout, "── "+name+":") {
+				t.Errorf("missing scenario header %q:\n%s", name, stdout)
+			}
+		}
+	})
+
+	t.Run("unknown scenario", func(t *testing.T) {
+		t.Parallel()
+		_, stderr, exitCode := runBinary(t, "", "preview", "--scenario", "bogus")
+		if exitCode != 2 || !strings.Contains(stderr, "unknown scenario") {
+			t.Errorf("exit=%d stderr=%q, want 2 and unknown scenario", exitCode, stderr)
+		}
+	})
+}
+
+func TestE2E_FormatJSON(t *testing.T) {
+	t.Parallel()
+
+	input := `{
+		"session_id": "abc",
+		"model": {"id": "claude-opus-4-6", "display_name": "Opus 4.6"},
+		"cost": {"total_cost_usd": 6.5, "total_duration_ms": 600000, "total_api_duration_ms": 120000},
+		"context_window": {"context_window_size": 200000, "used_percentage": 72},
+		"rate_limits": {"five_hour": {"used_percentage": 40, "resets_at": 4102444800}}
+	}`
+
+	stdout, _, exitCode := runBinary(t, input, "--format", "json")
+	if exitCode != 0 {
+		t.Fatalf("exitCode = %d, want 0", exitCode)
+	}
+	if strings.Contains(stdout, "\033[") {
+		t.Errorf("JSON output should not contain ANSI codes")
+	}
+
+	var report struct {
+		Mode    string `json:"mode"`
+		Session struct {
+			SessionID string `json:"session_id"`
+		} `json:"session"`
+		Metrics struct {
+			ContextPercent int `json:"context_percent"`
+			APIWaitRatio   int `json:"api_wait_ratio"`
+		} `json:"metrics"`
+		Severity map[string]*string `json:"severity"`
+		Usage    struct {
+			FiveHour struct {
+				ResetsAt string `json:"resets_at"`
+			} `json:"five_hour"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+	}
+	if report.Mode != "normal" || report.Session.SessionID != "abc" || report.Metrics.ContextPercent != 72 || report.Metrics.APIWaitRatio != 20 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if s := report.Severity["context"]; s == nil || *s != "warning" {
+		t.Errorf("context severity = %v, want warning", s)
+	}
+	if s := report.Severity["session_cost"]; s == nil || *s != "critical" {
+		t.Errorf("session_cost severity = %v, want critical", s)
+	}
+	if report.Usage.FiveHour.ResetsAt != "2100-01-01T00:00:00Z" {
+		t.Errorf("resets_at = %q, want absolute RFC 3339 time", report.Usage.FiveHour.ResetsAt)
+	}
+}
+
+func TestE2E_FormatUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, stderr, exitCode := runBinary(t, `{}`, "--format", "xml")
+	if exitCode != 2 || !strings.Contains(stderr, "unknown format") {
+		t.Errorf("exit=%d stderr=%q, want 2 and unknown format", exitCode, stderr)
+	}
+}
+
+func TestE2E_FormatTmuxFromSnapshot(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	env := []string{"HOME=" + home}
+	input := `{
+		"session_id": "tmux-1",
+		"model": {"display_name": "Opus 4.6"},
+		"workspace": {"project_dir": "/work/app"},
+		"context_window": {"context_window_size": 200000, "used_percentage": 42}
+	}`
+
+	// A normal statusline run stores the snapshot.
+	if _, _, exitCode := runBinaryEnv(t, env, input); exitCode != 0 {
+		t.Fatalf("text run exitCode = %d", exitCode)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".claude", "hud", "snapshots", "tmux-1.json")); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"--format", "tmux", "--session", "tmux-1"},
+		{"--format", "tmux", "--session", "latest", "--dir", "/work/app/cmd"},
+	} {
+		stdout, stderr, exitCode := runBinaryEnv(t, env, "", args...)
+		if exitCode != 0 {
+			t.Fatalf("%v: exit=%d stderr=%q", args, exitCode, stderr)
+		}
+		out := strings.TrimRight(stdout, "\n")
+		if strings.Contains(out, "\n") || strings.Contains(out, "\033") || strings.Contains(out, "\u00A0") {
+			t.Errorf("%v: want one line without SGR or NBSP, got %q", args, out)
+		}
+		if !strings.Contains(out, "Opus 4.6") || !strings.Contains(out, "#[fg=") {
+			t.Errorf("%v: unexpected output %q", args, out)
+		}
+	}
+
+	_, stderr, exitCode := runBinaryEnv(t, env, "", "--format", "zellij", "--session", "latest", "--dir", "/elsewhere")
+	if exitCode != 1 || !strings.Contains(stderr, "no session snapshot") {
+		t.Errorf("unmatched dir: exit=%d stderr=%q, want 1 and no snapshot", exitCode, stderr)
+	}
+}
+
+func TestE2E_StateRecorded(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	env := []string{"HOME=" + home}
+	input := `{"session_id": "hist-1", "cost": {"total_cost_usd": 0.5}, "context_window": {"used_percentage": 30}}`
+	path := filepath.Join(home, ".claude", "hud", "state", "hist-1.json")
+
+	// JSON output only reads history.
+	if _, _, exitCode := runBinaryEnv(t, env, input, "--format", "json"); exitCode != 0 {
+		t.Fatalf("json run exitCode = %d", exitCode)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("--format json should not record state, stat err = %v", err)
+	}
+
+	if _, _, exitCode := runBinaryEnv(t, env, input); exitCode != 0 {
+		t.Fatalf("text run exitCode = %d", exitCode)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("state not written: %v", err)
+	}
+	var st struct {
+		Samples []struct {
+			Ctx  float64 `json:"ctx"`
+			Cost float64 `json:"cost"`
+		} `json:"samples"`
+	}
+	if err := json.Unmarshal(data, &st); err != nil || len(st.Samples) != 1 || st.Samples[0].Ctx != 30 || st.Samples[0].Cost != 0.5 {
+		t.Errorf("state = %s (err %v), want one sample", data, err)
+	}
+}
+
+func TestE2E_Ledger(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	env := []string{"HOME=" + home}
+	for _, in := range []string{
+		`{"session_id": "led-1", "model": {"display_name": "Opus"}, "workspace": {"project_dir": "/work/app"}, "cost": {"total_cost_usd": 1.5}}`,
+		`{"session_id": "led-1", "model": {"display_name": "Opus"}, "workspace": {"project_dir": "/work/app"}, "cost": {"total_cost_usd": 2.25}}`,
+		`{"session_id": "led-2", "model": {"display_name": "Sonnet"}, "workspace": {"project_dir": "/work/lib"}, "cost": {"total_cost_usd": 0.5}}`,
+	} {
+		if _, stderr, exitCode := runBinaryEnv(t, env, in); exitCode != 0 {
+			t.Fatalf("statusline run exitCode = %d, stderr: %s", exitCode, stderr)
+		}
+	}
+
+	stdout, stderr, exitCode := runBinaryEnv(t, env, "", "ledger", "--by", "project")
+	if exitCode != 0 {
+		t.Fatalf("ledger exitCode = %d, stderr: %s", exitCode, stderr)
+	}
+	for _, want := range []string{"/work/app", "$2.25", "/work/lib", "$0.50", "TOTAL", "$2.75"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("ledger output missing %q:\n%s", want, stdout)
+		}
+	}
+
+	// The today segment reads the same ledger.
+	stdout, _, _ = runBinaryEnv(t, append(env, "HOWL_FEATURES=ledger"),
+		`{"session_id": "led-2", "cost": {"total_cost_usd": 0.5}}`)
+	if !strings.Contains(stdout, "$2.75") || !strings.Contains(stdout, "today") {
+		t.Errorf("statusline missing ledger total:\n%s", stdout)
+	}
+
+	if _, _, exitCode := runBinaryEnv(t, env, "", "ledger", "--by", "week"); exitCode != 2 {
+		t.Errorf("unknown group exitCode = %d, want 2", exitCode)
+	}
+}
+
+func TestE2E_EstimatedCost(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	env := []string{"HOME=" + home}
+	bedrock := `{"session_id": "est-1", "model": {"id": "us.anthropic.claude-sonnet-4-20250514-v1:0", "display_name": "Sonnet 4"},
+		"cost": {"total_cost_usd": 99}, "context_window": {"total_input_tokens": %d, "total_output_tokens": 100000}}`
+
+	stdout, _, exitCode := runBinaryEnv(t, env, fmt.Sprintf(bedrock, 1000000))
+	if exitCode != 0 || !strings.Contains(stdout, "$4.50") || !strings.Contains(stdout, "est.") {
+		t.Errorf("Bedrock cost should be estimated at list price, exit %d:\n%s", exitCode, stdout)
+	}
+
+	// The estimate continues from the recorded state: only 1M new input tokens.
+	stdout, _, _ = runBinaryEnv(t, env, fmt.Sprintf(bedrock, 2000000), "--format", "json")
+	var report struct {
+		Session struct {
+			Cost struct {
+				TotalCostUSD float64 `json:"total_cost_usd"`
+			} `json:"cost"`
+			CostEstimated    bool     `json:"cost_estimated"`
+			EstimatedCostUSD *float64 `json:"estimated_cost_usd"`
+		} `json:"session"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, stdout)
+	}
+	s := report.Session
+	if !s.CostEstimated || s.EstimatedCostUSD == nil || *s.EstimatedCostUSD != 7.5 || s.Cost.TotalCostUSD != 99 {
+		t.Errorf("report session = %+v, want an estimated $7.50 next to the reported $99", s)
+	}
+
+	// The ledger records the estimate and flags it as one.
+	data, err := os.ReadFile(filepath.Join(home, ".claude", "hud", "ledger", "est-1.json"))
+	if err != nil || !strings.Contains(string(data), `"last_cost":4.5`) || !strings.Contains(string(data), `"estimated":true`) {
+		t.Errorf("ledger record = %s, %v; want an estimated $4.50", data, err)
+	}
+
+	stdout, _, _ = runBinaryEnv(t, append(env, "HOWL_PRICING_ESTIMATE=never"), fmt.Sprintf(bedrock, 2000000))
+	if !strings.Contains(stdout, "$99") || strings.Contains(stdout, "est.") {
+		t.Errorf("never mode should show the reported cost:\n%s", stdout)
+	}
+}
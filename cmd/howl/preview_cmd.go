@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ai-screams/howl/internal"
+)
+
+// runPreview handles "howl preview": renders built-in synthetic sessions with
+// the effective config (optionally a different preset, config file or width)
+// so a config change can be checked without a running Claude Code session.
+func runPreview(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	scenario := fs.String("scenario", internal.DefaultScenario, "scenario to render: "+strings.Join(internal.ScenarioNames(), ", ")+", or all")
+	preset := fs.String("preset", "", "preset to render with (overrides config)")
+	configPath := fs.String("config", "", "config file to use instead of the user config")
+	columns := fs.Int("columns", 0, "terminal width (default: $COLUMNS)")
+	project := fs.String("project", "", "project directory for .claude/howl.json (default: current directory)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	names := []string{*scenario}
+	if *scenario == "all" {
+		names = internal.ScenarioNames()
+	}
+	for _, name := range names {
+		if !slices.Contains(internal.ScenarioNames(), name) {
+			fmt.Fprintf(stderr, "howl preview: unknown scenario %q (want %s or all)\n", name, strings.Join(internal.ScenarioNames(), ", "))
+			return 2
+		}
+	}
+
+	// Flags map onto the config layers: --config replaces the user file,
+	// --preset and --columns act like their environment variables.
+	if *configPath != "" {
+		if _, err := os.Stat(*configPath); err != nil {
+			fmt.Fprintf(stderr, "howl preview: %v\n", err)
+			return 2
+		}
+		os.Setenv(internal.EnvConfig, *configPath)
+	}
+	if *preset != "" {
+		os.Setenv(internal.EnvPreset, *preset)
+	}
+	if *columns > 0 {
+		os.Setenv("COLUMNS", strconv.Itoa(*columns))
+	}
+	dir := *project
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	cfg, problems := internal.LoadConfigForProject(dir)
+	for _, p := range problems {
+		fmt.Fprintf(stderr, "config: %s\n", p)
+	}
+
+	for i, name := range names {
+		rc, _ := internal.PreviewContext(name, time.Now(), cfg)
+		rc.ConfigProblems = problems
+		if len(names) > 1 {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			fmt.Fprintf(stdout, "── %s: %s (preset %s)\n", name, internal.ScenarioDescription(name), cfg.Preset)
+		}
+		for _, line := range internal.Render(rc) {
+			fmt.Fprintln(stdout, internal.Reset+line)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Budget is one configured spending limit and what has been spent against
+// it.
+type Budget struct {
+	Name     string   `json:"name"` // "session", "today", "month", "<project> today", "<project> month"
+	SpentUSD float64  `json:"spent_usd"`
+	LimitUSD float64  `json:"limit_usd"`
+	Severity Severity `json:"severity"`
+}
+
+// UsedPercent is the share of the budget spent, in percent (over 100 once
+// exceeded).
+func (b Budget) UsedPercent() float64 {
+	return b.SpentUSD / b.LimitUSD * 100
+}
+
+// activeBudgets lists the budgets set in t that have data to compare
+// against: the session cost (estimate when set, see SessionCost) always, day
+// and month budgets only with ledger totals. Project budgets are labeled with
+// the project directory's name.
+func activeBudgets(d *StdinData, estimate *float64, l *LedgerTotals, t Thresholds) []Budget {
+	var budgets []Budget
+	add := func(name string, spent, limit float64) {
+		if limit <= 0 {
+			return
+		}
+		b := Budget{Name: name, SpentUSD: spent, LimitUSD: limit}
+		b.Severity = budgetSeverity(b.UsedPercent())
+		budgets = append(budgets, b)
+	}
+	add("session", SessionCost(d, estimate), t.BudgetSession)
+	if l != nil {
+		add("today", l.Today, t.BudgetDay)
+		add("month", l.Month, t.BudgetMonth)
+		project := d.Workspace.ProjectDir
+		if project == "" {
+			project = d.CWD
+		}
+		name := filepath.Base(project)
+		if project == "" {
+			name = "project"
+		}
+		add(name+" today", l.ProjectToday, t.BudgetProjectDay)
+		add(name+" month", l.ProjectMonth, t.BudgetProjectMonth)
+	}
+	return budgets
+}
+
+// budgetDanger reports whether any budget has reached t.BudgetDanger percent.
+// Off when BudgetDanger is 0.
+func budgetDanger(budgets []Budget, t Thresholds) bool {
+	if t.BudgetDanger <= 0 {
+		return false
+	}
+	for _, b := range budgets {
+		if b.UsedPercent() >= float64(t.BudgetDanger) {
+			return true
+		}
+	}
+	return false
+}
+
+// budgetSeverity rates the share of a budget spent. The breakpoints are
+// fixed: the budget itself is the user's threshold.
+func budgetSeverity(usedPercent float64) Severity {
+	switch {
+	case usedPercent >= 100:
+		return SeverityCritical
+	case usedPercent >= 90:
+		return SeverityHigh
+	case usedPercent >= 75:
+		return SeverityWarning
+	case usedPercent >= 50:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
+
+// renderBudgets shows each budget as spent/limit colored by the share used,
+// e.g. "$12.40/$20 today $1.10/$5 session".
+func renderBudgets(budgets []Budget) string {
+	parts := make([]string, len(budgets))
+	for i, b := range budgets {
+		parts[i] = fmt.Sprintf("%s$%.2f/%s%s%s %s%s",
+			b.Severity.color(), b.SpentUSD, formatBudgetLimit(b.LimitUSD), Reset, grey, b.Name, Reset)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatBudgetLimit drops the cents of whole-dollar limits: "$20", "$7.50".
+func formatBudgetLimit(usd float64) string {
+	if usd == float64(int64(usd)) {
+		return fmt.Sprintf("$%d", int64(usd))
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
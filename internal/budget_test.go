@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActiveBudgets(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Workspace: Workspace{ProjectDir: "/work/app"}, Cost: Cost{TotalCostUSD: 4}}
+	th := DefaultThresholds()
+	th.BudgetSession = 5
+	th.BudgetDay = 20
+	th.BudgetProjectMonth = 100
+	ledger := &LedgerTotals{Today: 12.4, Month: 150, ProjectMonth: 95}
+
+	got := activeBudgets(d, nil, ledger, th)
+	want := []Budget{
+		{Name: "session", SpentUSD: 4, LimitUSD: 5, Severity: SeverityWarning},
+		{Name: "today", SpentUSD: 12.4, LimitUSD: 20, Severity: SeverityModerate},
+		{Name: "app month", SpentUSD: 95, LimitUSD: 100, Severity: SeverityHigh},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("activeBudgets = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("budget %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Without ledger totals only the session budget can be checked.
+	if got := activeBudgets(d, nil, nil, th); len(got) != 1 || got[0].Name != "session" {
+		t.Errorf("no ledger: %+v, want session only", got)
+	}
+	if got := activeBudgets(d, nil, ledger, DefaultThresholds()); len(got) != 0 {
+		t.Errorf("no budgets configured: %+v", got)
+	}
+}
+
+func TestBudgetDanger(t *testing.T) {
+	t.Parallel()
+
+	budgets := []Budget{{SpentUSD: 19, LimitUSD: 20}, {SpentUSD: 1, LimitUSD: 5}}
+	tests := []struct {
+		danger int
+		want   bool
+	}{
+		{0, false},   // off
+		{100, false}, // 95% spent
+		{90, true},
+	}
+	for _, tt := range tests {
+		th := DefaultThresholds()
+		th.BudgetDanger = tt.danger
+		if got := budgetDanger(budgets, th); got != tt.want {
+			t.Errorf("budget_danger %d: got %v, want %v", tt.danger, got, tt.want)
+		}
+	}
+}
+
+func TestBudgetSeverity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		used float64
+		want Severity
+	}{
+		{10, SeverityOK},
+		{50, SeverityModerate},
+		{80, SeverityWarning},
+		{95, SeverityHigh},
+		{100, SeverityCritical},
+		{250, SeverityCritical},
+	}
+	for _, tt := range tests {
+		if got := budgetSeverity(tt.used); got != tt.want {
+			t.Errorf("budgetSeverity(%v) = %s, want %s", tt.used, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBudgets(t *testing.T) {
+	t.Parallel()
+
+	got := renderBudgets([]Budget{
+		{Name: "today", SpentUSD: 12.4, LimitUSD: 20, Severity: SeverityModerate},
+		{Name: "session", SpentUSD: 8, LimitUSD: 7.5, Severity: SeverityCritical},
+	})
+	want := yellow + "$12.40/$20" + Reset + grey + " today" + Reset + " " +
+		boldRed + "$8.00/$7.50" + Reset + grey + " session" + Reset
+	if got != want {
+		t.Errorf("renderBudgets() = %q, want %q", got, want)
+	}
+	if got := renderBudgets(nil); got != "" {
+		t.Errorf("no budgets = %q, want empty", got)
+	}
+}
+
+func TestRender_BudgetTriggersDanger(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}, Cost: Cost{TotalCostUSD: 6}, ContextWindow: ContextWindow{ContextWindowSize: 200000}}
+	cfg := PresetConfig("full")
+	cfg.Thresholds.BudgetSession = 5
+	render := func(cfg Config) string {
+		return strings.Join(Render(RenderContext{Data: d, Metrics: Metrics{ContextPercent: 30}, Config: cfg}), "\n")
+	}
+
+	normal := render(cfg)
+	if !strings.Contains(normal, "$6.00/$5") || strings.Contains(normal, "left") {
+		t.Errorf("budget without budget_danger should stay in normal mode: %q", normal)
+	}
+	cfg.Thresholds.BudgetDanger = 100
+	danger := render(cfg)
+	if danger == normal || !strings.Contains(danger, "$6.00/$5") {
+		t.Errorf("exceeded budget should switch to danger mode: %q", danger)
+	}
+	// Only the budget tripped: the context keeps its normal bar, not the
+	// 🔴 "N left ~ETA" alarm meant for a full context.
+	if strings.Contains(danger, "🔴") || strings.Contains(danger, "left") || !strings.Contains(danger, "30% ( 60K/200K)") {
+		t.Errorf("budget-only danger at low context should show the normal context bar: %q", danger)
+	}
+
+	r := BuildStatusReport(RenderContext{Data: d, Metrics: Metrics{ContextPercent: 30}, Config: cfg}, time.Now())
+	if r.Mode != "danger" || len(r.Budgets) != 1 || r.Budgets[0].Severity != SeverityCritical {
+		t.Errorf("report mode %s budgets %+v, want danger with one critical budget", r.Mode, r.Budgets)
+	}
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,12 +13,15 @@ const maxConfigSize = 4096 // 4KB limit
 // Config represents user's statusline configuration.
 type Config struct {
 	Preset     string         `json:"preset"`
-	Features   FeatureToggles `json:"features"`   // v1.1: override preset base
+	Features   FeatureToggles `json:"features"`   // v1.1: preset base merged with overrides
 	Thresholds Thresholds     `json:"thresholds"` // v1.5: custom color/behavior thresholds
+	Layout     Layout         `json:"layout"`     // per-line segment ordering, see layout.go
+	Pricing    Pricing        `json:"pricing"`    // token-based cost estimation, see pricing.go
 }
 
-// Thresholds controls when colors and behavior modes change.
-// Zero values mean "use default" — only positive values override.
+// Thresholds controls when colors and behavior modes change. In config files
+// a zero value means "use default" (for budgets, no budget); see
+// ThresholdOverrides.
 type Thresholds struct {
 	ContextDanger      int     `json:"context_danger"`       // Context % to trigger danger mode (default 85)
 	ContextWarning     int     `json:"context_warning"`      // Context % to show warning (default 70)
@@ -34,6 +38,57 @@ type Thresholds struct {
 	QuotaLow           float64 `json:"quota_low"`            // Remaining % for red (default 25)
 	QuotaMedium        float64 `json:"quota_medium"`         // Remaining % for orange (default 50)
 	QuotaHigh          float64 `json:"quota_high"`           // Remaining % for yellow (default 75)
+	// Minutes of session history behind the windowed cost velocity, output
+	// throughput and context ETA (default 10, max 60).
+	VelocityWindowMinutes int `json:"velocity_window_minutes"`
+	// History samples each sparkline segment shows, one cell per sample
+	// (default 12, 2-60).
+	SparklineSamples int `json:"sparkline_samples"`
+	// Spending budgets in USD (default 0 = no budget). Day and month budgets
+	// count spend across all sessions, project budgets only this project's.
+	BudgetSession      float64 `json:"budget_session"`
+	BudgetDay          float64 `json:"budget_day"`
+	BudgetMonth        float64 `json:"budget_month"`
+	BudgetProjectDay   float64 `json:"budget_project_day"`
+	BudgetProjectMonth float64 `json:"budget_project_month"`
+	// Budget % used that triggers danger mode, like context_danger
+	// (default 0 = budgets never trigger it; 100 = once a budget is spent).
+	BudgetDanger int `json:"budget_danger"`
+	// Failed tool calls in a row that raise the failure streak warning
+	// (default 3).
+	ErrorStreak int `json:"error_streak"`
+}
+
+// ThresholdOverrides is the presence-aware form of Thresholds read from
+// config layers and the environment: an absent (nil) field keeps the value
+// of the layer below, a present one replaces it. A present zero or negative
+// value restores the built-in default, so a layer can turn off a budget or
+// undo another layer's threshold.
+type ThresholdOverrides struct {
+	ContextDanger         *int     `json:"context_danger"`
+	ContextWarning        *int     `json:"context_warning"`
+	ContextModerate       *int     `json:"context_moderate"`
+	SessionCostHigh       *float64 `json:"session_cost_high"`
+	SessionCostMedium     *float64 `json:"session_cost_medium"`
+	CacheExcellent        *int     `json:"cache_excellent"`
+	CacheGood             *int     `json:"cache_good"`
+	WaitHigh              *int     `json:"wait_high"`
+	WaitMedium            *int     `json:"wait_medium"`
+	CostVelocityHigh      *float64 `json:"cost_velocity_high"`
+	CostVelocityMedium    *float64 `json:"cost_velocity_medium"`
+	QuotaCritical         *float64 `json:"quota_critical"`
+	QuotaLow              *float64 `json:"quota_low"`
+	QuotaMedium           *float64 `json:"quota_medium"`
+	QuotaHigh             *float64 `json:"quota_high"`
+	VelocityWindowMinutes *int     `json:"velocity_window_minutes"`
+	SparklineSamples      *int     `json:"sparkline_samples"`
+	BudgetSession         *float64 `json:"budget_session"`
+	BudgetDay             *float64 `json:"budget_day"`
+	BudgetMonth           *float64 `json:"budget_month"`
+	BudgetProjectDay      *float64 `json:"budget_project_day"`
+	BudgetProjectMonth    *float64 `json:"budget_project_month"`
+	BudgetDanger          *int     `json:"budget_danger"`
+	ErrorStreak           *int     `json:"error_streak"`
 }
 
 // FeatureToggles controls which metrics are displayed.
@@ -50,6 +105,8 @@ type FeatureToggles struct {
 	CostVelocity    bool `json:"cost_velocity"`
 	VimMode         bool `json:"vim_mode"`
 	AgentName       bool `json:"agent_name"`
+	Throughput      bool `json:"throughput"`  // windowed output tokens/min (needs session history)
+	Compactions     bool `json:"compactions"` // context compaction count and age
 	// Optional CC 2.1 field renderers. Off in every preset by default to keep the
 	// normal layout uncluttered — opt in explicitly via config features override.
 	Effort      bool `json:"effort"`
@@ -57,6 +114,59 @@ type FeatureToggles struct {
 	SessionName bool `json:"session_name"`
 	PullRequest bool `json:"pull_request"`
 	Worktree    bool `json:"worktree"`
+	// VelocityAverage shows the lifetime $/min beside the windowed value.
+	VelocityAverage bool `json:"velocity_average"`
+	// Sparkline enables the context/cost/quota history sparkline segments.
+	Sparkline bool `json:"sparkline"`
+	// Ledger enables the cross-session spend segments (today/week/month).
+	Ledger bool `json:"ledger"`
+	// CacheSavings shows the dollars prompt caching saved (needs a model price).
+	CacheSavings bool `json:"cache_savings"`
+	// ToolErrors shows the session's tool failure rate.
+	ToolErrors bool `json:"tool_errors"`
+}
+
+// FeatureOverrides is the tri-state form of FeatureToggles read from config
+// files: an absent (nil) field keeps the preset value, an explicit true or
+// false replaces it. This lets "full minus account" be expressed directly.
+type FeatureOverrides struct {
+	Account         *bool `json:"account"`
+	Git             *bool `json:"git"`
+	LineChanges     *bool `json:"line_changes"`
+	OutputTokens    *bool `json:"output_tokens"`
+	Quota           *bool `json:"quota"`
+	Tools           *bool `json:"tools"`
+	Agents          *bool `json:"agents"`
+	CacheEfficiency *bool `json:"cache_efficiency"`
+	APIWaitRatio    *bool `json:"api_wait_ratio"`
+	CostVelocity    *bool `json:"cost_velocity"`
+	VimMode         *bool `json:"vim_mode"`
+	AgentName       *bool `json:"agent_name"`
+	Throughput      *bool `json:"throughput"`
+	Compactions     *bool `json:"compactions"`
+	Effort          *bool `json:"effort"`
+	Thinking        *bool `json:"thinking"`
+	SessionName     *bool `json:"session_name"`
+	PullRequest     *bool `json:"pull_request"`
+	Worktree        *bool `json:"worktree"`
+	VelocityAverage *bool `json:"velocity_average"`
+	Sparkline       *bool `json:"sparkline"`
+	Ledger          *bool `json:"ledger"`
+	CacheSavings    *bool `json:"cache_savings"`
+	ToolErrors      *bool `json:"tool_errors"`
+}
+
+// configFile is the on-disk shape of config.json. It differs from Config in
+// Features and Thresholds, which are overrides that record which keys a layer
+// set rather than resolved values, and in the legacy Priority list written by
+// older versions of /howl:customize, which resolveConfig migrates into Layout.
+type configFile struct {
+	Preset     string             `json:"preset"`
+	Features   FeatureOverrides   `json:"features"`
+	Thresholds ThresholdOverrides `json:"thresholds"`
+	Layout     Layout             `json:"layout"`
+	Pricing    Pricing            `json:"pricing"`
+	Priority   []string           `json:"priority"`
 }
 
 var presets = map[string]FeatureToggles{
@@ -73,6 +183,9 @@ var presets = map[string]FeatureToggles{
 		CostVelocity:    true,
 		VimMode:         true,
 		AgentName:       true,
+		Throughput:      true,
+		Compactions:     true,
+		ToolErrors:      true,
 	},
 	"minimal": {}, // all false
 	"developer": {
@@ -85,6 +198,8 @@ var presets = map[string]FeatureToggles{
 		Agents:          true,
 		CacheEfficiency: true,
 		VimMode:         true,
+		Compactions:     true,
+		ToolErrors:      true,
 	},
 	"cost-focused": {
 		Account:      true,
@@ -92,63 +207,86 @@ var presets = map[string]FeatureToggles{
 		Quota:        true,
 		APIWaitRatio: true,
 		CostVelocity: true,
+		Throughput:   true,
+		CacheSavings: true,
 	},
 }
 
-// mergeFeatures merges override into base. If override field is true, it overrides base.
-// If override field is false, base value is preserved (no reflection, explicit per field).
-func mergeFeatures(base, override FeatureToggles) FeatureToggles {
+// mergeFeatures merges override into base. A nil override field preserves the
+// base value; true or false replaces it (no reflection, explicit per field).
+func mergeFeatures(base FeatureToggles, override FeatureOverrides) FeatureToggles {
 	result := base
-	if override.Account {
-		result.Account = true
+	if override.Account != nil {
+		result.Account = *override.Account
+	}
+	if override.Git != nil {
+		result.Git = *override.Git
 	}
-	if override.Git {
-		result.Git = true
+	if override.LineChanges != nil {
+		result.LineChanges = *override.LineChanges
 	}
-	if override.LineChanges {
-		result.LineChanges = true
+	if override.OutputTokens != nil {
+		result.OutputTokens = *override.OutputTokens
 	}
-	if override.OutputTokens {
-		result.OutputTokens = true
+	if override.Quota != nil {
+		result.Quota = *override.Quota
 	}
-	if override.Quota {
-		result.Quota = true
+	if override.Tools != nil {
+		result.Tools = *override.Tools
 	}
-	if override.Tools {
-		result.Tools = true
+	if override.Agents != nil {
+		result.Agents = *override.Agents
 	}
-	if override.Agents {
-		result.Agents = true
+	if override.CacheEfficiency != nil {
+		result.CacheEfficiency = *override.CacheEfficiency
 	}
-	if override.CacheEfficiency {
-		result.CacheEfficiency = true
+	if override.APIWaitRatio != nil {
+		result.APIWaitRatio = *override.APIWaitRatio
 	}
-	if override.APIWaitRatio {
-		result.APIWaitRatio = true
+	if override.CostVelocity != nil {
+		result.CostVelocity = *override.CostVelocity
 	}
-	if override.CostVelocity {
-		result.CostVelocity = true
+	if override.VimMode != nil {
+		result.VimMode = *override.VimMode
 	}
-	if override.VimMode {
-		result.VimMode = true
+	if override.AgentName != nil {
+		result.AgentName = *override.AgentName
 	}
-	if override.AgentName {
-		result.AgentName = true
+	if override.Throughput != nil {
+		result.Throughput = *override.Throughput
 	}
-	if override.Effort {
-		result.Effort = true
+	if override.Compactions != nil {
+		result.Compactions = *override.Compactions
 	}
-	if override.Thinking {
-		result.Thinking = true
+	if override.Effort != nil {
+		result.Effort = *override.Effort
 	}
-	if override.SessionName {
-		result.SessionName = true
+	if override.Thinking != nil {
+		result.Thinking = *override.Thinking
 	}
-	if override.PullRequest {
-		result.PullRequest = true
+	if override.SessionName != nil {
+		result.SessionName = *override.SessionName
 	}
-	if override.Worktree {
-		result.Worktree = true
+	if override.PullRequest != nil {
+		result.PullRequest = *override.PullRequest
+	}
+	if override.Worktree != nil {
+		result.Worktree = *override.Worktree
+	}
+	if override.VelocityAverage != nil {
+		result.VelocityAverage = *override.VelocityAverage
+	}
+	if override.Sparkline != nil {
+		result.Sparkline = *override.Sparkline
+	}
+	if override.Ledger != nil {
+		result.Ledger = *override.Ledger
+	}
+	if override.CacheSavings != nil {
+		result.CacheSavings = *override.CacheSavings
+	}
+	if override.ToolErrors != nil {
+		result.ToolErrors = *override.ToolErrors
 	}
 	return result
 }
@@ -171,63 +309,186 @@ func DefaultThresholds() Thresholds {
 		QuotaLow:           QuotaLow,
 		QuotaMedium:        QuotaMedium,
 		QuotaHigh:          QuotaHigh,
+
+		VelocityWindowMinutes: VelocityWindowMinutes,
+		SparklineSamples:      SparklineSamples,
+		ErrorStreak:           ErrorStreak,
 	}
 }
 
-// mergeThresholds merges override into base. Only positive override values replace base.
-// Same explicit per-field pattern as mergeFeatures — no reflection.
-func mergeThresholds(base, override Thresholds) Thresholds {
+// mergeThresholds applies override to base. A present positive value replaces
+// the base value; a present zero or negative one restores the default (for
+// budgets, no budget). Same explicit per-field pattern as mergeFeatures — no
+// reflection.
+func mergeThresholds(base Thresholds, override ThresholdOverrides) Thresholds {
+	result := base
+	def := DefaultThresholds()
+	if override.ContextDanger != nil {
+		result.ContextDanger = positiveOr(*override.ContextDanger, def.ContextDanger)
+	}
+	if override.ContextWarning != nil {
+		result.ContextWarning = positiveOr(*override.ContextWarning, def.ContextWarning)
+	}
+	if override.ContextModerate != nil {
+		result.ContextModerate = positiveOr(*override.ContextModerate, def.ContextModerate)
+	}
+	if override.SessionCostHigh != nil {
+		result.SessionCostHigh = positiveOr(*override.SessionCostHigh, def.SessionCostHigh)
+	}
+	if override.SessionCostMedium != nil {
+		result.SessionCostMedium = positiveOr(*override.SessionCostMedium, def.SessionCostMedium)
+	}
+	if override.CacheExcellent != nil {
+		result.CacheExcellent = positiveOr(*override.CacheExcellent, def.CacheExcellent)
+	}
+	if override.CacheGood != nil {
+		result.CacheGood = positiveOr(*override.CacheGood, def.CacheGood)
+	}
+	if override.WaitHigh != nil {
+		result.WaitHigh = positiveOr(*override.WaitHigh, def.WaitHigh)
+	}
+	if override.WaitMedium != nil {
+		result.WaitMedium = positiveOr(*override.WaitMedium, def.WaitMedium)
+	}
+	if override.CostVelocityHigh != nil {
+		result.CostVelocityHigh = positiveOr(*override.CostVelocityHigh, def.CostVelocityHigh)
+	}
+	if override.CostVelocityMedium != nil {
+		result.CostVelocityMedium = positiveOr(*override.CostVelocityMedium, def.CostVelocityMedium)
+	}
+	if override.QuotaCritical != nil {
+		result.QuotaCritical = positiveOr(*override.QuotaCritical, def.QuotaCritical)
+	}
+	if override.QuotaLow != nil {
+		result.QuotaLow = positiveOr(*override.QuotaLow, def.QuotaLow)
+	}
+	if override.QuotaMedium != nil {
+		result.QuotaMedium = positiveOr(*override.QuotaMedium, def.QuotaMedium)
+	}
+	if override.QuotaHigh != nil {
+		result.QuotaHigh = positiveOr(*override.QuotaHigh, def.QuotaHigh)
+	}
+	if override.VelocityWindowMinutes != nil {
+		result.VelocityWindowMinutes = positiveOr(*override.VelocityWindowMinutes, def.VelocityWindowMinutes)
+	}
+	if override.SparklineSamples != nil {
+		result.SparklineSamples = positiveOr(*override.SparklineSamples, def.SparklineSamples)
+	}
+	if override.BudgetSession != nil {
+		result.BudgetSession = positiveOr(*override.BudgetSession, def.BudgetSession)
+	}
+	if override.BudgetDay != nil {
+		result.BudgetDay = positiveOr(*override.BudgetDay, def.BudgetDay)
+	}
+	if override.BudgetMonth != nil {
+		result.BudgetMonth = positiveOr(*override.BudgetMonth, def.BudgetMonth)
+	}
+	if override.BudgetProjectDay != nil {
+		result.BudgetProjectDay = positiveOr(*override.BudgetProjectDay, def.BudgetProjectDay)
+	}
+	if override.BudgetProjectMonth != nil {
+		result.BudgetProjectMonth = positiveOr(*override.BudgetProjectMonth, def.BudgetProjectMonth)
+	}
+	if override.BudgetDanger != nil {
+		result.BudgetDanger = positiveOr(*override.BudgetDanger, def.BudgetDanger)
+	}
+	if override.ErrorStreak != nil {
+		result.ErrorStreak = positiveOr(*override.ErrorStreak, def.ErrorStreak)
+	}
+	return result
+}
+
+// positiveOr returns v when positive, else def.
+func positiveOr[T int | float64](v, def T) T {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// mergeThresholdOverrides layers override on top of base, field by field.
+func mergeThresholdOverrides(base, override ThresholdOverrides) ThresholdOverrides {
 	result := base
-	if override.ContextDanger > 0 {
+	if override.ContextDanger != nil {
 		result.ContextDanger = override.ContextDanger
 	}
-	if override.ContextWarning > 0 {
+	if override.ContextWarning != nil {
 		result.ContextWarning = override.ContextWarning
 	}
-	if override.ContextModerate > 0 {
+	if override.ContextModerate != nil {
 		result.ContextModerate = override.ContextModerate
 	}
-	if override.SessionCostHigh > 0 {
+	if override.SessionCostHigh != nil {
 		result.SessionCostHigh = override.SessionCostHigh
 	}
-	if override.SessionCostMedium > 0 {
+	if override.SessionCostMedium != nil {
 		result.SessionCostMedium = override.SessionCostMedium
 	}
-	if override.CacheExcellent > 0 {
+	if override.CacheExcellent != nil {
 		result.CacheExcellent = override.CacheExcellent
 	}
-	if override.CacheGood > 0 {
+	if override.CacheGood != nil {
 		result.CacheGood = override.CacheGood
 	}
-	if override.WaitHigh > 0 {
+	if override.WaitHigh != nil {
 		result.WaitHigh = override.WaitHigh
 	}
-	if override.WaitMedium > 0 {
+	if override.WaitMedium != nil {
 		result.WaitMedium = override.WaitMedium
 	}
-	if override.CostVelocityHigh > 0 {
+	if override.CostVelocityHigh != nil {
 		result.CostVelocityHigh = override.CostVelocityHigh
 	}
-	if override.CostVelocityMedium > 0 {
+	if override.CostVelocityMedium != nil {
 		result.CostVelocityMedium = override.CostVelocityMedium
 	}
-	if override.QuotaCritical > 0 {
+	if override.QuotaCritical != nil {
 		result.QuotaCritical = override.QuotaCritical
 	}
-	if override.QuotaLow > 0 {
+	if override.QuotaLow != nil {
 		result.QuotaLow = override.QuotaLow
 	}
-	if override.QuotaMedium > 0 {
+	if override.QuotaMedium != nil {
 		result.QuotaMedium = override.QuotaMedium
 	}
-	if override.QuotaHigh > 0 {
+	if override.QuotaHigh != nil {
 		result.QuotaHigh = override.QuotaHigh
 	}
+	if override.VelocityWindowMinutes != nil {
+		result.VelocityWindowMinutes = override.VelocityWindowMinutes
+	}
+	if override.SparklineSamples != nil {
+		result.SparklineSamples = override.SparklineSamples
+	}
+	if override.BudgetSession != nil {
+		result.BudgetSession = override.BudgetSession
+	}
+	if override.BudgetDay != nil {
+		result.BudgetDay = override.BudgetDay
+	}
+	if override.BudgetMonth != nil {
+		result.BudgetMonth = override.BudgetMonth
+	}
+	if override.BudgetProjectDay != nil {
+		result.BudgetProjectDay = override.BudgetProjectDay
+	}
+	if override.BudgetProjectMonth != nil {
+		result.BudgetProjectMonth = override.BudgetProjectMonth
+	}
+	if override.BudgetDanger != nil {
+		result.BudgetDanger = override.BudgetDanger
+	}
+	if override.ErrorStreak != nil {
+		result.ErrorStreak = override.ErrorStreak
+	}
 	return result
 }
 
 // validateThresholds clamps all values to valid ranges, fixes inversions, and re-clamps.
-func validateThresholds(t *Thresholds) {
+// Returns one message per corrected value so callers can report what changed.
+func validateThresholds(t *Thresholds) []string {
+	before := thresholdValues(*t)
+
 	// Step 1: Clamp all values to valid ranges.
 
 	// Percentage-based: 0-100
@@ -251,6 +512,24 @@ func validateThresholds(t *Thresholds) {
 	t.QuotaMedium = max(0, min(t.QuotaMedium, 100))
 	t.QuotaHigh = max(0, min(t.QuotaHigh, 100))
 
+	// Velocity window: 1-60 minutes (the session history ring holds one hour)
+	t.VelocityWindowMinutes = max(1, min(t.VelocityWindowMinutes, 60))
+
+	// Sparklines: 2-60 cells, at least two to draw a trend
+	t.SparklineSamples = max(2, min(t.SparklineSamples, 60))
+
+	// Budgets: 0 (none) or positive; budget danger 0 (off) to 1000%
+	t.BudgetSession = max(0, t.BudgetSession)
+	t.BudgetDay = max(0, t.BudgetDay)
+	t.BudgetMonth = max(0, t.BudgetMonth)
+	t.BudgetProjectDay = max(0, t.BudgetProjectDay)
+	t.BudgetProjectMonth = max(0, t.BudgetProjectMonth)
+	t.BudgetDanger = max(0, min(t.BudgetDanger, 1000))
+
+	// Failure streak: 1-100 tool calls
+	t.ErrorStreak = max(1, min(t.ErrorStreak, 100))
+	clamped := thresholdValues(*t)
+
 	// Step 2: Fix inversions.
 
 	// Context: danger > warning > moderate
@@ -288,6 +567,34 @@ func validateThresholds(t *Thresholds) {
 	t.QuotaLow = max(0, min(t.QuotaLow, 100))
 	t.QuotaMedium = max(0, min(t.QuotaMedium, 100))
 	t.QuotaHigh = max(0, min(t.QuotaHigh, 100))
+
+	after := thresholdValues(*t)
+	var fixes []string
+	for _, key := range jsonKeys(*t) {
+		if before[key] != clamped[key] {
+			fixes = append(fixes, fmt.Sprintf("thresholds.%s: %g out of range, clamped to %g", key, before[key], clamped[key]))
+		}
+		if clamped[key] != after[key] {
+			fixes = append(fixes, fmt.Sprintf("thresholds.%s: %g inverted with its pair, adjusted to %g", key, clamped[key], after[key]))
+		}
+	}
+	return fixes
+}
+
+// thresholdValues returns t keyed by JSON name, for diffing before/after validation.
+func thresholdValues(t Thresholds) map[string]float64 {
+	data, _ := json.Marshal(t)
+	var m map[string]float64
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// NeedsLedger reports whether rendering needs cross-session spend totals:
+// the ledger segments are on or a day/month budget is set.
+func (c Config) NeedsLedger() bool {
+	t := c.Thresholds
+	return c.Features.Ledger || t.BudgetDay > 0 || t.BudgetMonth > 0 ||
+		t.BudgetProjectDay > 0 || t.BudgetProjectMonth > 0
 }
 
 // DefaultConfig returns the default configuration with full preset enabled.
@@ -300,42 +607,148 @@ func DefaultConfig() Config {
 func PresetConfig(name string) Config {
 	name = strings.ToLower(strings.TrimSpace(name))
 	if features, ok := presets[name]; ok {
-		return Config{Preset: name, Features: features, Thresholds: DefaultThresholds()}
+		return Config{Preset: name, Features: features, Thresholds: DefaultThresholds(), Layout: PresetLayout(name), Pricing: Pricing{Estimate: PricingAuto}}
 	}
-	return Config{Preset: "full", Features: presets["full"], Thresholds: DefaultThresholds()}
+	return Config{Preset: "full", Features: presets["full"], Thresholds: DefaultThresholds(), Layout: PresetLayout("full"), Pricing: Pricing{Estimate: PricingAuto}}
 }
 
-// LoadConfig loads configuration from ~/.claude/hud/config.json.
-// On any error (file not found, parse error, invalid preset), returns DefaultConfig().
-func LoadConfig() Config {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return DefaultConfig()
-	}
+// UserConfigPath returns the path of the user-level config file: $HOWL_CONFIG
+// when set, else the first existing of $XDG_CONFIG_HOME/howl/config.json and
+// ~/.claude/hud/config.json. Returns the last candidate when none exist, or ""
+// when the home directory is unknown.
+func UserConfigPath() string {
+	candidates := userConfigCandidates()
+	for _, p := range candidates {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[len(candidates)-1]
+}
 
-	path := filepath.Join(home, ".claude", "hud", "config.json")
+// ProjectConfigPath returns the path of the project-level config file,
+// <projectDir>/.claude/howl.json, or "" when projectDir is empty.
+func ProjectConfigPath(projectDir string) string {
+	if projectDir == "" {
+		return ""
+	}
+	return filepath.Join(projectDir, ".claude", "howl.json")
+}
 
-	// Guard: check file size before reading
-	stat, err := os.Stat(path)
-	if err != nil {
-		return DefaultConfig() // File not found
+// mergeConfigFiles layers override on top of base. Preset and priority are
+// replaced when set, features and thresholds merge per field, layout merges
+// per mode and pricing per model.
+func mergeConfigFiles(base, override configFile) configFile {
+	result := base
+	if strings.TrimSpace(override.Preset) != "" {
+		result.Preset = override.Preset
 	}
-	if stat.Size() > maxConfigSize {
-		return DefaultConfig() // Too large, DoS protection
+	result.Features = mergeFeatureOverrides(base.Features, override.Features)
+	result.Thresholds = mergeThresholdOverrides(base.Thresholds, override.Thresholds)
+	result.Layout = mergeLayout(base.Layout, override.Layout)
+	result.Pricing = mergePricing(base.Pricing, override.Pricing)
+	if len(override.Priority) > 0 {
+		result.Priority = override.Priority
 	}
+	return result
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return DefaultConfig()
+// mergeFeatureOverrides layers override on top of base, field by field.
+// Same explicit per-field pattern as mergeFeatures — no reflection.
+func mergeFeatureOverrides(base, override FeatureOverrides) FeatureOverrides {
+	result := base
+	if override.Account != nil {
+		result.Account = override.Account
+	}
+	if override.Git != nil {
+		result.Git = override.Git
+	}
+	if override.LineChanges != nil {
+		result.LineChanges = override.LineChanges
+	}
+	if override.OutputTokens != nil {
+		result.OutputTokens = override.OutputTokens
+	}
+	if override.Quota != nil {
+		result.Quota = override.Quota
+	}
+	if override.Tools != nil {
+		result.Tools = override.Tools
+	}
+	if override.Agents != nil {
+		result.Agents = override.Agents
+	}
+	if override.CacheEfficiency != nil {
+		result.CacheEfficiency = override.CacheEfficiency
 	}
+	if override.APIWaitRatio != nil {
+		result.APIWaitRatio = override.APIWaitRatio
+	}
+	if override.CostVelocity != nil {
+		result.CostVelocity = override.CostVelocity
+	}
+	if override.VimMode != nil {
+		result.VimMode = override.VimMode
+	}
+	if override.AgentName != nil {
+		result.AgentName = override.AgentName
+	}
+	if override.Throughput != nil {
+		result.Throughput = override.Throughput
+	}
+	if override.Compactions != nil {
+		result.Compactions = override.Compactions
+	}
+	if override.Effort != nil {
+		result.Effort = override.Effort
+	}
+	if override.Thinking != nil {
+		result.Thinking = override.Thinking
+	}
+	if override.SessionName != nil {
+		result.SessionName = override.SessionName
+	}
+	if override.PullRequest != nil {
+		result.PullRequest = override.PullRequest
+	}
+	if override.Worktree != nil {
+		result.Worktree = override.Worktree
+	}
+	if override.VelocityAverage != nil {
+		result.VelocityAverage = override.VelocityAverage
+	}
+	if override.Sparkline != nil {
+		result.Sparkline = override.Sparkline
+	}
+	if override.Ledger != nil {
+		result.Ledger = override.Ledger
+	}
+	if override.CacheSavings != nil {
+		result.CacheSavings = override.CacheSavings
+	}
+	if override.ToolErrors != nil {
+		result.ToolErrors = override.ToolErrors
+	}
+	return result
+}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return DefaultConfig() // Malformed JSON
+// resolveConfig turns a merged config layer into a Config: normalizes the
+// preset, applies feature overrides to its base, migrates a legacy priority
+// list and validates thresholds. Returns a problem for every value it had to
+// replace or correct and for every unknown layout segment.
+func resolveConfig(file configFile) (Config, []ConfigProblem) {
+	var fixes []ConfigProblem
+	corrected := func(msgs ...string) {
+		for _, msg := range msgs {
+			fixes = append(fixes, ConfigProblem{Source: "merged", Kind: ProblemCorrected, Message: msg})
+		}
 	}
 
 	// Normalize preset name: lowercase + trim whitespace
-	cfg.Preset = strings.ToLower(strings.TrimSpace(cfg.Preset))
+	cfg := Config{Preset: strings.ToLower(strings.TrimSpace(file.Preset))}
 	if cfg.Preset == "" {
 		cfg.Preset = "full"
 	}
@@ -343,17 +756,66 @@ func LoadConfig() Config {
 	// Get preset base
 	base, ok := presets[cfg.Preset]
 	if !ok {
-		// Unknown preset -> fallback to full silently
+		// Unknown preset -> fallback to full
+		corrected(fmt.Sprintf("preset: unknown preset %q, using \"full\"", cfg.Preset))
 		cfg.Preset = "full"
 		base = presets["full"]
 	}
 
-	// v1.1: merge features override into preset base
-	cfg.Features = mergeFeatures(base, cfg.Features)
+	// v1.1: merge features override into preset base (explicit false disables)
+	cfg.Features = mergeFeatures(base, file.Features)
 
 	// v1.5: merge thresholds override into defaults
-	cfg.Thresholds = mergeThresholds(DefaultThresholds(), cfg.Thresholds)
-	validateThresholds(&cfg.Thresholds)
+	cfg.Thresholds = mergeThresholds(DefaultThresholds(), file.Thresholds)
+	corrected(validateThresholds(&cfg.Thresholds)...)
+
+	// Layout override replaces whole modes of the preset's layout; unknown
+	// segment IDs are kept so the renderer can flag them in place.
+	cfg.Layout = mergeLayout(PresetLayout(cfg.Preset), file.Layout)
+	if len(file.Layout.Normal) == 0 {
+		// Legacy "priority" orders the preset layout; an explicit layout wins.
+		cfg.Layout.Normal = applyPriority(cfg.Layout.Normal, file.Priority)
+		if len(file.Layout.Compact) == 0 {
+			cfg.Layout.Compact = applyPriority(cfg.Layout.Compact, file.Priority)
+		}
+	}
+	for _, id := range unknownSegments(cfg.Layout) {
+		fixes = append(fixes, ConfigProblem{Source: "merged", Kind: ProblemUnknown, Message: fmt.Sprintf("layout: unknown segment %q", id)})
+	}
+
+	cfg.Pricing = mergePricing(Pricing{}, file.Pricing)
+	corrected(validatePricing(&cfg.Pricing)...)
+
+	return cfg, fixes
+}
+
+// LoadConfig loads the user config and HOWL_* environment overrides.
+// On any error (file not found, parse error, invalid preset), the affected
+// layer falls back to defaults; the returned problems say what went wrong.
+func LoadConfig() (Config, []ConfigProblem) {
+	return LoadConfigForProject("")
+}
 
-	return cfg
+// LoadConfigForProject loads the user config and layers the project config
+// (<projectDir>/.claude/howl.json) and environment on top of it.
+// Precedence, lowest first:
+//
+//  1. built-in defaults (full preset)
+//  2. user config: UserConfigPath()
+//  3. project config: <projectDir>/.claude/howl.json
+//  4. environment: HOWL_PRESET, HOWL_FEATURES, HOWL_THRESHOLD_*, HOWL_LAYOUT_*,
+//     HOWL_PRICING_ESTIMATE, HOWL_PRICING_MODELS
+//
+// Each file layer is skipped when missing, over 4KB, or malformed. Problems
+// (parse errors, unknown keys, corrected values) are returned alongside the
+// config so callers can surface them; a missing file is not a problem.
+// This is the statusline's path, so it skips the value origins InspectConfig
+// records for the same result.
+func LoadConfigForProject(projectDir string) (Config, []ConfigProblem) {
+	var merged configFile
+	_, problems := loadLayers(projectDir, func(_ string, file configFile) {
+		merged = mergeConfigFiles(merged, file)
+	})
+	cfg, fixes := resolveConfig(merged)
+	return cfg, append(problems, fixes...)
 }
@@ -28,10 +28,13 @@ func TestLoadConfig_NoFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	t.Setenv("HOME", tmpDir)
 
-	cfg := LoadConfig()
+	cfg, problems := LoadConfig()
 	if cfg.Preset != "full" {
 		t.Errorf("no config file should return full preset, got %s", cfg.Preset)
 	}
+	if len(problems) != 0 {
+		t.Errorf("missing config file is not a problem, got %v", problems)
+	}
 	if !cfg.Features.Account {
 		t.Errorf("default should have account enabled")
 	}
@@ -48,10 +51,13 @@ func TestLoadConfig_MalformedJSON(t *testing.T) {
 	// Write invalid JSON
 	os.WriteFile(configPath, []byte("{invalid json"), 0644)
 
-	cfg := LoadConfig()
+	cfg, problems := LoadConfig()
 	if cfg.Preset != "full" {
 		t.Errorf("malformed JSON should fallback to full, got %s", cfg.Preset)
 	}
+	if len(problems) != 1 || problems[0].Source != "user" {
+		t.Errorf("malformed JSON should be reported as one user problem, got %v", problems)
+	}
 }
 
 func TestLoadConfig_EmptyPreset(t *testing.T) {
@@ -65,7 +71,7 @@ func TestLoadConfig_EmptyPreset(t *testing.T) {
 	// Write empty preset
 	os.WriteFile(configPath, []byte(`{"preset":""}`), 0644)
 
-	cfg := LoadConfig()
+	cfg, _ := LoadConfig()
 	if cfg.Preset != "full" {
 		t.Errorf("empty preset should normalize to full, got %s", cfg.Preset)
 	}
@@ -82,10 +88,13 @@ func TestLoadConfig_UnknownPreset(t *testing.T) {
 	// Write unknown preset
 	os.WriteFile(configPath, []byte(`{"preset":"unknown"}`), 0644)
 
-	cfg := LoadConfig()
+	cfg, problems := LoadConfig()
 	if cfg.Preset != "full" {
 		t.Errorf("unknown preset should fallback to full, got %s", cfg.Preset)
 	}
+	if len(problems) != 1 {
+		t.Errorf("unknown preset should be reported, got %v", problems)
+	}
 	if !cfg.Features.Account {
 		t.Errorf("fallback should have full features")
 	}
@@ -118,7 +127,7 @@ func TestLoadConfig_ValidPresets(t *testing.T) {
 			content := `{"preset":"` + tt.preset + `"}`
 			os.WriteFile(configPath, []byte(content), 0644)
 
-			cfg := LoadConfig()
+			cfg, _ := LoadConfig()
 			if cfg.Preset != tt.preset {
 				t.Errorf("expected %s, got %s", tt.preset, cfg.Preset)
 			}
@@ -163,7 +172,7 @@ func TestLoadConfig_Normalization(t *testing.T) {
 
 			os.WriteFile(configPath, []byte(tt.input), 0644)
 
-			cfg := LoadConfig()
+			cfg, _ := LoadConfig()
 			if cfg.Preset != tt.expected {
 				t.Errorf("expected normalized %s, got %s", tt.expected, cfg.Preset)
 			}
@@ -183,7 +192,7 @@ func TestLoadConfig_FileTooLarge(t *testing.T) {
 	largeContent := strings.Repeat("x", maxConfigSize+1)
 	os.WriteFile(configPath, []byte(largeContent), 0644)
 
-	cfg := LoadConfig()
+	cfg, _ := LoadConfig()
 	if cfg.Preset != "full" {
 		t.Errorf("oversized file should fallback to full, got %s", cfg.Preset)
 	}
@@ -202,7 +211,7 @@ func TestLoadConfig_FeaturesIgnored(t *testing.T) {
 	content := `{"preset":"minimal","features":{"account":true}}`
 	os.WriteFile(configPath, []byte(content), 0644)
 
-	cfg := LoadConfig()
+	cfg, _ := LoadConfig()
 	if cfg.Preset != "minimal" {
 		t.Errorf("expected minimal, got %s", cfg.Preset)
 	}
@@ -214,19 +223,23 @@ func TestLoadConfig_FeaturesIgnored(t *testing.T) {
 
 // --- v1.1 Tests: mergeFeatures ---
 
-func TestMergeFeatures_AllFalse(t *testing.T) {
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestMergeFeatures_AllAbsent(t *testing.T) {
 	base := FeatureToggles{Account: true, Git: true, Tools: true}
-	override := FeatureToggles{} // all false
+	override := FeatureOverrides{} // all absent
 
 	result := mergeFeatures(base, override)
 	if !result.Account || !result.Git || !result.Tools {
-		t.Errorf("base should be preserved when override is all false")
+		t.Errorf("base should be preserved when override is all absent")
 	}
 }
 
 func TestMergeFeatures_Override(t *testing.T) {
 	base := FeatureToggles{Account: false, Git: false}
-	override := FeatureToggles{Account: true, Quota: true}
+	override := FeatureOverrides{Account: boolPtr(true), Quota: boolPtr(true)}
 
 	result := mergeFeatures(base, override)
 	if !result.Account {
@@ -236,16 +249,36 @@ func TestMergeFeatures_Override(t *testing.T) {
 		t.Errorf("override.Quota=true should set result.Quota=true")
 	}
 	if result.Git {
-		t.Errorf("base.Git=false + override.Git=false should keep result.Git=false")
+		t.Errorf("base.Git=false + absent override should keep result.Git=false")
+	}
+}
+
+func TestMergeFeatures_ExplicitFalse(t *testing.T) {
+	base := presets["full"]
+	override := FeatureOverrides{Account: boolPtr(false), Tools: boolPtr(false), Git: boolPtr(true)}
+
+	result := mergeFeatures(base, override)
+	if result.Account {
+		t.Errorf("override.Account=false should disable preset account")
+	}
+	if result.Tools {
+		t.Errorf("override.Tools=false should disable preset tools")
+	}
+	if !result.Git {
+		t.Errorf("override.Git=true should keep git enabled")
+	}
+	if !result.Quota {
+		t.Errorf("absent override.Quota should keep preset quota")
 	}
 }
 
 func TestMergeFeatures_AllTrue(t *testing.T) {
 	base := FeatureToggles{Account: false}
-	override := FeatureToggles{
-		Account: true, Git: true, LineChanges: true, OutputTokens: true,
-		Quota: true, Tools: true, Agents: true, CacheEfficiency: true,
-		APIWaitRatio: true, CostVelocity: true, VimMode: true, AgentName: true,
+	on := boolPtr(true)
+	override := FeatureOverrides{
+		Account: on, Git: on, LineChanges: on, OutputTokens: on,
+		Quota: on, Tools: on, Agents: on, CacheEfficiency: on,
+		APIWaitRatio: on, CostVelocity: on, VimMode: on, AgentName: on,
 	}
 
 	result := mergeFeatures(base, override)
@@ -266,7 +299,7 @@ func TestLoadConfig_WithFeaturesOverride(t *testing.T) {
 	content := `{"preset":"minimal","features":{"quota":true,"git":true}}`
 	os.WriteFile(configPath, []byte(content), 0644)
 
-	cfg := LoadConfig()
+	cfg, _ := LoadConfig()
 	if cfg.Preset != "minimal" {
 		t.Errorf("expected minimal, got %s", cfg.Preset)
 	}
@@ -281,6 +314,30 @@ func TestLoadConfig_WithFeaturesOverride(t *testing.T) {
 	}
 }
 
+func TestLoadConfig_FeaturesExplicitFalse(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".claude", "hud")
+	os.MkdirAll(configDir, 0755)
+	configPath := filepath.Join(configDir, "config.json")
+
+	// full minus account: explicit false disables a preset feature
+	content := `{"preset":"full","features":{"account":false,"effort":true}}`
+	os.WriteFile(configPath, []byte(content), 0644)
+
+	cfg, _ := LoadConfig()
+	if cfg.Features.Account {
+		t.Errorf("features.account=false should disable account in full preset")
+	}
+	if !cfg.Features.Effort {
+		t.Errorf("features.effort=true should enable effort")
+	}
+	if !cfg.Features.Git || !cfg.Features.Tools {
+		t.Errorf("omitted features should keep full preset values")
+	}
+}
+
 // --- v1.5 Tests: Thresholds ---
 
 func TestDefaultThresholds(t *testing.T) {
@@ -334,9 +391,28 @@ func TestDefaultThresholds(t *testing.T) {
 	}
 }
 
+func TestMergeThresholds_PresentZeroRestoresDefault(t *testing.T) {
+	base := DefaultThresholds()
+	base.BudgetMonth = 200
+	base.ContextDanger = 95
+	base.QuotaLow = 30
+
+	result := mergeThresholds(base, ThresholdOverrides{
+		BudgetMonth:   floatPtr(0),
+		ContextDanger: intPtr(0),
+		QuotaLow:      floatPtr(-5),
+	})
+	if result.BudgetMonth != 0 {
+		t.Errorf("BudgetMonth: explicit 0 should disable the budget, got %.2f", result.BudgetMonth)
+	}
+	if result.ContextDanger != DangerThreshold || result.QuotaLow != QuotaLow {
+		t.Errorf("explicit 0 or negative should restore defaults, got context_danger=%d quota_low=%.1f", result.ContextDanger, result.QuotaLow)
+	}
+}
+
 func TestMergeThresholds_AllZero(t *testing.T) {
 	base := DefaultThresholds()
-	override := Thresholds{} // all zero
+	override := ThresholdOverrides{} // all zero
 
 	result := mergeThresholds(base, override)
 
@@ -351,11 +427,11 @@ func TestMergeThresholds_AllZero(t *testing.T) {
 
 func TestMergeThresholds_PartialOverride(t *testing.T) {
 	base := DefaultThresholds()
-	override := Thresholds{
-		ContextDanger:      90,
-		SessionCostHigh:    10.0,
-		CostVelocityMedium: 0.25,
-		QuotaCritical:      15.0,
+	override := ThresholdOverrides{
+		ContextDanger:      intPtr(90),
+		SessionCostHigh:    floatPtr(10.0),
+		CostVelocityMedium: floatPtr(0.25),
+		QuotaCritical:      floatPtr(15.0),
 	}
 
 	result := mergeThresholds(base, override)
@@ -390,10 +466,10 @@ func TestMergeThresholds_PartialOverride(t *testing.T) {
 
 func TestMergeThresholds_NegativeIgnored(t *testing.T) {
 	base := DefaultThresholds()
-	override := Thresholds{
-		ContextDanger: -1,
-		CacheGood:     -50,
-		QuotaCritical: -10.0,
+	override := ThresholdOverrides{
+		ContextDanger: intPtr(-1),
+		CacheGood:     intPtr(-50),
+		QuotaCritical: floatPtr(-10.0),
 	}
 
 	result := mergeThresholds(base, override)
@@ -422,7 +498,7 @@ func TestLoadConfig_WithThresholds(t *testing.T) {
 	content := `{"preset":"full","thresholds":{"context_danger":90}}`
 	os.WriteFile(configPath, []byte(content), 0644)
 
-	cfg := LoadConfig()
+	cfg, _ := LoadConfig()
 
 	// Verify overridden values
 	if cfg.Thresholds.ContextDanger != 90 {
@@ -505,6 +581,85 @@ func TestValidateThresholds_ValidUnchanged(t *testing.T) {
 	}
 }
 
+func TestValidateThresholds_VelocityWindow(t *testing.T) {
+	tests := []struct {
+		in, want int
+		fixed    bool
+	}{
+		{10, 10, false},
+		{60, 60, false},
+		{0, 1, true},
+		{240, 60, true},
+	}
+	for _, tt := range tests {
+		th := DefaultThresholds()
+		th.VelocityWindowMinutes = tt.in
+		fixes := validateThresholds(&th)
+		if th.VelocityWindowMinutes != tt.want {
+			t.Errorf("window %d: got %d, want %d", tt.in, th.VelocityWindowMinutes, tt.want)
+		}
+		if got := len(fixes) > 0; got != tt.fixed {
+			t.Errorf("window %d: fixes = %v, want reported=%v", tt.in, fixes, tt.fixed)
+		}
+	}
+}
+
+func TestValidateThresholds_SparklineSamples(t *testing.T) {
+	tests := []struct {
+		in, want int
+		fixed    bool
+	}{
+		{12, 12, false},
+		{2, 2, false},
+		{1, 2, true},
+		{100, 60, true},
+	}
+	for _, tt := range tests {
+		th := DefaultThresholds()
+		th.SparklineSamples = tt.in
+		fixes := validateThresholds(&th)
+		if th.SparklineSamples != tt.want {
+			t.Errorf("samples %d: got %d, want %d", tt.in, th.SparklineSamples, tt.want)
+		}
+		if got := len(fixes) > 0; got != tt.fixed {
+			t.Errorf("samples %d: fixes = %v, want reported=%v", tt.in, fixes, tt.fixed)
+		}
+	}
+}
+
+func TestValidateThresholds_Budgets(t *testing.T) {
+	th := DefaultThresholds()
+	th.BudgetDay = 20
+	th.BudgetDanger = 5000
+	fixes := validateThresholds(&th)
+	if th.BudgetDay != 20 || th.BudgetDanger != 1000 {
+		t.Errorf("got budget_day %v budget_danger %d, want 20 and 1000", th.BudgetDay, th.BudgetDanger)
+	}
+	if len(fixes) != 1 || !strings.Contains(fixes[0], "budget_danger") {
+		t.Errorf("fixes = %v, want one budget_danger clamp", fixes)
+	}
+}
+
+func TestConfig_NeedsLedger(t *testing.T) {
+	cfg := PresetConfig("full")
+	if cfg.NeedsLedger() {
+		t.Error("default config should not need the ledger")
+	}
+	cfg.Thresholds.BudgetSession = 5 // session cost comes from stdin
+	if cfg.NeedsLedger() {
+		t.Error("session budget alone should not need the ledger")
+	}
+	cfg.Thresholds.BudgetProjectDay = 5
+	if !cfg.NeedsLedger() {
+		t.Error("project day budget needs the ledger")
+	}
+	cfg = PresetConfig("minimal")
+	cfg.Features.Ledger = true
+	if !cfg.NeedsLedger() {
+		t.Error("ledger feature needs the ledger")
+	}
+}
+
 func TestLoadConfig_InvertedThresholdsCorrected(t *testing.T) {
 	tmpDir := t.TempDir()
 	t.Setenv("HOME", tmpDir)
@@ -517,7 +672,7 @@ func TestLoadConfig_InvertedThresholdsCorrected(t *testing.T) {
 	content := `{"preset":"full","thresholds":{"context_danger":50,"context_warning":90}}`
 	os.WriteFile(configPath, []byte(content), 0644)
 
-	cfg := LoadConfig()
+	cfg, _ := LoadConfig()
 
 	if cfg.Thresholds.ContextWarning >= cfg.Thresholds.ContextDanger {
 		t.Errorf("LoadConfig should fix inverted thresholds: warning=%d >= danger=%d",
@@ -635,22 +790,22 @@ func TestValidateThresholds_UpperBoundClamping(t *testing.T) {
 
 func TestMergeThresholds_AllOverridden(t *testing.T) {
 	base := DefaultThresholds()
-	override := Thresholds{
-		ContextDanger:      95,
-		ContextWarning:     80,
-		ContextModerate:    60,
-		SessionCostHigh:    10.0,
-		SessionCostMedium:  2.0,
-		CacheExcellent:     90,
-		CacheGood:          60,
-		WaitHigh:           70,
-		WaitMedium:         40,
-		CostVelocityHigh:   1.0,
-		CostVelocityMedium: 0.20,
-		QuotaCritical:      15,
-		QuotaLow:           30,
-		QuotaMedium:        55,
-		QuotaHigh:          80,
+	override := ThresholdOverrides{
+		ContextDanger:      intPtr(95),
+		ContextWarning:     intPtr(80),
+		ContextModerate:    intPtr(60),
+		SessionCostHigh:    floatPtr(10.0),
+		SessionCostMedium:  floatPtr(2.0),
+		CacheExcellent:     intPtr(90),
+		CacheGood:          intPtr(60),
+		WaitHigh:           intPtr(70),
+		WaitMedium:         intPtr(40),
+		CostVelocityHigh:   floatPtr(1.0),
+		CostVelocityMedium: floatPtr(0.20),
+		QuotaCritical:      floatPtr(15),
+		QuotaLow:           floatPtr(30),
+		QuotaMedium:        floatPtr(55),
+		QuotaHigh:          floatPtr(80),
 	}
 
 	result := mergeThresholds(base, override)
@@ -725,3 +880,131 @@ func TestValidateThresholds_QuotaCriticalHigh(t *testing.T) {
 		t.Errorf("QuotaHigh should not exceed 100, got %.1f", th.QuotaHigh)
 	}
 }
+
+// --- Project config layering ---
+
+// writeConfigLayers writes the user config under a temp HOME and the project
+// config under a temp project dir. Empty content skips that layer.
+func writeConfigLayers(t *testing.T, user, project string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	projectDir := t.TempDir()
+
+	if user != "" {
+		dir := filepath.Join(home, ".claude", "hud")
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "config.json"), []byte(user), 0644)
+	}
+	if project != "" {
+		dir := filepath.Join(projectDir, ".claude")
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "howl.json"), []byte(project), 0644)
+	}
+	return projectDir
+}
+
+func TestProjectConfigPath(t *testing.T) {
+	if got := ProjectConfigPath(""); got != "" {
+		t.Errorf("ProjectConfigPath(\"\") = %q, want empty", got)
+	}
+	want := filepath.Join("/repo", ".claude", "howl.json")
+	if got := ProjectConfigPath("/repo"); got != want {
+		t.Errorf("ProjectConfigPath(/repo) = %q, want %q", got, want)
+	}
+}
+
+func TestLoadConfigForProject_ProjectOnly(t *testing.T) {
+	projectDir := writeConfigLayers(t, "", `{"preset":"minimal","thresholds":{"context_danger":95,"context_warning":90}}`)
+
+	cfg, _ := LoadConfigForProject(projectDir)
+	if cfg.Preset != "minimal" {
+		t.Errorf("expected project preset minimal, got %s", cfg.Preset)
+	}
+	if cfg.Thresholds.ContextDanger != 95 || cfg.Thresholds.ContextWarning != 90 {
+		t.Errorf("expected project thresholds 95/90, got %d/%d", cfg.Thresholds.ContextDanger, cfg.Thresholds.ContextWarning)
+	}
+}
+
+func TestLoadConfigForProject_Precedence(t *testing.T) {
+	user := `{"preset":"developer","features":{"effort":true,"account":false},"thresholds":{"session_cost_high":20,"context_danger":90}}`
+	project := `{"features":{"account":true,"git":false},"thresholds":{"context_danger":95},"layout":{"normal":[["model","context"]]}}`
+	projectDir := writeConfigLayers(t, user, project)
+
+	cfg, _ := LoadConfigForProject(projectDir)
+	if cfg.Preset != "developer" {
+		t.Errorf("project without preset should keep user preset, got %s", cfg.Preset)
+	}
+	if !cfg.Features.Account {
+		t.Errorf("project account=true should override user account=false")
+	}
+	if cfg.Features.Git {
+		t.Errorf("project git=false should disable preset git")
+	}
+	if !cfg.Features.Effort {
+		t.Errorf("user effort=true should survive project layer")
+	}
+	if cfg.Thresholds.ContextDanger != 95 {
+		t.Errorf("project context_danger should win, got %d", cfg.Thresholds.ContextDanger)
+	}
+	if cfg.Thresholds.SessionCostHigh != 20 {
+		t.Errorf("user session_cost_high should survive project layer, got %.2f", cfg.Thresholds.SessionCostHigh)
+	}
+	if len(cfg.Layout.Normal) != 1 {
+		t.Errorf("project layout should apply, got %v", cfg.Layout.Normal)
+	}
+}
+
+func TestLoadConfigForProject_ProjectZeroResets(t *testing.T) {
+	user := `{"thresholds":{"budget_day":20,"budget_danger":100,"context_danger":90,"error_streak":5}}`
+	project := `{"thresholds":{"budget_day":0,"context_danger":0}}`
+	projectDir := writeConfigLayers(t, user, project)
+
+	cfg, problems := LoadConfigForProject(projectDir)
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+	// An explicit 0 turns off the user's budget and restores the default
+	// context_danger; keys the project does not set keep the user's values.
+	if cfg.Thresholds.BudgetDay != 0 {
+		t.Errorf("project budget_day 0 should disable the user budget, got %.2f", cfg.Thresholds.BudgetDay)
+	}
+	if cfg.Thresholds.ContextDanger != DangerThreshold {
+		t.Errorf("project context_danger 0 should restore the default %d, got %d", DangerThreshold, cfg.Thresholds.ContextDanger)
+	}
+	if cfg.Thresholds.BudgetDanger != 100 || cfg.Thresholds.ErrorStreak != 5 {
+		t.Errorf("unset keys should keep user values, got budget_danger=%d error_streak=%d", cfg.Thresholds.BudgetDanger, cfg.Thresholds.ErrorStreak)
+	}
+	if cfg.NeedsLedger() {
+		t.Error("no budget left, the ledger should not be needed")
+	}
+}
+
+func TestLoadConfigForProject_InvalidProjectSkipped(t *testing.T) {
+	tests := []struct {
+		name    string
+		project string
+	}{
+		{"malformed", "{not json"},
+		{"too large", strings.Repeat("x", maxConfigSize+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := writeConfigLayers(t, `{"preset":"cost-focused"}`, tt.project)
+
+			cfg, _ := LoadConfigForProject(projectDir)
+			if cfg.Preset != "cost-focused" {
+				t.Errorf("invalid project config should keep user config, got %s", cfg.Preset)
+			}
+		})
+	}
+}
+
+func TestLoadConfigForProject_EmptyDir(t *testing.T) {
+	writeConfigLayers(t, `{"preset":"minimal"}`, "")
+
+	cfg, _ := LoadConfigForProject("")
+	if cfg.Preset != "minimal" {
+		t.Errorf("empty project dir should load user config only, got %s", cfg.Preset)
+	}
+}
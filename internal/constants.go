@@ -28,6 +28,19 @@ const (
 	CostHigh   = 0.50 // Red: expensive session
 	CostMedium = 0.10 // Yellow: moderate cost
 	// Below 0.10 = Green: economical
+
+	VelocityWindowMinutes = 10 // history window for windowed rates and the context ETA
+)
+
+// Tool failures
+const (
+	ErrorStreak = 3 // failed tool calls in a row that raise the streak warning
+)
+
+// Context history
+const (
+	CompactionDropPercent = 10.0 // context % drop between samples treated as a compaction or /clear
+	SparklineSamples      = 12   // default history samples shown by sparkline segments
 )
 
 // Session cost thresholds (USD)
@@ -0,0 +1,366 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckStatus is the outcome of one doctor check.
+type CheckStatus int
+
+const (
+	CheckPass CheckStatus = iota
+	CheckWarn             // works, but something is degraded or unverifiable
+	CheckFail             // a feature is broken until the user acts
+)
+
+func (s CheckStatus) String() string {
+	switch s {
+	case CheckPass:
+		return "pass"
+	case CheckWarn:
+		return "warn"
+	default:
+		return "fail"
+	}
+}
+
+// CheckResult is one line of the doctor report. Hint is a remediation step,
+// empty when the check passed.
+type CheckResult struct {
+	Name   string
+	Status CheckStatus
+	Detail string
+	Hint   string
+}
+
+// DoctorOptions are the inputs RunDoctor cannot discover on its own.
+type DoctorOptions struct {
+	Version        string // binary version ("dev" for source builds)
+	BinaryPath     string // path of the running binary
+	PluginRoot     string // plugin directory; "" searches the usual locations
+	ProjectDir     string // project for .claude/howl.json
+	TranscriptPath string // transcript to tail; "" picks the newest session
+}
+
+// RunDoctor checks the whole install: statusLine wiring, binary/plugin
+// version, config, git, account, terminal width and transcript access.
+// Every check runs even if an earlier one fails.
+func RunDoctor(opts DoctorOptions) []CheckResult {
+	home, _ := os.UserHomeDir()
+	return []CheckResult{
+		checkStatusLine(home, opts.BinaryPath),
+		checkVersion(home, opts.Version, opts.PluginRoot),
+		checkConfig(opts.ProjectDir),
+		checkGit(),
+		checkAccount(home),
+		checkColumns(),
+		checkTranscript(home, opts.TranscriptPath),
+	}
+}
+
+func checkStatusLine(home, binary string) CheckResult {
+	r := CheckResult{Name: "statusline"}
+	path := filepath.Join(home, ".claude", "settings.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r.Status, r.Detail = CheckFail, "cannot read "+path
+		r.Hint = "run /howl:setup (or scripts/install.sh) to configure the statusLine"
+		return r
+	}
+	var settings struct {
+		StatusLine *struct {
+			Type    string `json:"type"`
+			Command string `json:"command"`
+		} `json:"statusLine"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		r.Status, r.Detail = CheckFail, path+": "+describeJSONError(data, err)
+		r.Hint = "fix the JSON syntax in " + path
+		return r
+	}
+	sl := settings.StatusLine
+	if sl == nil || sl.Command == "" {
+		r.Status, r.Detail = CheckFail, "no statusLine command in "+path
+		r.Hint = `add "statusLine": {"type": "command", "command": "~/.claude/hud/howl"}`
+		return r
+	}
+	if sl.Type != "command" {
+		r.Status, r.Detail = CheckFail, fmt.Sprintf("statusLine type is %q, want \"command\"", sl.Type)
+		r.Hint = `set "statusLine.type" to "command"`
+		return r
+	}
+
+	target := statusLineBinary(sl.Command, home)
+	switch {
+	case target == "":
+		r.Status, r.Detail = CheckWarn, fmt.Sprintf("cannot tell which binary %q runs", sl.Command)
+		r.Hint = "make sure the command runs " + binary
+	case !samePath(target, binary):
+		r.Status, r.Detail = CheckWarn, fmt.Sprintf("statusLine runs %s, but this is %s", target, binary)
+		r.Hint = "point statusLine.command at the binary you meant to install, or run that binary's doctor"
+	default:
+		r.Detail = "runs " + target
+	}
+	return r
+}
+
+// statusLineBinary extracts the executable from a statusLine command such as
+// "HOWL_PRESET=minimal ~/.claude/hud/howl", expanding ~ and $HOME. Returns ""
+// when the command is not a plain binary invocation (e.g. a shell pipeline).
+func statusLineBinary(command, home string) string {
+	for _, field := range strings.Fields(command) {
+		field = strings.Trim(field, `"'`)
+		if name, _, ok := strings.Cut(field, "="); field == "env" || (ok && !strings.Contains(name, "/")) {
+			continue // env prefix or VAR=value assignment
+		}
+		switch {
+		case field == "~" || strings.HasPrefix(field, "~/"):
+			field = home + field[1:]
+		case strings.HasPrefix(field, "${HOME}"):
+			field = home + strings.TrimPrefix(field, "${HOME}")
+		case strings.HasPrefix(field, "$HOME"):
+			field = home + strings.TrimPrefix(field, "$HOME")
+		}
+		if !strings.Contains(field, "/") {
+			if p, err := exec.LookPath(field); err == nil {
+				return p
+			}
+			return ""
+		}
+		return field
+	}
+	return ""
+}
+
+// samePath compares two paths after resolving symlinks where possible.
+func samePath(a, b string) bool {
+	resolve := func(p string) string {
+		if r, err := filepath.EvalSymlinks(p); err == nil {
+			return r
+		}
+		return filepath.Clean(p)
+	}
+	return resolve(a) == resolve(b)
+}
+
+func checkVersion(home, version, pluginRoot string) CheckResult {
+	r := CheckResult{Name: "version"}
+	manifest := pluginManifestPath(home, pluginRoot)
+	if manifest == "" {
+		r.Status, r.Detail = CheckWarn, "binary "+version+", plugin manifest not found"
+		r.Hint = "direct installs have no plugin; otherwise pass --plugin-root DIR"
+		return r
+	}
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		r.Status, r.Detail = CheckWarn, err.Error()
+		return r
+	}
+	var plugin struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &plugin); err != nil || plugin.Version == "" {
+		r.Status, r.Detail = CheckWarn, "no version in "+manifest
+		return r
+	}
+
+	binary := strings.TrimPrefix(version, "v")
+	switch {
+	case binary == "dev" || binary == "":
+		r.Status, r.Detail = CheckWarn, "development build, plugin is "+plugin.Version
+		r.Hint = "install a release binary with scripts/install.sh to track the plugin"
+	case binary != strings.TrimPrefix(plugin.Version, "v"):
+		r.Status, r.Detail = CheckFail, fmt.Sprintf("binary %s, plugin %s (%s)", binary, plugin.Version, manifest)
+		r.Hint = "run /howl:setup to download the matching binary"
+	default:
+		r.Detail = "binary and plugin are both " + binary
+	}
+	return r
+}
+
+// pluginManifestPath finds the plugin's plugin.json: explicit root or
+// $CLAUDE_PLUGIN_ROOT first, then the newest copy in Claude Code's plugin
+// cache, then the manifest last recorded by scripts/sync-binary.sh.
+func pluginManifestPath(home, root string) string {
+	if root == "" {
+		root = os.Getenv("CLAUDE_PLUGIN_ROOT")
+	}
+	if root != "" {
+		p := filepath.Join(root, ".claude-plugin", "plugin.json")
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		return ""
+	}
+
+	var newest string
+	var newestMod time.Time
+	matches, _ := filepath.Glob(filepath.Join(home, ".claude", "plugins", "cache", "*", "howl", "*", ".claude-plugin", "plugin.json"))
+	for _, m := range matches {
+		if st, err := os.Stat(m); err == nil && st.ModTime().After(newestMod) {
+			newest, newestMod = m, st.ModTime()
+		}
+	}
+	if newest != "" {
+		return newest
+	}
+	saved := filepath.Join(home, ".claude", "hud", ".plugin-state", "plugin.json")
+	if _, err := os.Stat(saved); err == nil {
+		return saved
+	}
+	return ""
+}
+
+func checkConfig(projectDir string) CheckResult {
+	r := CheckResult{Name: "config"}
+	report := InspectConfig(projectDir)
+	var loaded []string
+	for _, l := range report.Layers {
+		if l.Status == "loaded" && l.Path != "" {
+			loaded = append(loaded, l.Path)
+		}
+	}
+	switch {
+	case len(report.Problems) > 0:
+		// Only an unreadable or unparseable value fails; unknown keys and
+		// corrected values still leave a working config.
+		r.Status = CheckWarn
+		first := report.Problems[0]
+		for _, p := range report.Problems {
+			if p.Kind == ProblemInvalid {
+				r.Status, first = CheckFail, p
+				break
+			}
+		}
+		r.Detail = fmt.Sprintf("%d problem(s), first: %s", len(report.Problems), first)
+		r.Hint = "run `howl config validate` for the full list"
+	case len(loaded) == 0:
+		r.Detail = "no config file, using preset " + report.Config.Preset
+	default:
+		r.Detail = "parsed " + strings.Join(loaded, ", ")
+	}
+	return r
+}
+
+func checkGit() CheckResult {
+	r := CheckResult{Name: "git"}
+	path, err := exec.LookPath("git")
+	if err != nil {
+		r.Status, r.Detail = CheckWarn, "git not on PATH, branch display disabled"
+		r.Hint = "install git or add it to the PATH Claude Code runs the statusLine with"
+		return r
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), GitTimeout)
+	defer cancel()
+	start := time.Now()
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	elapsed := time.Since(start)
+	switch {
+	case ctx.Err() != nil:
+		r.Status, r.Detail = CheckFail, fmt.Sprintf("%s did not answer within %s", path, GitTimeout)
+		r.Hint = "check for a slow git wrapper or antivirus scanning the git binary"
+	case err != nil:
+		r.Status, r.Detail = CheckFail, fmt.Sprintf("%s --version: %v", path, err)
+		r.Hint = "reinstall git"
+	default:
+		r.Detail = fmt.Sprintf("%s (%dms)", strings.TrimSpace(string(out)), elapsed.Milliseconds())
+	}
+	return r
+}
+
+func checkAccount(home string) CheckResult {
+	r := CheckResult{Name: "account"}
+	if info := GetAccountInfo(); info != nil {
+		r.Detail = "oauthAccount " + info.EmailAddress
+		return r
+	}
+	path := filepath.Join(home, ".claude.json")
+	r.Status = CheckWarn
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		r.Detail = path + " not found, account segment hidden"
+	} else {
+		r.Detail = "no oauthAccount in " + path + ", account segment hidden"
+	}
+	r.Hint = "expected for API-key users; subscribers should run `claude /login`"
+	return r
+}
+
+func checkColumns() CheckResult {
+	r := CheckResult{Name: "columns"}
+	v, ok := os.LookupEnv("COLUMNS")
+	if !ok {
+		r.Status, r.Detail = CheckWarn, fmt.Sprintf("COLUMNS not set, tool line wraps at %d", maxToolLineWidth)
+		r.Hint = "export COLUMNS in the statusLine command (e.g. \"COLUMNS=120 ~/.claude/hud/howl\") for wider terminals"
+		return r
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		r.Status, r.Detail = CheckWarn, fmt.Sprintf("COLUMNS=%q is not a number, using %d", v, maxToolLineWidth)
+		r.Hint = "set COLUMNS to the terminal width"
+		return r
+	}
+	if used := terminalColumns(); used != n {
+		r.Status, r.Detail = CheckWarn, fmt.Sprintf("COLUMNS=%d, clamped to %d", n, used)
+		return r
+	}
+	r.Detail = "COLUMNS=" + v
+	return r
+}
+
+func checkTranscript(home, path string) CheckResult {
+	r := CheckResult{Name: "transcript"}
+	if path == "" {
+		path = newestTranscript(filepath.Join(home, ".claude", "projects"))
+	}
+	if path == "" {
+		r.Status, r.Detail = CheckWarn, "no transcript found under ~/.claude/projects"
+		r.Hint = "start a Claude Code session, or pass --transcript FILE"
+		return r
+	}
+
+	// A cold index, as on a session's first refresh; later refreshes only
+	// parse what was appended.
+	start := time.Now()
+	idx := &TranscriptIndex{Path: path}
+	_, err := idx.update(path)
+	elapsed := time.Since(start)
+	if err != nil {
+		r.Status, r.Detail = CheckFail, err.Error()
+		r.Hint = "tools and agents need read access to the transcript"
+		return r
+	}
+	if idx.Invalid > 0 && idx.Lines == 0 {
+		r.Status, r.Detail = CheckWarn, fmt.Sprintf("%s: none of %d lines is JSON", path, idx.Invalid)
+		r.Hint = "the file does not look like a Claude Code transcript"
+		return r
+	}
+	r.Detail = fmt.Sprintf("indexed %d lines of %s (%dms)", idx.Lines, path, elapsed.Milliseconds())
+	if info, err := os.Stat(path); err == nil && info.Size() > idx.Offset {
+		r.Detail += fmt.Sprintf(", %d more bytes over the next refreshes", info.Size()-idx.Offset)
+	}
+	return r
+}
+
+// newestTranscript returns the most recently modified *.jsonl one level below
+// dir (Claude Code keeps one directory per project), or "".
+func newestTranscript(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*", "*.jsonl"))
+	var newest string
+	var newestMod time.Time
+	for _, m := range matches {
+		if st, err := os.Stat(m); err == nil && st.ModTime().After(newestMod) {
+			newest, newestMod = m, st.ModTime()
+		}
+	}
+	return newest
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatusLineBinary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"~/.claude/hud/howl", "/home/u/.claude/hud/howl"},
+		{"$HOME/.claude/hud/howl", "/home/u/.claude/hud/howl"},
+		{"${HOME}/bin/howl --flag", "/home/u/bin/howl"},
+		{"HOWL_PRESET=minimal COLUMNS=120 ~/.claude/hud/howl", "/home/u/.claude/hud/howl"},
+		{"env HOWL_CONFIG=/etc/howl.json /usr/local/bin/howl", "/usr/local/bin/howl"},
+		{`"/opt/howl/howl"`, "/opt/howl/howl"},
+		{"no-such-binary-on-path-xyz", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := statusLineBinary(tt.command, "/home/u"); got != tt.want {
+			t.Errorf("statusLineBinary(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestCheckStatusLine(t *testing.T) {
+	home := t.TempDir()
+	binary := filepath.Join(home, ".claude", "hud", "howl")
+	settings := filepath.Join(home, ".claude", "settings.json")
+	os.MkdirAll(filepath.Dir(binary), 0755)
+	os.WriteFile(binary, []byte("#!/bin/sh\n"), 0755)
+
+	tests := []struct {
+		name     string
+		settings string
+		want     CheckStatus
+	}{
+		{"missing file", "", CheckFail},
+		{"malformed", `{"statusLine": }`, CheckFail},
+		{"no statusLine", `{"theme": "dark"}`, CheckFail},
+		{"wrong type", `{"statusLine": {"type": "static", "command": "~/.claude/hud/howl"}}`, CheckFail},
+		{"other binary", `{"statusLine": {"type": "command", "command": "/usr/bin/other-hud"}}`, CheckWarn},
+		{"this binary", `{"statusLine": {"type": "command", "command": "COLUMNS=100 ~/.claude/hud/howl"}}`, CheckPass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(settings)
+			if tt.settings != "" {
+				os.WriteFile(settings, []byte(tt.settings), 0644)
+			}
+			r := checkStatusLine(home, binary)
+			if r.Status != tt.want {
+				t.Errorf("status = %s, want %s (%s)", r.Status, tt.want, r.Detail)
+			}
+			if r.Status != CheckPass && r.Hint == "" {
+				t.Errorf("non-passing check should carry a hint")
+			}
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	t.Setenv("CLAUDE_PLUGIN_ROOT", "")
+	home := t.TempDir()
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, ".claude-plugin"), 0755)
+	os.WriteFile(filepath.Join(root, ".claude-plugin", "plugin.json"), []byte(`{"name":"howl","version":"1.9.0"}`), 0644)
+
+	tests := []struct {
+		name    string
+		version string
+		root    string
+		want    CheckStatus
+	}{
+		{"match", "1.9.0", root, CheckPass},
+		{"match with v prefix", "v1.9.0", root, CheckPass},
+		{"mismatch", "1.8.0", root, CheckFail},
+		{"dev build", "dev", root, CheckWarn},
+		{"no manifest", "1.9.0", "", CheckWarn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := checkVersion(home, tt.version, tt.root); r.Status != tt.want {
+				t.Errorf("status = %s, want %s (%s)", r.Status, tt.want, r.Detail)
+			}
+		})
+	}
+}
+
+func TestPluginManifestPath_Cache(t *testing.T) {
+	t.Setenv("CLAUDE_PLUGIN_ROOT", "")
+	home := t.TempDir()
+	saved := filepath.Join(home, ".claude", "hud", ".plugin-state", "plugin.json")
+	os.MkdirAll(filepath.Dir(saved), 0755)
+	os.WriteFile(saved, []byte(`{"version":"1.8.0"}`), 0644)
+	if got := pluginManifestPath(home, ""); got != saved {
+		t.Errorf("should fall back to sync-binary manifest, got %q", got)
+	}
+
+	cached := filepath.Join(home, ".claude", "plugins", "cache", "ai-screams-howl", "howl", "1.9.0", ".claude-plugin", "plugin.json")
+	os.MkdirAll(filepath.Dir(cached), 0755)
+	os.WriteFile(cached, []byte(`{"version":"1.9.0"}`), 0644)
+	if got := pluginManifestPath(home, ""); got != cached {
+		t.Errorf("should prefer plugin cache, got %q", got)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	writeUserConfig(t, `{"preset":"developer"}`)
+	if r := checkConfig(""); r.Status != CheckPass || !strings.Contains(r.Detail, "config.json") {
+		t.Errorf("valid config: %s %s", r.Status, r.Detail)
+	}
+
+	writeUserConfig(t, `{"preset":"developer",}`)
+	if r := checkConfig(""); r.Status != CheckFail || !strings.Contains(r.Hint, "config validate") {
+		t.Errorf("malformed config: %s %s", r.Status, r.Detail)
+	}
+
+	writeUserConfig(t, `{"preset":"developer","colour":"blue"}`)
+	if r := checkConfig(""); r.Status != CheckWarn || !strings.Contains(r.Detail, "colour") {
+		t.Errorf("unknown key: %s %s", r.Status, r.Detail)
+	}
+
+	writeUserConfig(t, `{"thresholds":{"context_danger":150}}`)
+	if r := checkConfig(""); r.Status != CheckWarn || !strings.Contains(r.Detail, "clamped") {
+		t.Errorf("corrected value: %s %s", r.Status, r.Detail)
+	}
+
+	writeUserConfig(t, `{"colour":"blue"}`)
+	t.Setenv("HOWL_THRESHOLD_CONTEXT_DANGER", "high")
+	if r := checkConfig(""); r.Status != CheckFail || !strings.Contains(r.Detail, "HOWL_THRESHOLD_CONTEXT_DANGER") {
+		t.Errorf("invalid env value: %s %s", r.Status, r.Detail)
+	}
+}
+
+func TestCheckColumns(t *testing.T) {
+	tests := []struct {
+		value string
+		set   bool
+		want  CheckStatus
+	}{
+		{"", false, CheckWarn},
+		{"120", true, CheckPass},
+		{"wide", true, CheckWarn},
+		{"500", true, CheckWarn},
+	}
+	for _, tt := range tests {
+		if tt.set {
+			t.Setenv("COLUMNS", tt.value)
+		} else {
+			t.Setenv("COLUMNS", "")
+			os.Unsetenv("COLUMNS")
+		}
+		if r := checkColumns(); r.Status != tt.want {
+			t.Errorf("COLUMNS=%q: status = %s, want %s (%s)", tt.value, r.Status, tt.want, r.Detail)
+		}
+	}
+}
+
+func TestCheckTranscript(t *testing.T) {
+	home := t.TempDir()
+
+	if r := checkTranscript(home, ""); r.Status != CheckWarn {
+		t.Errorf("no transcripts: status = %s, want warn", r.Status)
+	}
+	if r := checkTranscript(home, filepath.Join(home, "missing.jsonl")); r.Status != CheckFail {
+		t.Errorf("unreadable transcript: status = %s, want fail", r.Status)
+	}
+	if r := checkTranscript(home, "testdata/transcript_mixed.jsonl"); r.Status != CheckPass {
+		t.Errorf("fixture transcript: status = %s, want pass (%s)", r.Status, r.Detail)
+	}
+
+	project := filepath.Join(home, ".claude", "projects", "-repo")
+	os.MkdirAll(project, 0755)
+	session := filepath.Join(project, "session.jsonl")
+	os.WriteFile(session, []byte(`{"type":"user"}`+"\n"), 0644)
+	if r := checkTranscript(home, ""); r.Status != CheckPass || !strings.Contains(r.Detail, session) {
+		t.Errorf("newest transcript should be found: %s %s", r.Status, r.Detail)
+	}
+}
@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Environment variables recognized by Howl. Every Config value can be set
+// from the environment, so one binary can behave differently per statusLine
+// command (e.g. per profile or per container) without a config file.
+const (
+	EnvConfig          = "HOWL_CONFIG"             // explicit user config path
+	EnvPreset          = "HOWL_PRESET"             // preset name
+	EnvFeatures        = "HOWL_FEATURES"           // e.g. "git,-account" (+name enables, -name disables)
+	EnvThreshold       = "HOWL_THRESHOLD_"         // prefix + upper-cased threshold key, e.g. HOWL_THRESHOLD_CONTEXT_DANGER
+	EnvLayoutNormal    = "HOWL_LAYOUT_NORMAL"      // lines separated by ";", segments by ","
+	EnvLayoutCompact   = "HOWL_LAYOUT_COMPACT"     // same format as HOWL_LAYOUT_NORMAL
+	EnvLayoutDanger    = "HOWL_LAYOUT_DANGER"      // same format as HOWL_LAYOUT_NORMAL
+	EnvPricingEstimate = "HOWL_PRICING_ESTIMATE"   // auto, always or never
+	EnvPricingModels   = "HOWL_PRICING_MODELS"     // JSON object, same shape as pricing.models
+	envXDGConfigHome   = "XDG_CONFIG_HOME"         // XDG base directory for config files
+	xdgConfigSubpath   = "howl/config.json"        // config path relative to the XDG config dir
+	legacyConfigPath   = ".claude/hud/config.json" // config path relative to $HOME
+)
+
+// userConfigCandidates returns user config paths in lookup order:
+// $HOWL_CONFIG alone when set, otherwise $XDG_CONFIG_HOME/howl/config.json
+// (default ~/.config) followed by ~/.claude/hud/config.json.
+func userConfigCandidates() []string {
+	if p := os.Getenv(EnvConfig); p != "" {
+		return []string{p}
+	}
+	home, _ := os.UserHomeDir()
+	var paths []string
+	xdg := os.Getenv(envXDGConfigHome)
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		paths = append(paths, filepath.Join(xdg, filepath.FromSlash(xdgConfigSubpath)))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, filepath.FromSlash(legacyConfigPath)))
+	}
+	return paths
+}
+
+// jsonKeys returns the JSON object keys v marshals to, sorted. Used to derive
+// env variable names from struct tags so they never drift from config.json.
+func jsonKeys(v any) []string {
+	data, _ := json.Marshal(v)
+	var m map[string]json.RawMessage
+	_ = json.Unmarshal(data, &m)
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// envConfigFile builds a config layer from HOWL_* environment variables.
+// Unset variables leave the corresponding fields empty so lower layers show
+// through. Malformed values are skipped and reported as problems.
+func envConfigFile() (configFile, []ConfigProblem) {
+	var file configFile
+	var problems []ConfigProblem
+	report := func(kind, format string, args ...any) {
+		problems = append(problems, ConfigProblem{Source: "env", Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+	file.Preset = os.Getenv(EnvPreset)
+
+	var unknown []string
+	file.Features, unknown = parseFeatureList(os.Getenv(EnvFeatures))
+	for _, name := range unknown {
+		report(ProblemUnknown, "%s: unknown feature %q", EnvFeatures, name)
+	}
+
+	for _, key := range jsonKeys(Thresholds{}) {
+		name := EnvThreshold + strings.ToUpper(key)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			report(ProblemInvalid, "%s: %q is not a number", name, v)
+			continue
+		}
+		// Decode one key at a time so a bad value only drops that key.
+		var one ThresholdOverrides
+		data, _ := json.Marshal(map[string]float64{key: f})
+		if err := json.Unmarshal(data, &one); err != nil {
+			report(ProblemInvalid, "%s: %q must be a whole number", name, v)
+			continue
+		}
+		file.Thresholds = mergeThresholdOverrides(file.Thresholds, one)
+	}
+
+	file.Layout.Normal = parseLayoutLines(os.Getenv(EnvLayoutNormal))
+	file.Layout.Compact = parseLayoutLines(os.Getenv(EnvLayoutCompact))
+	file.Layout.Danger = parseLayoutLines(os.Getenv(EnvLayoutDanger))
+	file.Pricing.Estimate = os.Getenv(EnvPricingEstimate)
+	if v := strings.TrimSpace(os.Getenv(EnvPricingModels)); v != "" {
+		if err := json.Unmarshal([]byte(v), &file.Pricing.Models); err != nil {
+			file.Pricing.Models = nil
+			report(ProblemInvalid, "%s: %v", EnvPricingModels, err)
+		}
+	}
+	return file, problems
+}
+
+// parseFeatureList parses "git,-account,+tools" into tri-state overrides:
+// a bare or "+" name enables, a "-" name disables. Unknown names are skipped
+// and returned so the caller can report them.
+func parseFeatureList(s string) (FeatureOverrides, []string) {
+	var o FeatureOverrides
+	if strings.TrimSpace(s) == "" {
+		return o, nil
+	}
+	known := make(map[string]bool)
+	for _, k := range jsonKeys(FeatureOverrides{}) {
+		known[k] = true
+	}
+	m := make(map[string]bool)
+	var unknown []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		on := true
+		if strings.HasPrefix(item, "-") {
+			on = false
+		}
+		item = strings.TrimLeft(item, "+-")
+		switch {
+		case item == "":
+		case known[item]:
+			m[item] = on
+		default:
+			unknown = append(unknown, item)
+		}
+	}
+	data, _ := json.Marshal(m)
+	_ = json.Unmarshal(data, &o)
+	return o, unknown
+}
+
+// parseLayoutLines parses "model,context,git;quota,tools" into layout lines.
+// Returns nil for an empty string so the mode falls through to lower layers.
+func parseLayoutLines(s string) [][]string {
+	var lines [][]string
+	for _, line := range strings.Split(s, ";") {
+		var ids []string
+		for _, id := range strings.Split(line, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			lines = append(lines, ids)
+		}
+	}
+	return lines
+}
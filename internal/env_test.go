@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFeatureList(t *testing.T) {
+	t.Parallel()
+
+	o, unknown := parseFeatureList(" git , -Account,+tools,unknown,-")
+	if o.Git == nil || !*o.Git {
+		t.Errorf("bare name should enable git, got %v", o.Git)
+	}
+	if o.Account == nil || *o.Account {
+		t.Errorf("-account should disable account, got %v", o.Account)
+	}
+	if o.Tools == nil || !*o.Tools {
+		t.Errorf("+tools should enable tools, got %v", o.Tools)
+	}
+	if o.Quota != nil {
+		t.Errorf("unlisted feature should stay absent, got %v", *o.Quota)
+	}
+	if len(unknown) != 1 || unknown[0] != "unknown" {
+		t.Errorf("unknown names should be returned, got %v", unknown)
+	}
+
+	if empty, _ := parseFeatureList(""); empty != (FeatureOverrides{}) {
+		t.Errorf("empty list should produce no overrides, got %+v", empty)
+	}
+}
+
+func TestParseLayoutLines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want [][]string
+	}{
+		{"", nil},
+		{"model", [][]string{{"model"}}},
+		{"model, context ,git;quota;;tools,", [][]string{{"model", "context", "git"}, {"quota"}, {"tools"}}},
+	}
+	for _, tt := range tests {
+		got := parseLayoutLines(tt.in)
+		if len(got) != len(tt.want) {
+			t.Errorf("parseLayoutLines(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if len(got[i]) != len(tt.want[i]) {
+				t.Errorf("parseLayoutLines(%q) line %d = %v, want %v", tt.in, i, got[i], tt.want[i])
+				continue
+			}
+			for j := range got[i] {
+				if got[i][j] != tt.want[i][j] {
+					t.Errorf("parseLayoutLines(%q)[%d][%d] = %q, want %q", tt.in, i, j, got[i][j], tt.want[i][j])
+				}
+			}
+		}
+	}
+}
+
+func TestEnvConfigFile_Thresholds(t *testing.T) {
+	t.Setenv("HOWL_THRESHOLD_CONTEXT_DANGER", "90")
+	t.Setenv("HOWL_THRESHOLD_SESSION_COST_HIGH", " 12.5 ")
+	t.Setenv("HOWL_THRESHOLD_WAIT_HIGH", "abc")     // not a number: ignored
+	t.Setenv("HOWL_THRESHOLD_CACHE_GOOD", "40.5")   // int field: ignored
+	t.Setenv("HOWL_THRESHOLD_QUOTA_LOW", `1,"x":2`) // injection attempt: ignored
+
+	file, problems := envConfigFile()
+	th := file.Thresholds
+	if !intPtrEq(th.ContextDanger, intPtr(90)) {
+		t.Errorf("ContextDanger = %s, want 90", ptrIntToString(th.ContextDanger))
+	}
+	if !floatPtrEq(th.SessionCostHigh, floatPtr(12.5)) {
+		t.Errorf("SessionCostHigh = %s, want 12.5", ptrFloatToString(th.SessionCostHigh))
+	}
+	if th.WaitHigh != nil || th.CacheGood != nil || th.QuotaLow != nil {
+		t.Errorf("malformed values should be ignored, got wait=%s cache=%s quota=%s",
+			ptrIntToString(th.WaitHigh), ptrIntToString(th.CacheGood), ptrFloatToString(th.QuotaLow))
+	}
+	if len(problems) != 3 {
+		t.Errorf("expected 3 problems for malformed values, got %v", problems)
+	}
+}
+
+func TestLoadConfig_EnvOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	configDir := filepath.Join(tmpDir, ".claude", "hud")
+	os.MkdirAll(configDir, 0755)
+	os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"preset":"developer","thresholds":{"context_danger":80}}`), 0644)
+
+	t.Setenv("HOWL_PRESET", "full")
+	t.Setenv("HOWL_FEATURES", "-account,effort")
+	t.Setenv("HOWL_THRESHOLD_CONTEXT_DANGER", "92")
+	t.Setenv("HOWL_LAYOUT_DANGER", "model,context")
+
+	cfg, _ := LoadConfig()
+	if cfg.Preset != "full" {
+		t.Errorf("HOWL_PRESET should win over file preset, got %s", cfg.Preset)
+	}
+	if cfg.Features.Account {
+		t.Errorf("HOWL_FEATURES=-account should disable account")
+	}
+	if !cfg.Features.Effort {
+		t.Errorf("HOWL_FEATURES=effort should enable effort")
+	}
+	if cfg.Thresholds.ContextDanger != 92 {
+		t.Errorf("env threshold should win over file, got %d", cfg.Thresholds.ContextDanger)
+	}
+	if len(cfg.Layout.Danger) != 1 || len(cfg.Layout.Danger[0]) != 2 {
+		t.Errorf("HOWL_LAYOUT_DANGER should set danger layout, got %v", cfg.Layout.Danger)
+	}
+}
+
+func TestLoadConfig_EnvZeroDisablesBudget(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	configDir := filepath.Join(tmpDir, ".claude", "hud")
+	os.MkdirAll(configDir, 0755)
+	os.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"thresholds":{"budget_day":20,"budget_month":300,"budget_danger":100}}`), 0644)
+
+	t.Setenv("HOWL_THRESHOLD_BUDGET_DAY", "0")
+	t.Setenv("HOWL_THRESHOLD_BUDGET_DANGER", "0")
+
+	cfg, problems := LoadConfig()
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+	if cfg.Thresholds.BudgetDay != 0 {
+		t.Errorf("HOWL_THRESHOLD_BUDGET_DAY=0 should disable the file budget, got %v", cfg.Thresholds.BudgetDay)
+	}
+	if cfg.Thresholds.BudgetDanger != 0 {
+		t.Errorf("HOWL_THRESHOLD_BUDGET_DANGER=0 should disable the file trigger, got %d", cfg.Thresholds.BudgetDanger)
+	}
+	if cfg.Thresholds.BudgetMonth != 300 {
+		t.Errorf("unset env budget should keep the file value, got %v", cfg.Thresholds.BudgetMonth)
+	}
+}
+
+func TestEnvConfigFile_PricingModels(t *testing.T) {
+	t.Setenv("HOWL_PRICING_MODELS", `{"opus-4-6":{"input":4.5,"output":22.5}}`)
+
+	file, problems := envConfigFile()
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+	if p, ok := file.Pricing.Models["opus-4-6"]; !ok || p.Input != 4.5 || p.Output != 22.5 {
+		t.Errorf("HOWL_PRICING_MODELS should set model prices, got %+v", file.Pricing.Models)
+	}
+
+	t.Setenv("HOWL_PRICING_MODELS", `{"opus-4-6":`)
+	file, problems = envConfigFile()
+	if file.Pricing.Models != nil {
+		t.Errorf("malformed HOWL_PRICING_MODELS should be ignored, got %+v", file.Pricing.Models)
+	}
+	if len(problems) != 1 {
+		t.Errorf("expected 1 problem for malformed JSON, got %v", problems)
+	}
+}
+
+func TestUserConfigPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("HOWL_CONFIG", "")
+	xdg := filepath.Join(tmpDir, "xdg")
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	legacy := filepath.Join(tmpDir, ".claude", "hud", "config.json")
+	xdgPath := filepath.Join(xdg, "howl", "config.json")
+
+	if got := UserConfigPath(); got != legacy {
+		t.Errorf("no files: UserConfigPath() = %q, want legacy %q", got, legacy)
+	}
+
+	os.MkdirAll(filepath.Dir(xdgPath), 0755)
+	os.WriteFile(xdgPath, []byte(`{"preset":"minimal"}`), 0644)
+	if got := UserConfigPath(); got != xdgPath {
+		t.Errorf("XDG file exists: UserConfigPath() = %q, want %q", got, xdgPath)
+	}
+	if cfg, _ := LoadConfig(); cfg.Preset != "minimal" {
+		t.Errorf("XDG config should be loaded, got preset %s", cfg.Preset)
+	}
+
+	explicit := filepath.Join(tmpDir, "custom.json")
+	os.WriteFile(explicit, []byte(`{"preset":"cost-focused"}`), 0644)
+	t.Setenv("HOWL_CONFIG", explicit)
+	if got := UserConfigPath(); got != explicit {
+		t.Errorf("HOWL_CONFIG set: UserConfigPath() = %q, want %q", got, explicit)
+	}
+	if cfg, _ := LoadConfig(); cfg.Preset != "cost-focused" {
+		t.Errorf("HOWL_CONFIG should be loaded, got preset %s", cfg.Preset)
+	}
+}
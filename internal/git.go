@@ -7,8 +7,8 @@ import (
 
 // GitInfo represents the current git repository status.
 type GitInfo struct {
-	Branch string
-	Dirty  bool
+	Branch string `json:"branch"`
+	Dirty  bool   `json:"dirty"`
 }
 
 // GetGitInfo runs git commands with a tight timeout.
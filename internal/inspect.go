@@ -0,0 +1,340 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Problem kinds for ConfigProblem.Kind.
+const (
+	ProblemInvalid   = "invalid"   // unreadable or unparseable; the layer or value was dropped
+	ProblemCorrected = "corrected" // value replaced by a valid one
+	ProblemUnknown   = "unknown"   // key, feature or segment Howl does not recognize
+)
+
+// ConfigProblem is one issue found while loading config. Source names the
+// layer it came from ("user", "project", "env") or "merged" for problems found
+// after layering (unknown preset, corrected thresholds, unknown segments).
+type ConfigProblem struct {
+	Source  string `json:"source"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (p ConfigProblem) String() string {
+	return p.Source + ": " + p.Message
+}
+
+// ConfigLayerInfo describes one config source consulted by InspectConfig.
+type ConfigLayerInfo struct {
+	Source string // "user", "project" or "env"
+	Path   string // file path; "" for env
+	Status string // "loaded", "not found", "invalid" or "not set"
+}
+
+// ConfigValue is one effective config value and the layer it came from.
+type ConfigValue struct {
+	Key    string // dotted JSON path, e.g. "thresholds.context_danger"
+	Value  string // JSON-encoded value
+	Origin string // "default", "preset:<name>", "user", "project" or "env"
+}
+
+// ConfigReport is the fully merged config plus how it was assembled.
+type ConfigReport struct {
+	Config   Config
+	Layers   []ConfigLayerInfo
+	Values   []ConfigValue
+	Problems []ConfigProblem
+}
+
+// InspectConfig loads config exactly like LoadConfigForProject and records
+// which layers were read, where each effective value came from, and every
+// problem found along the way (parse errors, unknown keys, corrections).
+func InspectConfig(projectDir string) ConfigReport {
+	var r ConfigReport
+	var merged configFile
+	origins := map[string]string{"preset": "default"}
+	for _, key := range jsonKeys(Thresholds{}) {
+		origins["thresholds."+key] = "default"
+	}
+	for _, key := range jsonKeys(Pricing{}) {
+		origins["pricing."+key] = "default"
+	}
+
+	r.Layers, r.Problems = loadLayers(projectDir, func(source string, file configFile) {
+		merged = mergeConfigFiles(merged, file)
+		for _, key := range setKeys(file) {
+			origins[key] = source
+		}
+	})
+
+	cfg, fixes := resolveConfig(merged)
+	r.Config = cfg
+	r.Problems = append(r.Problems, fixes...)
+
+	// Features and layout modes not set by any layer come from the resolved
+	// preset.
+	for _, key := range jsonKeys(FeatureToggles{}) {
+		if _, ok := origins["features."+key]; !ok {
+			origins["features."+key] = "preset:" + cfg.Preset
+		}
+	}
+	for _, key := range jsonKeys(Layout{}) {
+		if _, ok := origins["layout."+key]; !ok {
+			origins["layout."+key] = "preset:" + cfg.Preset
+		}
+	}
+	if _, ok := presets[strings.ToLower(strings.TrimSpace(merged.Preset))]; !ok && origins["preset"] != "default" {
+		origins["preset"] += " (fallback)"
+	}
+	unvalidated := thresholdValues(mergeThresholds(DefaultThresholds(), merged.Thresholds))
+	for key, v := range thresholdValues(cfg.Thresholds) {
+		if unvalidated[key] != v {
+			origins["thresholds."+key] += " (corrected)"
+		}
+	}
+
+	r.Values = flattenConfig(cfg, origins)
+	return r
+}
+
+// loadLayers reads the user and project config files and the environment,
+// passing each usable layer to apply in precedence order (lowest first). It
+// returns the layers consulted and the problems found in them.
+func loadLayers(projectDir string, apply func(source string, file configFile)) ([]ConfigLayerInfo, []ConfigProblem) {
+	var layers []ConfigLayerInfo
+	var problems []ConfigProblem
+
+	files := []struct{ source, path string }{{"user", UserConfigPath()}}
+	if p := ProjectConfigPath(projectDir); p != "" {
+		files = append(files, struct{ source, path string }{"project", p})
+	}
+	for _, f := range files {
+		file, info, found := loadFileLayer(f.source, f.path)
+		// A missing fallback file is normal, but an explicit path must exist.
+		if f.source == "user" && info.Status == "not found" && os.Getenv(EnvConfig) != "" {
+			found = append(found, ConfigProblem{Source: f.source, Kind: ProblemInvalid, Message: EnvConfig + ": file not found"})
+		}
+		layers = append(layers, info)
+		problems = append(problems, found...)
+		if info.Status == "loaded" {
+			apply(f.source, file)
+		}
+	}
+
+	envFile, envProblems := envConfigFile()
+	envInfo := ConfigLayerInfo{Source: "env", Status: "not set"}
+	if howlEnvSet() {
+		envInfo.Status = "loaded"
+	}
+	layers = append(layers, envInfo)
+	problems = append(problems, envProblems...)
+	apply("env", envFile)
+	return layers, problems
+}
+
+// loadFileLayer reads and decodes one config file. The layer is usable only
+// when the returned status is "loaded"; problems explain why it is not, and
+// also list unknown keys in an otherwise valid file.
+func loadFileLayer(source, path string) (configFile, ConfigLayerInfo, []ConfigProblem) {
+	var file configFile
+	info := ConfigLayerInfo{Source: source, Path: path, Status: "not found"}
+	if path == "" {
+		return file, info, nil
+	}
+	invalid := func(msg string) []ConfigProblem {
+		return []ConfigProblem{{Source: source, Kind: ProblemInvalid, Message: msg}}
+	}
+
+	// Guard: check file size before reading
+	stat, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, info, nil
+	}
+	info.Status = "invalid"
+	if err != nil {
+		return file, info, invalid(err.Error())
+	}
+	if stat.Size() > maxConfigSize {
+		// Too large, DoS protection
+		return file, info, invalid(fmt.Sprintf("file is %d bytes, limit is %d", stat.Size(), maxConfigSize))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, info, invalid(err.Error())
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return configFile{}, info, invalid(describeJSONError(data, err)) // Malformed JSON
+	}
+
+	info.Status = "loaded"
+	var problems []ConfigProblem
+	for _, msg := range unknownKeys(data) {
+		problems = append(problems, ConfigProblem{Source: source, Kind: ProblemUnknown, Message: msg})
+	}
+	return file, info, problems
+}
+
+// describeJSONError formats a decode error with the line and column it
+// occurred at, when the error carries an offset.
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := lineCol(data, syntaxErr.Offset)
+		return fmt.Sprintf("line %d, column %d: %v", line, col, syntaxErr)
+	case errors.As(err, &typeErr):
+		line, col := lineCol(data, typeErr.Offset)
+		return fmt.Sprintf("line %d, column %d: %s must be %s, got %s", line, col, typeErr.Field, typeErr.Type, typeErr.Value)
+	default:
+		return err.Error()
+	}
+}
+
+// lineCol converts a byte offset into a 1-based line and column.
+func lineCol(data []byte, offset int64) (line, col int) {
+	line, col = 1, 1
+	for i := 0; i < len(data) && int64(i) < offset-1; i++ {
+		if data[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+// unknownKeys lists keys in a config file that Howl does not recognize, at the
+// top level and inside the features, thresholds, layout and pricing objects.
+func unknownKeys(data []byte) []string {
+	var top map[string]json.RawMessage
+	if json.Unmarshal(data, &top) != nil {
+		return nil
+	}
+	sections := map[string][]string{
+		"features":   jsonKeys(FeatureOverrides{}),
+		"thresholds": jsonKeys(ThresholdOverrides{}),
+		"layout":     jsonKeys(Layout{}),
+		"pricing":    jsonKeys(Pricing{}),
+	}
+	known := make(map[string]bool)
+	for _, k := range jsonKeys(configFile{}) {
+		known[k] = true
+	}
+
+	var problems []string
+	for _, key := range sortedKeys(top) {
+		switch {
+		case !known[key]:
+			problems = append(problems, fmt.Sprintf("unknown key %q", key))
+		case sections[key] != nil:
+			var nested map[string]json.RawMessage
+			if json.Unmarshal(top[key], &nested) != nil {
+				continue
+			}
+			allowed := make(map[string]bool)
+			for _, k := range sections[key] {
+				allowed[k] = true
+			}
+			for _, k := range sortedKeys(nested) {
+				if !allowed[k] {
+					problems = append(problems, fmt.Sprintf("unknown key %q", key+"."+k))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// presentKeys returns the dotted keys of the non-nil fields of an overrides
+// struct, prefixed with section.
+func presentKeys(section string, overrides any) []string {
+	data, _ := json.Marshal(overrides)
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(data, &fields)
+	var keys []string
+	for _, k := range sortedKeys(fields) {
+		if string(fields[k]) != "null" {
+			keys = append(keys, section+"."+k)
+		}
+	}
+	return keys
+}
+
+// setKeys returns the dotted keys a layer actually sets, using the same rules
+// as mergeConfigFiles: non-empty preset, non-nil features and thresholds,
+// non-empty layout modes (a Normal mode or legacy priority also sets Compact),
+// non-empty pricing mode and models.
+func setKeys(file configFile) []string {
+	var keys []string
+	if strings.TrimSpace(file.Preset) != "" {
+		keys = append(keys, "preset")
+	}
+
+	keys = append(keys, presentKeys("features", file.Features)...)
+	keys = append(keys, presentKeys("thresholds", file.Thresholds)...)
+
+	if len(file.Layout.Normal) > 0 || len(file.Priority) > 0 {
+		keys = append(keys, "layout.normal", "layout.compact")
+	}
+	if len(file.Layout.Compact) > 0 {
+		keys = append(keys, "layout.compact")
+	}
+	if len(file.Layout.Danger) > 0 {
+		keys = append(keys, "layout.danger")
+	}
+	if strings.TrimSpace(file.Pricing.Estimate) != "" {
+		keys = append(keys, "pricing.estimate")
+	}
+	if len(file.Pricing.Models) > 0 {
+		keys = append(keys, "pricing.models")
+	}
+	return keys
+}
+
+// flattenConfig lists every effective value in cfg as dotted keys, sorted.
+func flattenConfig(cfg Config, origins map[string]string) []ConfigValue {
+	data, _ := json.Marshal(cfg)
+	var top map[string]json.RawMessage
+	_ = json.Unmarshal(data, &top)
+
+	var values []ConfigValue
+	for _, key := range sortedKeys(top) {
+		var nested map[string]json.RawMessage
+		if json.Unmarshal(top[key], &nested) != nil {
+			values = append(values, ConfigValue{Key: key, Value: string(top[key]), Origin: origins[key]})
+			continue
+		}
+		for _, k := range sortedKeys(nested) {
+			full := key + "." + k
+			values = append(values, ConfigValue{Key: full, Value: string(nested[k]), Origin: origins[full]})
+		}
+	}
+	return values
+}
+
+// howlEnvSet reports whether any HOWL_* variable that feeds the env layer is set.
+func howlEnvSet() bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "HOWL_") && !strings.HasPrefix(kv, EnvConfig+"=") {
+			return true
+		}
+	}
+	return false
+}
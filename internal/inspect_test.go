@@ -0,0 +1,290 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeUserConfig writes content to the legacy user config path under a
+// fresh HOME and returns that HOME.
+func writeUserConfig(t *testing.T, content string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOWL_CONFIG", "")
+
+	configDir := filepath.Join(tmpDir, ".claude", "hud")
+	os.MkdirAll(configDir, 0755)
+	os.WriteFile(filepath.Join(configDir, "config.json"), []byte(content), 0644)
+	return tmpDir
+}
+
+func problemMessages(r ConfigReport) string {
+	var msgs []string
+	for _, p := range r.Problems {
+		msgs = append(msgs, p.String())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func originOf(r ConfigReport, key string) string {
+	for _, v := range r.Values {
+		if v.Key == key {
+			return v.Origin
+		}
+	}
+	return ""
+}
+
+func TestLineCol(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("{\n  \"a\": 1,\n  \"b\" 2\n}")
+	tests := []struct {
+		offset    int64
+		line, col int
+	}{
+		{0, 1, 1},
+		{1, 1, 1},
+		{2, 1, 2},
+		{3, 2, 1},
+		{19, 3, 7},
+	}
+	for _, tt := range tests {
+		line, col := lineCol(data, tt.offset)
+		if line != tt.line || col != tt.col {
+			t.Errorf("lineCol(%d) = %d:%d, want %d:%d", tt.offset, line, col, tt.line, tt.col)
+		}
+	}
+}
+
+func TestInspectConfig_Clean(t *testing.T) {
+	writeUserConfig(t, `{"preset":"developer","features":{"quota":true},"thresholds":{"context_danger":90}}`)
+
+	r := InspectConfig("")
+	if len(r.Problems) != 0 {
+		t.Errorf("expected no problems, got:\n%s", problemMessages(r))
+	}
+	if r.Config.Preset != "developer" || !r.Config.Features.Quota {
+		t.Errorf("report config should match the merged file, got %+v", r.Config)
+	}
+
+	tests := []struct{ key, want string }{
+		{"preset", "user"},
+		{"features.quota", "user"},
+		{"features.git", "preset:developer"},
+		{"thresholds.context_danger", "user"},
+		{"thresholds.context_warning", "default"},
+		{"layout.normal", "preset:developer"},
+	}
+	for _, tt := range tests {
+		if got := originOf(r, tt.key); got != tt.want {
+			t.Errorf("origin of %s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestInspectConfig_SyntaxErrorPosition(t *testing.T) {
+	writeUserConfig(t, "{\n  \"preset\": \"full\",\n  \"thresholds\": {\"context_danger\" 90}\n}")
+
+	r := InspectConfig("")
+	if len(r.Problems) != 1 {
+		t.Fatalf("expected 1 problem, got:\n%s", problemMessages(r))
+	}
+	if !strings.Contains(r.Problems[0].Message, "line 3, column 35") {
+		t.Errorf("problem should carry line:col, got %q", r.Problems[0].Message)
+	}
+	if r.Layers[0].Status != "invalid" {
+		t.Errorf("user layer status = %q, want invalid", r.Layers[0].Status)
+	}
+	if r.Config.Thresholds.ContextDanger != 85 {
+		t.Errorf("invalid file should be skipped, got context_danger %d", r.Config.Thresholds.ContextDanger)
+	}
+}
+
+func TestInspectConfig_TypeError(t *testing.T) {
+	writeUserConfig(t, `{"thresholds":{"context_danger":"high"}}`)
+
+	r := InspectConfig("")
+	if len(r.Problems) != 1 || !strings.Contains(r.Problems[0].Message, "thresholds.context_danger") {
+		t.Errorf("type error should name the field, got:\n%s", problemMessages(r))
+	}
+	if len(r.Problems) == 1 && r.Problems[0].Kind != ProblemInvalid {
+		t.Errorf("type error kind = %q, want %q", r.Problems[0].Kind, ProblemInvalid)
+	}
+}
+
+func TestInspectConfig_LegacyPriority(t *testing.T) {
+	writeUserConfig(t, `{"priority":["tool_errors","line_changes"]}`)
+
+	r := InspectConfig("")
+	if len(r.Problems) != 0 {
+		t.Errorf("legacy priority should load cleanly, got:\n%s", problemMessages(r))
+	}
+	metrics := r.Config.Layout.Normal[2]
+	if metrics[0] != "tool_errors" || !slices.Contains(metrics, "line_changes") {
+		t.Errorf("priority should lead the metrics line with tool_errors, got %v", metrics)
+	}
+	if origin := originOf(r, "layout.normal"); origin != "user" {
+		t.Errorf("layout.normal origin = %q, want user", origin)
+	}
+	if r.Config.Layout.Compact[1][0] != "tool_errors" {
+		t.Errorf("priority should apply to compact too, got %v", r.Config.Layout.Compact[1])
+	}
+}
+
+func TestInspectConfig_ReportsEveryProblem(t *testing.T) {
+	writeUserConfig(t, `{
+		"preset": "dev",
+		"priority": ["git"],
+		"colour": "blue",
+		"features": {"gti": true},
+		"thresholds": {"context_danger": 150, "contxt_warning": 60, "quota_critical": 40, "quota_low": 20},
+		"layout": {"normal": [["model", "contxt"]], "extra": []}
+	}`)
+
+	r := InspectConfig("")
+	got := problemMessages(r)
+	for _, want := range []string{
+		`user: unknown key "colour"`,
+		`user: unknown key "features.gti"`,
+		`user: unknown key "thresholds.contxt_warning"`,
+		`user: unknown key "layout.extra"`,
+		`merged: preset: unknown preset "dev", using "full"`,
+		`merged: thresholds.context_danger: 150 out of range, clamped to 100`,
+		`merged: thresholds.quota_low: 20 inverted with its pair`,
+		`merged: layout: unknown segment "contxt"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing problem %q in:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "priority") {
+		t.Errorf("legacy priority should be accepted, got:\n%s", got)
+	}
+	for _, p := range r.Problems {
+		want := ProblemCorrected
+		if strings.Contains(p.Message, "unknown key") || strings.Contains(p.Message, "unknown segment") {
+			want = ProblemUnknown
+		}
+		if p.Kind != want {
+			t.Errorf("%s: kind = %q, want %q", p, p.Kind, want)
+		}
+	}
+
+	if origin := originOf(r, "thresholds.context_danger"); origin != "user (corrected)" {
+		t.Errorf("corrected threshold origin = %q, want %q", origin, "user (corrected)")
+	}
+	if origin := originOf(r, "preset"); origin != "user (fallback)" {
+		t.Errorf("fallback preset origin = %q, want %q", origin, "user (fallback)")
+	}
+}
+
+func TestInspectConfig_LayerProvenance(t *testing.T) {
+	writeUserConfig(t, `{"preset":"developer","thresholds":{"context_danger":80,"context_warning":60}}`)
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "howl.json"), []byte(`{"thresholds":{"context_danger":90},"layout":{"normal":[["model"]]}}`), 0644)
+	t.Setenv("HOWL_FEATURES", "-git")
+
+	r := InspectConfig(projectDir)
+	if len(r.Layers) != 3 {
+		t.Fatalf("expected user, project and env layers, got %+v", r.Layers)
+	}
+	for i, want := range []string{"loaded", "loaded", "loaded"} {
+		if r.Layers[i].Status != want {
+			t.Errorf("layer %s status = %q, want %q", r.Layers[i].Source, r.Layers[i].Status, want)
+		}
+	}
+
+	tests := []struct{ key, want string }{
+		{"preset", "user"},
+		{"thresholds.context_warning", "user"},
+		{"thresholds.context_danger", "project"},
+		{"layout.normal", "project"},
+		{"layout.compact", "project"},
+		{"layout.danger", "preset:developer"},
+		{"features.git", "env"},
+		{"features.account", "preset:developer"},
+	}
+	for _, tt := range tests {
+		if got := originOf(r, tt.key); got != tt.want {
+			t.Errorf("origin of %s = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+// LoadConfigForProject skips provenance but must resolve the same config and
+// problems as InspectConfig.
+func TestLoadConfigForProject_MatchesInspect(t *testing.T) {
+	writeUserConfig(t, `{"preset":"developer","colour":true,"thresholds":{"context_danger":150}}`)
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "howl.json"), []byte(`{"layout":{"normal":[["model","bogus"]]}}`), 0644)
+	t.Setenv("HOWL_FEATURES", "-git,acount")
+
+	r := InspectConfig(projectDir)
+	cfg, problems := LoadConfigForProject(projectDir)
+	if !reflect.DeepEqual(cfg, r.Config) {
+		t.Errorf("config = %+v, want %+v", cfg, r.Config)
+	}
+	if !reflect.DeepEqual(problems, r.Problems) || len(problems) != 4 {
+		t.Errorf("problems = %v, want the 4 InspectConfig found: %v", problems, r.Problems)
+	}
+}
+
+func TestInspectConfig_Pricing(t *testing.T) {
+	writeUserConfig(t, `{"pricing":{"estimate":"always","models":{"opus":{"input":4,"output":20}},"currency":"EUR"}}`)
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, ".claude"), 0755)
+	os.WriteFile(filepath.Join(projectDir, ".claude", "howl.json"), []byte(`{"pricing":{"models":{"sonnet":{"input":2,"output":10}}}}`), 0644)
+	t.Setenv("HOWL_PRICING_ESTIMATE", "never")
+
+	r := InspectConfig(projectDir)
+	p := r.Config.Pricing
+	if p.Estimate != PricingNever || p.Models["opus"].Input != 4 || p.Models["sonnet"].Input != 2 {
+		t.Errorf("pricing = %+v, want env mode and both layers' models", p)
+	}
+	if msgs := problemMessages(r); !strings.Contains(msgs, `"pricing.currency"`) {
+		t.Errorf("unknown pricing key not reported:\n%s", msgs)
+	}
+	if got := originOf(r, "pricing.estimate"); got != "env" {
+		t.Errorf("origin of pricing.estimate = %q, want env", got)
+	}
+	if got := originOf(r, "pricing.models"); got != "project" {
+		t.Errorf("origin of pricing.models = %q, want project", got)
+	}
+}
+
+func TestInspectConfig_MissingExplicitFile(t *testing.T) {
+	writeUserConfig(t, `{"preset":"minimal"}`)
+
+	// Without HOWL_CONFIG, missing fallback files are silent.
+	if r := InspectConfig(t.TempDir()); len(r.Problems) != 0 {
+		t.Fatalf("fallback files: problems = %v, want none", r.Problems)
+	}
+
+	t.Setenv("HOWL_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	r := InspectConfig("")
+	if len(r.Problems) != 1 || r.Problems[0].Kind != ProblemInvalid || r.Problems[0].Message != "HOWL_CONFIG: file not found" {
+		t.Errorf("problems = %v, want HOWL_CONFIG: file not found", r.Problems)
+	}
+	if r.Config.Preset != "full" {
+		t.Errorf("preset = %s, want the default (the fallback file is not read)", r.Config.Preset)
+	}
+}
+
+func TestInspectConfig_OversizedFile(t *testing.T) {
+	writeUserConfig(t, `{"preset":"full","pad":"`+strings.Repeat("x", maxConfigSize)+`"}`)
+
+	r := InspectConfig("")
+	if len(r.Problems) != 1 || !strings.Contains(r.Problems[0].Message, "limit") {
+		t.Errorf("oversized file should be reported, got:\n%s", problemMessages(r))
+	}
+}
@@ -0,0 +1,517 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Layout lists statusline lines as ordered arrays of segment IDs, separately
+// for normal and danger mode. Empty modes fall back to the preset's layout.
+type Layout struct {
+	Normal [][]string `json:"normal"`
+	// Compact is the normal-mode layout used when no quota bars are shown
+	// (API-key users, quota feature off). Falls back to Normal when unset.
+	Compact [][]string `json:"compact"`
+	Danger  [][]string `json:"danger"`
+}
+
+// DefaultLayout returns the layout of the default (full) preset.
+func DefaultLayout() Layout {
+	return PresetLayout("full")
+}
+
+// PresetLayout returns the built-in layout of a preset. Every preset shares
+// the layout that reproduces the statusline from before layouts were
+// configurable: presets differ only in their feature toggles, which decide
+// which segments show.
+func PresetLayout(_ string) Layout {
+	// L1: model(+context size) | ⚙! | ✗streak | account | git | out | cost | today | budgets | duration
+	// L2: context bar | context sparkline | 5h quota | 7d quota
+	return stackedLayout(
+		[]string{"model", "config_warning", "error_streak", "account", "git", "output_tokens", "cost", "cost_today", "budget", "duration"},
+		[]string{
+			"line_changes", "cache_efficiency", "cache_savings", "api_wait_ratio", "cost_velocity", "throughput",
+			"compactions", "tool_errors", "vim_mode", "agent_name", "effort", "thinking", "session_name",
+			"pull_request", "worktree", "version",
+		},
+		[]string{"running_tool", "todos", "tools", "agents"},
+	)
+}
+
+// stackedLayout builds the multi-line preset layout: info line, context and
+// quota bars, then the given lines. Without quota bars (Compact) the context
+// bar is inlined into the info line after its warnings.
+func stackedLayout(info []string, lines ...[]string) Layout {
+	normal := append([][]string{info, {"context", "context_sparkline", "quota"}}, lines...)
+
+	inline := make([]string, 0, len(info)+2)
+	for _, id := range info {
+		inline = append(inline, id)
+		if id == "error_streak" {
+			inline = append(inline, "context", "context_sparkline")
+		}
+	}
+	compact := append([][]string{inline}, lines...)
+	return Layout{Normal: normal, Compact: compact, Danger: dangerLayout()}
+}
+
+// dangerLayout is the layout every preset uses past a danger threshold.
+func dangerLayout() [][]string {
+	// L1: model | ⚙! | ✗streak | 🔴 context (remaining+ETA) | ⟲compactions | quota
+	// L2: workspace/git | Δchanges | In/Out | C:X% | $cost $/h | budgets | duration
+	return [][]string{
+		{"model", "config_warning", "error_streak", "context", "compactions", "quota"},
+		{"workspace", "line_changes", "tokens", "cache_efficiency", "cost", "budget", "duration"},
+	}
+}
+
+// maxPriority caps the legacy priority list, as /howl:customize always did.
+const maxPriority = 5
+
+// applyPriority migrates the legacy "priority" list: within each line, the
+// slots holding listed segments are refilled in list order. Other segments
+// keep their place, and listed segments absent from a line are ignored.
+// Duplicates and entries past maxPriority are dropped. lines is not modified.
+func applyPriority(lines [][]string, priority []string) [][]string {
+	rank := make(map[string]int)
+	for _, id := range priority {
+		if _, dup := rank[id]; !dup && len(rank) < maxPriority {
+			rank[id] = len(rank)
+		}
+	}
+	if len(rank) == 0 {
+		return lines
+	}
+
+	result := make([][]string, len(lines))
+	for i, line := range lines {
+		result[i] = append([]string(nil), line...)
+		var slots []int
+		var ids []string
+		for j, id := range line {
+			if _, ok := rank[id]; ok {
+				slots = append(slots, j)
+				ids = append(ids, id)
+			}
+		}
+		sort.SliceStable(ids, func(a, b int) bool { return rank[ids[a]] < rank[ids[b]] })
+		for k, j := range slots {
+			result[i][j] = ids[k]
+		}
+	}
+	return result
+}
+
+// mergeLayout merges override into base per mode. A mode present in override
+// replaces the base mode entirely; a custom Normal without Compact is reused
+// for Compact so the user's ordering applies with and without quota bars.
+func mergeLayout(base, override Layout) Layout {
+	result := base
+	if len(override.Normal) > 0 {
+		result.Normal = override.Normal
+		result.Compact = override.Normal
+	}
+	if len(override.Compact) > 0 {
+		result.Compact = override.Compact
+	}
+	if len(override.Danger) > 0 {
+		result.Danger = override.Danger
+	}
+	return result
+}
+
+// segmentCtx carries per-line state into segment renderers.
+type segmentCtx struct {
+	rc     *RenderContext
+	danger bool
+	line   map[string]bool // segment IDs present on the current line
+	width  int             // remaining column budget (flexible segments only)
+}
+
+// enabled reports whether a feature-gated segment should render. Danger mode
+// ignores feature toggles so critical information is always visible.
+func (sc *segmentCtx) enabled(feature bool) bool {
+	return sc.danger || feature
+}
+
+// segment renders one statusline part. Returns "" when there is nothing to
+// show; the layout engine skips empty parts and empty lines.
+type segment struct {
+	render   func(sc *segmentCtx) string
+	flexible bool // rendered last, with the width left over by fixed segments
+}
+
+// segments is the registry of segment IDs usable in Layout. IDs reuse the
+// FeatureToggles JSON names where a toggle gates the segment.
+var segments = map[string]segment{
+	"model": {render: func(sc *segmentCtx) string {
+		// Show the window size on the badge only when the context bar is elsewhere.
+		size := 0
+		if !sc.line["context"] {
+			size = sc.rc.Data.ContextWindow.ContextWindowSize
+		}
+		return renderModelBadge(sc.rc.Data.Model, size)
+	}},
+	"config_warning": {render: func(sc *segmentCtx) string {
+		return renderConfigWarning(sc.rc.ConfigProblems)
+	}},
+	// A failure streak is a warning, shown whatever the feature toggles.
+	"error_streak": {render: func(sc *segmentCtx) string {
+		if sc.rc.Tools == nil {
+			return ""
+		}
+		return renderErrorStreak(sc.rc.Tools.ErrorStreak, sc.rc.Config.Thresholds.ErrorStreak)
+	}},
+	"context": {render: func(sc *segmentCtx) string {
+		d, m, t := sc.rc.Data, sc.rc.Metrics, sc.rc.Config.Thresholds
+		// The 🔴 alarm is for a full context; danger mode tripped by a budget
+		// alone keeps the normal bar.
+		if sc.danger && m.ContextPercent >= t.ContextDanger {
+			// Without enough history, extrapolate from session start. A fitted
+			// trend without an ETA means context is flat, shrinking or just
+			// compacted, where the lifetime estimate would be stale.
+			eta := m.ContextETAMinutes
+			if eta == nil && !m.ContextETAFitted {
+				eta = lifetimeContextETA(m.ContextPercent, d.Cost.TotalDurationMS)
+			}
+			return renderContextBarDanger(m.ContextPercent, d.ContextWindow, eta, t)
+		}
+		var eta *float64
+		if m.ContextPercent >= t.ContextWarning {
+			eta = m.ContextETAMinutes
+		}
+		return renderContextBar(m.ContextPercent, d.ContextWindow, eta, t)
+	}},
+	"account": {render: func(sc *segmentCtx) string {
+		a := sc.rc.Account
+		if !sc.enabled(sc.rc.Config.Features.Account) || a == nil || a.EmailAddress == "" {
+			return ""
+		}
+		return renderAccount(a)
+	}},
+	"git": {render: func(sc *segmentCtx) string {
+		g := sc.rc.Git
+		if !sc.enabled(sc.rc.Config.Features.Git) || g == nil {
+			return ""
+		}
+		return renderGitCompact(g)
+	}},
+	"workspace": {render: func(sc *segmentCtx) string {
+		ws := renderWorkspace(sc.rc.Data)
+		if ws == "" {
+			return ""
+		}
+		if g := sc.rc.Git; g != nil && sc.enabled(sc.rc.Config.Features.Git) {
+			return ws + renderGitCompact(g)
+		}
+		return ws
+	}},
+	"output_tokens": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.OutputTokens) {
+			return ""
+		}
+		return renderOutputTokens(sc.rc.Data.ContextWindow.CurrentUsage)
+	}},
+	"tokens": {render: func(sc *segmentCtx) string {
+		cu := sc.rc.Data.ContextWindow.CurrentUsage
+		if cu == nil {
+			return ""
+		}
+		return renderTokenIO(cu)
+	}},
+	"cost": {render: func(sc *segmentCtx) string {
+		t := sc.rc.Config.Thresholds
+		s := renderCost(SessionCost(sc.rc.Data, sc.rc.CostEstimate), t)
+		if s != "" && sc.rc.CostEstimate != nil {
+			s += grey + " est." + Reset
+		}
+		v := sc.rc.Metrics.costVelocity()
+		if s == "" || !sc.danger || v == nil {
+			return s
+		}
+		hourly := *v * 60
+		return s + " " + fmt.Sprintf("%s$%.1f/h%s", yellow, hourly, Reset)
+	}},
+	"duration": {render: func(sc *segmentCtx) string {
+		return renderDuration(sc.rc.Data.Cost.TotalDurationMS)
+	}},
+	"quota": {render: func(sc *segmentCtx) string {
+		u, t := sc.rc.Usage, sc.rc.Config.Thresholds
+		if !sc.enabled(sc.rc.Config.Features.Quota) || u == nil {
+			return ""
+		}
+		parts := make([]string, 0, 2)
+		if s := renderQuotaWindow(u.FiveHour, "5h", t); s != "" {
+			parts = append(parts, s)
+		}
+		if s := renderQuotaWindow(u.SevenDay, "7d", t); s != "" {
+			parts = append(parts, s)
+		}
+		return joinParts(parts)
+	}},
+	"line_changes": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.LineChanges) {
+			return ""
+		}
+		return renderLineChanges(sc.rc.Data.Cost)
+	}},
+	"cache_efficiency": {render: func(sc *segmentCtx) string {
+		ce, t := sc.rc.Metrics.CacheEfficiency, sc.rc.Config.Thresholds
+		if ce == nil {
+			return ""
+		}
+		if sc.danger {
+			return renderCacheEfficiencyCompact(*ce, t)
+		}
+		if !sc.rc.Config.Features.CacheEfficiency || *ce <= 0 {
+			return ""
+		}
+		return renderCacheEfficiencyLabeled(*ce, t, sc.rc.Data.ContextWindow.CurrentUsage)
+	}},
+	"cache_savings": {render: func(sc *segmentCtx) string {
+		m := sc.rc.Metrics
+		if !sc.enabled(sc.rc.Config.Features.CacheSavings) || (m.CacheSavings == nil && m.CacheSavingsTotal == nil) {
+			return ""
+		}
+		return renderCacheSavings(m.CacheSavings, m.CacheSavingsTotal)
+	}},
+	"api_wait_ratio": {render: func(sc *segmentCtx) string {
+		w := sc.rc.Metrics.APIWaitRatio
+		if !sc.enabled(sc.rc.Config.Features.APIWaitRatio) || w == nil || *w <= 0 {
+			return ""
+		}
+		return renderAPIRatioLabeled(*w, sc.rc.Config.Thresholds)
+	}},
+	"cost_velocity": {render: func(sc *segmentCtx) string {
+		m := sc.rc.Metrics
+		v := m.costVelocity()
+		if !sc.enabled(sc.rc.Config.Features.CostVelocity) || v == nil {
+			return ""
+		}
+		s := renderCostVelocityLabeled(*v, sc.rc.Config.Thresholds)
+		if sc.rc.Config.Features.VelocityAverage && m.WindowCostPerMinute != nil && m.CostPerMinute != nil {
+			s += renderVelocityAverage(*m.CostPerMinute)
+		}
+		return s
+	}},
+	"throughput": {render: func(sc *segmentCtx) string {
+		v := sc.rc.Metrics.WindowOutputPerMinute
+		if !sc.enabled(sc.rc.Config.Features.Throughput) || v == nil {
+			return ""
+		}
+		return renderThroughput(*v)
+	}},
+	"budget": {render: func(sc *segmentCtx) string {
+		return renderBudgets(activeBudgets(sc.rc.Data, sc.rc.CostEstimate, sc.rc.Ledger, sc.rc.Config.Thresholds))
+	}},
+	"cost_today": {render: func(sc *segmentCtx) string {
+		l := sc.rc.Ledger
+		if !sc.enabled(sc.rc.Config.Features.Ledger) || l == nil {
+			return ""
+		}
+		return renderLedgerTotal(l.Today, "today")
+	}},
+	"cost_week": {render: func(sc *segmentCtx) string {
+		l := sc.rc.Ledger
+		if !sc.enabled(sc.rc.Config.Features.Ledger) || l == nil {
+			return ""
+		}
+		return renderLedgerTotal(l.Week, "week")
+	}},
+	"cost_month": {render: func(sc *segmentCtx) string {
+		l := sc.rc.Ledger
+		if !sc.enabled(sc.rc.Config.Features.Ledger) || l == nil {
+			return ""
+		}
+		return renderLedgerTotal(l.Month, "month")
+	}},
+	"context_sparkline": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.Sparkline) {
+			return ""
+		}
+		return renderContextSparkline(sc.rc.History, sc.rc.Config.Thresholds)
+	}},
+	"cost_sparkline": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.Sparkline) {
+			return ""
+		}
+		return renderCostSparkline(sc.rc.History, sc.rc.Config.Thresholds)
+	}},
+	"quota_sparkline": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.Sparkline) {
+			return ""
+		}
+		return renderQuotaSparkline(sc.rc.History, sc.rc.Config.Thresholds)
+	}},
+	"compactions": {render: func(sc *segmentCtx) string {
+		m := sc.rc.Metrics
+		if !sc.enabled(sc.rc.Config.Features.Compactions) || m.Compactions == 0 {
+			return ""
+		}
+		ago := time.Duration(-1)
+		if m.LastCompaction != nil {
+			ago = max(time.Since(*m.LastCompaction), 0)
+		}
+		return renderCompactions(m.Compactions, ago)
+	}},
+	"vim_mode": {render: func(sc *segmentCtx) string {
+		v := sc.rc.Data.Vim
+		if !sc.enabled(sc.rc.Config.Features.VimMode) || v == nil {
+			return ""
+		}
+		return renderVimCompact(v.Mode)
+	}},
+	"agent_name": {render: func(sc *segmentCtx) string {
+		a := sc.rc.Data.Agent
+		if !sc.enabled(sc.rc.Config.Features.AgentName) || a == nil || a.Name == "" {
+			return ""
+		}
+		return renderAgentCompact(a.Name)
+	}},
+	"effort": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.Effort) {
+			return ""
+		}
+		return renderEffort(sc.rc.Data.Effort)
+	}},
+	"thinking": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.Thinking) {
+			return ""
+		}
+		return renderThinking(sc.rc.Data.Thinking)
+	}},
+	"session_name": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.SessionName) {
+			return ""
+		}
+		return renderSessionName(sc.rc.Data.SessionName)
+	}},
+	"pull_request": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.PullRequest) {
+			return ""
+		}
+		return renderPR(sc.rc.Data.PR)
+	}},
+	"worktree": {render: func(sc *segmentCtx) string {
+		if !sc.enabled(sc.rc.Config.Features.Worktree) {
+			return ""
+		}
+		return renderWorktreeName(sc.rc.Data.Worktree)
+	}},
+	"version": {render: func(sc *segmentCtx) string {
+		return renderVersion(sc.rc.Data.Version)
+	}},
+	"tools": {flexible: true, render: func(sc *segmentCtx) string {
+		ti := sc.rc.Tools
+		if !sc.enabled(sc.rc.Config.Features.Tools) || ti == nil || len(ti.Tools) == 0 {
+			return ""
+		}
+		return renderTools(ti.Tools, ti.Errors, max(sc.width, 20))
+	}},
+	"todos": {flexible: true, render: func(sc *segmentCtx) string {
+		ti := sc.rc.Tools
+		if !sc.enabled(sc.rc.Config.Features.Tools) || ti == nil {
+			return ""
+		}
+		// Rendered before tools, so capped to leave them room.
+		return renderTodos(ti.Todos, min(sc.width, maxTodosWidth))
+	}},
+	"running_tool": {render: func(sc *segmentCtx) string {
+		ti := sc.rc.Tools
+		if !sc.enabled(sc.rc.Config.Features.Tools) || ti == nil {
+			return ""
+		}
+		return renderRunningTool(ti.Running, time.Now())
+	}},
+	"tool_errors": {render: func(sc *segmentCtx) string {
+		ti := sc.rc.Tools
+		if !sc.enabled(sc.rc.Config.Features.ToolErrors) || ti == nil {
+			return ""
+		}
+		return renderToolErrors(ti.Failed, ti.Calls)
+	}},
+	"agents": {render: func(sc *segmentCtx) string {
+		ti := sc.rc.Tools
+		if !sc.enabled(sc.rc.Config.Features.Agents) || ti == nil {
+			return ""
+		}
+		return renderAgents(ti.AgentTree, ti.AgentsDone, ti.AgentsFailed, time.Now(), maxAgentsWidth)
+	}},
+}
+
+// unknownSegments returns segment IDs in l that are not in the registry, in
+// order of first appearance and without duplicates.
+func unknownSegments(l Layout) []string {
+	seen := make(map[string]bool)
+	var unknown []string
+	for _, mode := range [][][]string{l.Normal, l.Compact, l.Danger} {
+		for _, line := range mode {
+			for _, id := range line {
+				if _, ok := segments[id]; ok || seen[id] {
+					continue
+				}
+				seen[id] = true
+				unknown = append(unknown, id)
+			}
+		}
+	}
+	return unknown
+}
+
+// renderUnknownSegment marks an unrecognized segment ID in place so typos in
+// the layout are visible rather than silently dropped.
+func renderUnknownSegment(id string) string {
+	return dim + "?" + id + Reset
+}
+
+// renderLayout renders each layout line by resolving segment IDs through the
+// registry. Fixed segments render first; flexible segments then share the
+// terminal width left over on their line. Empty parts and lines are skipped.
+func renderLayout(rc *RenderContext, lines [][]string, danger bool) []string {
+	out := make([]string, 0, len(lines))
+	for _, ids := range lines {
+		sc := &segmentCtx{rc: rc, danger: danger, line: make(map[string]bool, len(ids))}
+		for _, id := range ids {
+			sc.line[id] = true
+		}
+
+		parts := make([]string, len(ids))
+		used := 0
+		for i, id := range ids {
+			seg, ok := segments[id]
+			switch {
+			case !ok:
+				parts[i] = renderUnknownSegment(id)
+			case !seg.flexible:
+				parts[i] = seg.render(sc)
+			default:
+				continue
+			}
+			if parts[i] != "" {
+				used += visibleLen(parts[i]) + 3 // 3 for " | " separator
+			}
+		}
+
+		sc.width = terminalColumns() - used
+		for i, id := range ids {
+			if seg, ok := segments[id]; ok && seg.flexible {
+				parts[i] = seg.render(sc)
+				if parts[i] != "" {
+					sc.width -= visibleLen(parts[i]) + 3
+				}
+			}
+		}
+
+		nonEmpty := parts[:0]
+		for _, p := range parts {
+			if p != "" {
+				nonEmpty = append(nonEmpty, p)
+			}
+		}
+		if len(nonEmpty) > 0 {
+			out = append(out, joinParts(nonEmpty))
+		}
+	}
+	return out
+}
@@ -0,0 +1,612 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresetLayout(t *testing.T) {
+	t.Parallel()
+
+	// Presets differ only in feature toggles, so every preset's default
+	// layout reproduces the statusline from before layouts existed.
+	full := PresetConfig("full").Layout
+	for _, name := range []string{"minimal", "developer", "cost-focused", "unknown"} {
+		if l := PresetLayout(name); !reflect.DeepEqual(l, full) {
+			t.Errorf("PresetLayout(%q) = %v, want the full layout", name, l.Normal)
+		}
+	}
+	if ids := unknownSegments(full); len(ids) > 0 {
+		t.Errorf("full layout has unknown segments %v", ids)
+	}
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}, Version: "2.1.0"}
+	wait, perMin := 40, 0.25
+
+	// minimal keeps the always-on version line.
+	lines := Render(RenderContext{Data: d, Metrics: ComputeMetrics(d), Config: PresetConfig("minimal")})
+	if len(lines) != 2 || !strings.Contains(lines[1], "v2.1.0") {
+		t.Errorf("minimal = %q, want L1 and a version line", lines)
+	}
+
+	// cost-focused keeps API wait ahead of cost velocity.
+	m := ComputeMetrics(d)
+	m.APIWaitRatio, m.CostPerMinute = &wait, &perMin
+	lines = Render(RenderContext{Data: d, Metrics: m, Config: PresetConfig("cost-focused")})
+	l3 := lines[len(lines)-1]
+	if w, c := strings.Index(l3, "Wait:"), strings.Index(l3, "$0.25/m"); w < 0 || c < w {
+		t.Errorf("cost-focused L3 = %q, want Wait before Cost", l3)
+	}
+}
+
+func TestDefaultLayout_AllSegmentsKnown(t *testing.T) {
+	t.Parallel()
+
+	if unknown := unknownSegments(DefaultLayout()); len(unknown) != 0 {
+		t.Errorf("DefaultLayout() references unknown segments: %v", unknown)
+	}
+}
+
+func TestApplyPriority(t *testing.T) {
+	t.Parallel()
+
+	lines := [][]string{{"model", "account", "git", "cost"}, {"context", "quota"}}
+	tests := []struct {
+		name     string
+		priority []string
+		want     [][]string
+	}{
+		{"none", nil, lines},
+		{"reorders listed slots", []string{"git", "account"}, [][]string{{"model", "git", "account", "cost"}, {"context", "quota"}}},
+		{"per line only", []string{"quota", "git", "account"}, [][]string{{"model", "git", "account", "cost"}, {"context", "quota"}}},
+		{"duplicates and absent ids", []string{"git", "git", "nope", "account"}, [][]string{{"model", "git", "account", "cost"}, {"context", "quota"}}},
+		{"capped at five", []string{"a", "b", "c", "d", "e", "git", "account"}, lines},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := applyPriority(lines, tt.priority); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyPriority(%v) = %v, want %v", tt.priority, got, tt.want)
+			}
+		})
+	}
+	if lines[0][1] != "account" {
+		t.Errorf("applyPriority modified its input: %v", lines)
+	}
+}
+
+func TestMergeLayout(t *testing.T) {
+	t.Parallel()
+
+	base := DefaultLayout()
+	custom := [][]string{{"model", "context", "git", "cost"}}
+
+	t.Run("empty override keeps base", func(t *testing.T) {
+		t.Parallel()
+		got := mergeLayout(base, Layout{})
+		if len(got.Normal) != len(base.Normal) || len(got.Compact) != len(base.Compact) || len(got.Danger) != len(base.Danger) {
+			t.Errorf("mergeLayout with empty override changed base: %+v", got)
+		}
+	})
+
+	t.Run("normal override also applies to compact", func(t *testing.T) {
+		t.Parallel()
+		got := mergeLayout(base, Layout{Normal: custom})
+		if len(got.Normal) != 1 || len(got.Compact) != 1 {
+			t.Fatalf("expected custom normal reused for compact, got normal=%v compact=%v", got.Normal, got.Compact)
+		}
+		if len(got.Danger) != len(base.Danger) {
+			t.Errorf("danger layout should be untouched, got %v", got.Danger)
+		}
+	})
+
+	t.Run("explicit compact wins over normal", func(t *testing.T) {
+		t.Parallel()
+		compact := [][]string{{"model"}, {"context"}}
+		got := mergeLayout(base, Layout{Normal: custom, Compact: compact})
+		if len(got.Compact) != 2 {
+			t.Errorf("expected explicit compact layout, got %v", got.Compact)
+		}
+	})
+}
+
+func TestUnknownSegments(t *testing.T) {
+	t.Parallel()
+
+	l := Layout{
+		Normal: [][]string{{"model", "contxt", "git"}},
+		Danger: [][]string{{"model", "contxt"}, {"bogus"}},
+	}
+	got := unknownSegments(l)
+	if len(got) != 2 || got[0] != "contxt" || got[1] != "bogus" {
+		t.Errorf("unknownSegments() = %v, want [contxt bogus]", got)
+	}
+}
+
+func TestRenderLayout_CustomOrdering(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{
+		Model:         Model{DisplayName: "Sonnet"},
+		ContextWindow: ContextWindow{ContextWindowSize: 200000},
+		Cost:          Cost{TotalDurationMS: 120000, TotalCostUSD: 1.5},
+	}
+	cfg := PresetConfig("full")
+	cfg.Layout = Layout{Normal: [][]string{{"git", "model", "context"}, {"cost"}}}
+
+	lines := Render(RenderContext{Data: d, Metrics: Metrics{ContextPercent: 30}, Git: &GitInfo{Branch: "main"}, Config: cfg})
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %v", len(lines), lines)
+	}
+	if !strings.HasPrefix(lines[0], magenta+"main") {
+		t.Errorf("line 1 should start with git branch, got %q", lines[0])
+	}
+	if strings.Index(lines[0], "Sonnet") > strings.Index(lines[0], "30%") {
+		t.Errorf("model should precede context on line 1, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "$1.50") {
+		t.Errorf("line 2 should contain cost, got %q", lines[1])
+	}
+}
+
+func TestRenderLayout_FeatureGatesStillApply(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Sonnet"}, ContextWindow: ContextWindow{ContextWindowSize: 200000}}
+	cfg := PresetConfig("minimal")
+	cfg.Layout = Layout{Normal: [][]string{{"model", "git"}}}
+
+	lines := Render(RenderContext{Data: d, Metrics: Metrics{ContextPercent: 10}, Git: &GitInfo{Branch: "main"}, Config: cfg})
+	if strings.Contains(strings.Join(lines, " "), "main") {
+		t.Errorf("git disabled by preset should not render even when in layout: %v", lines)
+	}
+}
+
+func TestRenderLayout_UnknownSegmentMarked(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Sonnet"}}
+	cfg := PresetConfig("full")
+	cfg.Layout = Layout{Normal: [][]string{{"model", "contxt"}}}
+
+	lines := Render(RenderContext{Data: d, Metrics: Metrics{ContextPercent: 10}, Config: cfg})
+	if len(lines) != 1 || !strings.Contains(lines[0], "?contxt") {
+		t.Errorf("unknown segment should render as ?contxt, got %v", lines)
+	}
+}
+
+func TestRenderLayout_CustomDanger(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{
+		Model:         Model{DisplayName: "Sonnet"},
+		ContextWindow: ContextWindow{ContextWindowSize: 200000},
+		Cost:          Cost{TotalDurationMS: 120000, TotalCostUSD: 1.5},
+	}
+	cfg := PresetConfig("minimal")
+	cfg.Layout = Layout{Danger: [][]string{{"context", "git"}}}
+
+	lines := Render(RenderContext{Data: d, Metrics: Metrics{ContextPercent: 90}, Git: &GitInfo{Branch: "main"}, Config: cfg})
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 danger line, got %d: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "🔴") || !strings.Contains(lines[0], "left") {
+		t.Errorf("danger context segment should use danger bar, got %q", lines[0])
+	}
+	if !strings.Contains(lines[0], "main") {
+		t.Errorf("danger mode should ignore feature toggles, got %q", lines[0])
+	}
+}
+
+func TestRenderLayout_ToolsShareWidth(t *testing.T) {
+	t.Setenv("COLUMNS", "40")
+
+	tools := &ToolInfo{
+		Tools:     map[string]int{"Read": 9, "Write": 8, "Edit": 7, "Bash": 6, "Grep": 5},
+		Agents:    []string{"researcher", "tester"},
+		AgentTree: []RunningAgent{{Name: "researcher"}, {Name: "tester"}},
+	}
+	cfg := PresetConfig("full")
+	cfg.Layout = Layout{Normal: [][]string{{"agents", "tools"}}}
+
+	lines := Render(RenderContext{Data: &StdinData{}, Tools: tools, Config: cfg})
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], "+") {
+		t.Errorf("tools should be truncated to remaining width, got %q", lines[0])
+	}
+}
+
+func TestLoadConfig_WithLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ".claude", "hud")
+	os.MkdirAll(configDir, 0755)
+	configPath := filepath.Join(configDir, "config.json")
+
+	content := `{"preset":"developer","layout":{"normal":[["model","context","git","cost"]]}}`
+	os.WriteFile(configPath, []byte(content), 0644)
+
+	cfg, _ := LoadConfig()
+	if len(cfg.Layout.Normal) != 1 || cfg.Layout.Normal[0][2] != "git" {
+		t.Errorf("expected custom normal layout, got %v", cfg.Layout.Normal)
+	}
+	if len(cfg.Layout.Compact) != 1 {
+		t.Errorf("custom normal layout should apply to compact, got %v", cfg.Layout.Compact)
+	}
+	if len(cfg.Layout.Danger) != len(DefaultLayout().Danger) {
+		t.Errorf("danger layout should keep default, got %v", cfg.Layout.Danger)
+	}
+}
+
+func TestRenderLayout_ConfigWarning(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Sonnet"}, ContextWindow: ContextWindow{ContextWindowSize: 200000}}
+	problems := []ConfigProblem{{Source: "user", Kind: ProblemInvalid, Message: "line 1, column 2: invalid character"}}
+	unknown := []ConfigProblem{{Source: "user", Kind: ProblemUnknown, Message: `unknown key "colour"`}}
+
+	tests := []struct {
+		name     string
+		percent  int
+		usage    *UsageData
+		problems []ConfigProblem
+		want     string
+	}{
+		{"normal", 10, &UsageData{}, problems, "⚙!"},
+		{"compact", 10, nil, problems, "⚙!"},
+		{"danger", 90, nil, problems, "⚙!"},
+		{"clean config", 10, nil, nil, ""},
+		{"unknown keys only", 10, nil, unknown, grey + "⚙?"},
+		{"unknown and corrected", 10, nil, append(unknown, ConfigProblem{Source: "merged", Kind: ProblemCorrected, Message: "x"}), "⚙!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			lines := Render(RenderContext{
+				Data:           d,
+				Metrics:        Metrics{ContextPercent: tt.percent},
+				Usage:          tt.usage,
+				Config:         PresetConfig("minimal"),
+				ConfigProblems: tt.problems,
+			})
+			if tt.want == "" {
+				if strings.Contains(lines[0], "⚙") {
+					t.Errorf("line 1 should have no config warning: %q", lines[0])
+				}
+			} else if !strings.Contains(lines[0], tt.want) {
+				t.Errorf("line 1 missing %q: %q", tt.want, lines[0])
+			}
+		})
+	}
+}
+
+func TestRenderLayout_ConfigWarningRemovable(t *testing.T) {
+	t.Parallel()
+
+	cfg := PresetConfig("full")
+	cfg.Layout = Layout{Normal: [][]string{{"model", "cost"}}}
+	lines := Render(RenderContext{
+		Data:           &StdinData{Model: Model{DisplayName: "Sonnet"}},
+		Config:         cfg,
+		ConfigProblems: []ConfigProblem{{Source: "merged", Message: "x"}},
+	})
+	if strings.Contains(strings.Join(lines, "\n"), "⚙!") {
+		t.Errorf("layout without config_warning should hide it, got %v", lines)
+	}
+}
+
+func TestRenderLayout_WindowedVelocity(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}, ContextWindow: ContextWindow{ContextWindowSize: 200000}}
+	lifetime, window, out := 0.05, 0.80, 1500.0
+
+	tests := []struct {
+		name    string
+		metrics Metrics
+		average bool
+		want    []string
+		notWant []string
+	}{
+		{"lifetime only", Metrics{CostPerMinute: &lifetime}, true, []string{"$0.05/m"}, []string{"avg", "Out:"}},
+		{"windowed preferred", Metrics{CostPerMinute: &lifetime, WindowCostPerMinute: &window, WindowOutputPerMinute: &out},
+			false, []string{boldRed + "$0.80/m", "Out:2K/m"}, []string{"avg"}},
+		{"average beside windowed", Metrics{CostPerMinute: &lifetime, WindowCostPerMinute: &window},
+			true, []string{"$0.80/m", "(avg:$0.05)"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := PresetConfig("full")
+			cfg.Features.VelocityAverage = tt.average
+			cfg.Layout = Layout{Normal: [][]string{{"cost_velocity", "throughput"}}}
+			got := strings.Join(Render(RenderContext{Data: d, Metrics: tt.metrics, Config: cfg}), "\n")
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("missing %q in %q", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("unexpected %q in %q", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderLayout_ContextETA(t *testing.T) {
+	t.Parallel()
+
+	eta := 14.0
+	samples := func(ctx ...float64) *SessionState {
+		h := &SessionState{}
+		for i, c := range ctx {
+			h.Samples = append(h.Samples, Sample{Time: int64(i) * msPerMinute, ContextPercent: c})
+		}
+		return h
+	}
+	tests := []struct {
+		name    string
+		percent int
+		eta     *float64
+		history *SessionState // when set, the ETA is fitted from it
+		want    string        // "" = no ETA expected
+	}{
+		{"below warning hides ETA", 60, &eta, nil, ""},
+		{"past warning shows ETA", 75, &eta, nil, "(150K/200K ~14m)"},
+		{"past warning without history", 75, nil, nil, ""},
+		{"danger prefers history", 90, &eta, nil, "left ~14m)"},
+		{"danger falls back to lifetime", 90, nil, nil, "left ~7m)"}, // 90% in 60m → 10% in ~7m
+		{"danger with one sample falls back to lifetime", 90, nil, samples(90), "left ~7m)"},
+		{"danger with under a minute of history falls back", 90, nil, &SessionState{Samples: []Sample{{Time: 0, ContextPercent: 88}, {Time: 30000, ContextPercent: 90}}}, "left ~7m)"},
+		{"danger just after compaction", 90, nil, samples(80, 96, 85), ""},
+		{"danger with flat history", 90, nil, samples(90, 90, 90), ""},
+		{"danger with declining history", 90, nil, samples(95, 92, 90), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			d := &StdinData{
+				Model:         Model{DisplayName: "Opus"},
+				ContextWindow: ContextWindow{ContextWindowSize: 200000},
+				Cost:          Cost{TotalDurationMS: 60 * msPerMinute},
+			}
+			cfg := PresetConfig("minimal")
+			m := Metrics{ContextPercent: tt.percent, ContextETAMinutes: tt.eta}
+			if tt.history != nil {
+				ApplyHistory(&m, tt.history, cfg.Thresholds)
+			}
+			got := strings.Join(Render(RenderContext{
+				Data:    d,
+				Metrics: m,
+				Config:  cfg,
+				History: tt.history,
+			}), "\n")
+			if tt.want == "" {
+				if strings.Contains(got, "~") {
+					t.Errorf("unexpected ETA in %q", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("missing %q in %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderLayout_Compactions(t *testing.T) {
+	t.Parallel()
+
+	last := time.Now().Add(-12 * time.Minute)
+	d := &StdinData{Model: Model{DisplayName: "Opus"}, ContextWindow: ContextWindow{ContextWindowSize: 200000}}
+	render := func(cfg Config, m Metrics) string {
+		return strings.Join(Render(RenderContext{Data: d, Metrics: m, Config: cfg}), "\n")
+	}
+
+	m := Metrics{ContextPercent: 40, Compactions: 2, LastCompaction: &last}
+	if got := render(PresetConfig("full"), m); !strings.Contains(got, "⟲2") || !strings.Contains(got, "12m ago") {
+		t.Errorf("full preset should show compactions, got %q", got)
+	}
+	if got := render(PresetConfig("minimal"), m); strings.Contains(got, "⟲") {
+		t.Errorf("minimal preset should hide compactions, got %q", got)
+	}
+	if got := render(PresetConfig("full"), Metrics{ContextPercent: 40}); strings.Contains(got, "⟲") {
+		t.Errorf("no compactions should render nothing, got %q", got)
+	}
+	// Danger mode ignores the toggle.
+	m.ContextPercent = 90
+	if got := render(PresetConfig("minimal"), m); !strings.Contains(got, "⟲2") {
+		t.Errorf("danger mode should show compactions, got %q", got)
+	}
+}
+
+func TestRenderLayout_Sparkline(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}, ContextWindow: ContextWindow{ContextWindowSize: 200000}}
+	h := &SessionState{Samples: ctxSamples(10, 20, 30, 40)}
+	render := func(cfg Config) string {
+		return strings.Join(Render(RenderContext{Data: d, Metrics: Metrics{ContextPercent: 40}, Config: cfg, History: h}), "\n")
+	}
+
+	if got := render(PresetConfig("full")); strings.Contains(got, "▂") {
+		t.Errorf("sparkline should be opt-in, got %q", got)
+	}
+	cfg := PresetConfig("full")
+	cfg.Features.Sparkline = true
+	if got := render(cfg); !strings.Contains(got, "▂") {
+		t.Errorf("enabled sparkline missing from default layout, got %q", got)
+	}
+	cfg.Layout.Normal = [][]string{{"model", "cost_sparkline", "quota_sparkline"}}
+	if got := render(cfg); strings.Contains(got, "?") {
+		t.Errorf("cost/quota sparkline IDs should be known, got %q", got)
+	}
+}
+
+func TestRenderLayout_LedgerTotals(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}, Cost: Cost{TotalCostUSD: 1}}
+	totals := &LedgerTotals{Today: 12.4, Week: 48.1, Month: 210.55}
+	render := func(cfg Config) string {
+		return strings.Join(Render(RenderContext{Data: d, Config: cfg, Ledger: totals}), "\n")
+	}
+
+	if got := render(PresetConfig("full")); strings.Contains(got, "today") {
+		t.Errorf("ledger should be opt-in, got %q", got)
+	}
+	cfg := PresetConfig("full")
+	cfg.Features.Ledger = true
+	if got := render(cfg); !strings.Contains(got, "$12.40"+grey+" today") {
+		t.Errorf("cost_today missing from default layout, got %q", got)
+	}
+	cfg.Layout.Compact = [][]string{{"cost_week", "cost_month"}}
+	if got := render(cfg); !strings.Contains(got, "$48.10"+grey+" week") || !strings.Contains(got, "$210.55"+grey+" month") {
+		t.Errorf("week/month segments missing, got %q", got)
+	}
+}
+
+func TestRenderLayout_EstimatedCost(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}, Cost: Cost{TotalCostUSD: 99}}
+	estimate := 2.5
+	render := func(estimated bool, m Metrics) string {
+		rc := RenderContext{Data: d, Metrics: m, Config: PresetConfig("full")}
+		if estimated {
+			rc.CostEstimate = &estimate
+		}
+		return strings.Join(Render(rc), "\n")
+	}
+
+	if got := render(false, Metrics{}); strings.Contains(got, "est.") || !strings.Contains(got, "$99") {
+		t.Errorf("reported cost should have no marker, got %q", got)
+	}
+	if got := render(true, Metrics{}); !strings.Contains(got, "$2.50"+Reset+grey+" est.") || strings.Contains(got, "$99") {
+		t.Errorf("estimated cost should replace the reported one and be marked, got %q", got)
+	}
+	if d.Cost.TotalCostUSD != 99 {
+		t.Errorf("rendering should keep the reported cost, got %v", d.Cost.TotalCostUSD)
+	}
+	if got := render(true, Metrics{ContextPercent: 90}); !strings.Contains(got, " est.") {
+		t.Errorf("danger mode should mark the estimate too, got %q", got)
+	}
+}
+
+func TestRenderLayout_CacheSavings(t *testing.T) {
+	t.Parallel()
+
+	saved, turn := 3.2, 0.16
+	d := &StdinData{Model: Model{DisplayName: "Sonnet"}}
+	m := Metrics{CacheSavings: &turn, CacheSavingsTotal: &saved}
+	render := func(cfg Config, m Metrics) string {
+		return strings.Join(Render(RenderContext{Data: d, Metrics: m, Config: cfg}), "\n")
+	}
+
+	if got := render(PresetConfig("full"), m); strings.Contains(got, "Saved:") {
+		t.Errorf("cache savings should be off in full, got %q", got)
+	}
+	if got := render(PresetConfig("cost-focused"), m); !strings.Contains(got, "+$3.20") {
+		t.Errorf("cost-focused should show cache savings, got %q", got)
+	}
+	if got := render(PresetConfig("cost-focused"), Metrics{}); strings.Contains(got, "Saved:") {
+		t.Errorf("no savings data should render nothing, got %q", got)
+	}
+}
+
+func TestRenderLayout_RunningTool(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}}
+	started := time.Now().Add(-3 * time.Minute)
+	tools := &ToolInfo{
+		Tools:   map[string]int{"Bash": 2},
+		Running: []RunningTool{{Name: "Bash", Summary: "npm test", Started: &started}},
+	}
+	render := func(preset string) string {
+		return strings.Join(Render(RenderContext{Data: d, Metrics: ComputeMetrics(d), Tools: tools, Config: PresetConfig(preset)}), "\n")
+	}
+
+	got := render("full")
+	if !strings.Contains(got, "⏵") || !strings.Contains(got, "3m0") || !strings.Contains(got, ": npm test") {
+		t.Errorf("full preset = %q, want the running Bash call", got)
+	}
+	if got := render("minimal"); strings.Contains(got, "⏵") {
+		t.Errorf("running tool follows the tools toggle, got %q", got)
+	}
+}
+
+func TestRenderLayout_Todos(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}}
+	tools := &ToolInfo{
+		Tools: map[string]int{"Read": 24, "Edit": 11},
+		Todos: &TodoProgress{Done: 4, Total: 9, Active: "Running the whole integration test suite against staging"},
+	}
+	render := func(preset string) []string {
+		return Render(RenderContext{Data: d, Metrics: ComputeMetrics(d), Tools: tools, Config: PresetConfig(preset)})
+	}
+
+	var line string
+	for _, l := range render("full") {
+		if strings.Contains(l, "☑ 4/9") {
+			line = l
+		}
+	}
+	if line == "" {
+		t.Fatalf("full preset has no todo progress: %q", render("full"))
+	}
+	// The item is capped so the tools still fit on the line.
+	if !strings.Contains(line, "Running the") || !strings.Contains(line, "…") || !strings.Contains(line, "Read") {
+		t.Errorf("activity line = %q, want a truncated item and the tools", line)
+	}
+	if got := strings.Join(render("minimal"), "\n"); strings.Contains(got, "☑") {
+		t.Errorf("todos follow the tools toggle, got %q", got)
+	}
+}
+
+func TestRenderLayout_ToolErrors(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{Model: Model{DisplayName: "Opus"}}
+	tools := &ToolInfo{Tools: map[string]int{"Bash": 12}, Errors: map[string]int{"Bash": 3}, Calls: 47, Failed: 3, ErrorStreak: 2}
+	render := func(cfg Config, ti *ToolInfo) string {
+		return strings.Join(Render(RenderContext{Data: d, Metrics: ComputeMetrics(d), Tools: ti, Config: cfg}), "\n")
+	}
+
+	got := render(PresetConfig("full"), tools)
+	for _, want := range []string{"✗3", "Fail:", "(3/47)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("full preset missing %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, "in a row") {
+		t.Errorf("a streak of 2 is below the default 3, got %q", got)
+	}
+	if got := render(PresetConfig("cost-focused"), tools); strings.Contains(got, "Fail:") {
+		t.Errorf("tool errors should be off in cost-focused, got %q", got)
+	}
+
+	// The streak warning shows on line 1 whatever the toggles.
+	streak := *tools
+	streak.ErrorStreak = 4
+	lines := Render(RenderContext{Data: d, Metrics: ComputeMetrics(d), Tools: &streak, Config: PresetConfig("minimal")})
+	if !strings.Contains(lines[0], "✗4 in a row") {
+		t.Errorf("line 1 = %q, want the streak warning", lines[0])
+	}
+	cfg := PresetConfig("full")
+	cfg.Thresholds.ErrorStreak = 5
+	if got := render(cfg, &streak); strings.Contains(got, "in a row") {
+		t.Errorf("streak below error_streak 5 should not warn, got %q", got)
+	}
+}
@@ -0,0 +1,413 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The cost ledger attributes each session's spend to local days, so totals
+// across sessions and projects (today, this week, this month) survive the
+// sessions themselves. Every session owns one file under
+// ~/.claude/hud/ledger/<session_id>.json, rewritten atomically: a session has
+// a single writer, so concurrent sessions never contend for a shared log.
+//
+// The statusline reads period totals from ledger/.totals.json instead, which
+// holds the current periods' spend of every session, so a refresh reads one
+// file rather than every record of the month. Each writer replaces only its
+// own session's entry there; a write lost to a concurrent writer is repaired
+// by that session's next write or by the full rescan every ledgerCacheTTL.
+const (
+	LedgerMaxAge        = 400 * 24 * time.Hour // sessions idle this long are pruned
+	ledgerMaxBytes      = 64 * 1024            // refuse to load larger ledger files
+	ledgerDayLayout     = "2006-01-02"
+	ledgerCacheName     = ".totals.json"   // skipped by LoadLedger (dot file)
+	ledgerCacheTTL      = 10 * time.Minute // rescan all records at least this often
+	ledgerCacheMaxBytes = 1024 * 1024      // refuse to load a larger totals cache
+)
+
+// LedgerSession is one session's ledger record.
+type LedgerSession struct {
+	SessionID string `json:"session_id"`
+	Project   string `json:"project"`
+	Account   string `json:"account,omitempty"`
+	// LastCost is the cumulative session cost at the last update; spend is
+	// the difference to the next one.
+	LastCost float64 `json:"last_cost"`
+	// Estimated means LastCost is a token-based estimate (see EstimateCost)
+	// rather than the cost Claude Code reported.
+	Estimated bool          `json:"estimated,omitempty"`
+	Spend     []LedgerSpend `json:"spend"`
+}
+
+// LedgerSpend is what a session spent on one local day with one model.
+type LedgerSpend struct {
+	Day     string  `json:"day"` // YYYY-MM-DD, local time
+	Model   string  `json:"model"`
+	CostUSD float64 `json:"cost"`
+}
+
+// LedgerTotals is the spend across all sessions in the current periods, and
+// the part of it spent in one project.
+type LedgerTotals struct {
+	Today        float64 `json:"today_usd"`
+	Week         float64 `json:"week_usd"` // since Monday
+	Month        float64 `json:"month_usd"`
+	ProjectToday float64 `json:"project_today_usd"`
+	ProjectMonth float64 `json:"project_month_usd"`
+}
+
+// LedgerRow is one line of a ledger summary.
+type LedgerRow struct {
+	Key      string  `json:"key"`
+	CostUSD  float64 `json:"cost_usd"`
+	Sessions int     `json:"sessions"`
+}
+
+// LedgerGroups are the keys SummarizeLedger can group by.
+var LedgerGroups = []string{"day", "project", "model", "account"}
+
+// LedgerDir returns ~/.claude/hud/ledger, or "" when the home directory is
+// unknown.
+func LedgerDir() string {
+	return hudDir("ledger")
+}
+
+// add records the session's cumulative cost at day with model and reports
+// whether the record changed. The increase since the last update is spend;
+// a lower cost means the counters restarted (a resumed session), so the
+// whole new cost is spend.
+func (ls *LedgerSession) add(cost float64, day, model string) bool {
+	if cost == ls.LastCost {
+		return false
+	}
+	delta := cost - ls.LastCost
+	if cost < ls.LastCost {
+		delta = cost
+	}
+	ls.LastCost = cost
+	if delta <= 0 {
+		return true
+	}
+	for i := range ls.Spend {
+		if ls.Spend[i].Day == day && ls.Spend[i].Model == model {
+			ls.Spend[i].CostUSD += delta
+			return true
+		}
+	}
+	ls.Spend = append(ls.Spend, LedgerSpend{Day: day, Model: model, CostUSD: delta})
+	return true
+}
+
+// RecordLedger adds the current cost of d to its session's ledger record:
+// estimate when EstimateCost returned one, otherwise the reported cost.
+// account may be empty. Creating a new session's record prunes records
+// untouched for LedgerMaxAge.
+func RecordLedger(dir string, d *StdinData, estimate *float64, account string, now time.Time) error {
+	if dir == "" {
+		return errors.New("ledger dir unknown")
+	}
+	if !validSessionID(d.SessionID) {
+		return fmt.Errorf("invalid session id %q", d.SessionID)
+	}
+	ls, err := readLedgerSession(filepath.Join(dir, d.SessionID+".json"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		ls = &LedgerSession{}
+	}
+	ls.SessionID = d.SessionID
+	if p := d.Workspace.ProjectDir; p != "" {
+		ls.Project = p
+	} else if ls.Project == "" {
+		ls.Project = d.CWD
+	}
+	if account != "" {
+		ls.Account = account
+	}
+	cost := SessionCost(d, estimate)
+	rebased := ls.Spend != nil && ls.Estimated != (estimate != nil)
+	if rebased {
+		// Switching between reported and estimated cost is not spend: the
+		// gap between the two figures would be counted otherwise.
+		ls.LastCost = cost
+	}
+	ls.Estimated = estimate != nil
+	if !ls.add(cost, now.Format(ledgerDayLayout), d.Model.DisplayName) && !rebased {
+		return nil
+	}
+	data, err := json.Marshal(ls)
+	if err != nil {
+		return err
+	}
+	existed, err := writeFileAtomic(dir, d.SessionID+".json", data)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		pruneStaleFiles(dir, now.Add(-LedgerMaxAge))
+	}
+
+	c, _, err := loadLedgerCache(dir, now)
+	if err != nil {
+		return err
+	}
+	c.Sessions[ls.SessionID] = *ls
+	return c.write(dir, now)
+}
+
+// ledgerCache is the on-disk shape of ledger/.totals.json.
+type ledgerCache struct {
+	Built    int64                    `json:"built"` // Unix ms of the last full rescan
+	Sessions map[string]LedgerSession `json:"sessions"`
+}
+
+// loadLedgerCache returns the totals cache, rescanning every record updated
+// since LedgerSince(now) when the cache is missing, unreadable or older than
+// ledgerCacheTTL. rebuilt reports a rescan the caller has not saved yet.
+func loadLedgerCache(dir string, now time.Time) (c *ledgerCache, rebuilt bool, err error) {
+	path := filepath.Join(dir, ledgerCacheName)
+	if info, err := os.Stat(path); err == nil && info.Size() <= ledgerCacheMaxBytes {
+		if data, err := os.ReadFile(path); err == nil {
+			var cached ledgerCache
+			if json.Unmarshal(data, &cached) == nil && cached.Sessions != nil {
+				age := now.UnixMilli() - cached.Built
+				if age >= 0 && age < ledgerCacheTTL.Milliseconds() {
+					return &cached, false, nil
+				}
+			}
+		}
+	}
+
+	sessions, err := LoadLedger(dir, LedgerSince(now))
+	if err != nil {
+		return nil, false, err
+	}
+	c = &ledgerCache{Built: now.UnixMilli(), Sessions: make(map[string]LedgerSession, len(sessions))}
+	for _, ls := range sessions {
+		c.Sessions[ls.SessionID] = ls
+	}
+	return c, true, nil
+}
+
+// write saves the cache, keeping only spend on or after LedgerSince(now).
+func (c *ledgerCache) write(dir string, now time.Time) error {
+	from := LedgerSince(now).Format(ledgerDayLayout)
+	for id, ls := range c.Sessions {
+		var recent []LedgerSpend
+		for _, s := range ls.Spend {
+			if s.Day >= from {
+				recent = append(recent, s)
+			}
+		}
+		if len(recent) == 0 {
+			delete(c.Sessions, id)
+			continue
+		}
+		ls.Spend = recent
+		c.Sessions[id] = ls
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = writeFileAtomic(dir, ledgerCacheName, data)
+	return err
+}
+
+// LoadLedgerTotals returns SumLedger over every session for now from the
+// totals cache, rescanning the records only when the cache is stale.
+func LoadLedgerTotals(dir string, now time.Time, project string) (LedgerTotals, error) {
+	if dir == "" {
+		return LedgerTotals{}, errors.New("ledger dir unknown")
+	}
+	c, rebuilt, err := loadLedgerCache(dir, now)
+	if err != nil {
+		return LedgerTotals{}, err
+	}
+	if rebuilt && len(c.Sessions) > 0 {
+		_ = c.write(dir, now) // best effort: the next refresh rescans again
+	}
+	sessions := make([]LedgerSession, 0, len(c.Sessions))
+	for _, ls := range c.Sessions {
+		sessions = append(sessions, ls)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].SessionID < sessions[j].SessionID })
+	return SumLedger(sessions, now, project), nil
+}
+
+// LoadLedger reads the records of sessions updated at or after since (all
+// records when since is zero), sorted by session ID. Unreadable records are
+// skipped.
+func LoadLedger(dir string, since time.Time) ([]LedgerSession, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sessions []LedgerSession
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if info, err := e.Info(); err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		if ls, err := readLedgerSession(filepath.Join(dir, name)); err == nil {
+			sessions = append(sessions, *ls)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].SessionID < sessions[j].SessionID })
+	return sessions, nil
+}
+
+func readLedgerSession(path string) (*LedgerSession, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > ledgerMaxBytes {
+		return nil, fmt.Errorf("%s: ledger record larger than %d bytes", path, ledgerMaxBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ls LedgerSession
+	if err := json.Unmarshal(data, &ls); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &ls, nil
+}
+
+// ledgerPeriodStart returns the local midnights starting the day, the week
+// (Monday) and the month containing now.
+func ledgerPeriodStart(now time.Time) (day, week, month time.Time) {
+	y, m, d := now.Date()
+	day = time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	week = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	month = time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	return day, week, month
+}
+
+// LedgerSince is the earliest update time a record needs for
+// SumLedger(now): the start of the week or the month, whichever is first.
+func LedgerSince(now time.Time) time.Time {
+	_, week, month := ledgerPeriodStart(now)
+	if week.Before(month) {
+		return week
+	}
+	return month
+}
+
+// SumLedger totals the spend of sessions for the day, week and month
+// containing now; the project totals count sessions of project only.
+func SumLedger(sessions []LedgerSession, now time.Time, project string) LedgerTotals {
+	day, week, month := ledgerPeriodStart(now)
+	today := day.Format(ledgerDayLayout)
+	weekFrom, monthFrom := week.Format(ledgerDayLayout), month.Format(ledgerDayLayout)
+	var t LedgerTotals
+	for _, ls := range sessions {
+		for _, s := range ls.Spend {
+			inProject := project != "" && ls.Project == project
+			if s.Day == today {
+				t.Today += s.CostUSD
+				if inProject {
+					t.ProjectToday += s.CostUSD
+				}
+			}
+			if s.Day >= weekFrom && s.Day <= today {
+				t.Week += s.CostUSD
+			}
+			if s.Day >= monthFrom && s.Day <= today {
+				t.Month += s.CostUSD
+				if inProject {
+					t.ProjectMonth += s.CostUSD
+				}
+			}
+		}
+	}
+	return t
+}
+
+// SummarizeLedger groups spend on or after the day from (YYYY-MM-DD, "" for
+// all) by one of LedgerGroups. Days are listed oldest first; other groups
+// by cost, highest first.
+func SummarizeLedger(sessions []LedgerSession, by, from string) ([]LedgerRow, error) {
+	if !slices.Contains(LedgerGroups, by) {
+		return nil, fmt.Errorf("unknown group %q (want %s)", by, strings.Join(LedgerGroups, ", "))
+	}
+	rows := make(map[string]*LedgerRow)
+	seen := make(map[[2]string]bool) // (key, session) pairs already counted
+	for _, ls := range sessions {
+		for _, s := range ls.Spend {
+			if s.Day < from {
+				continue
+			}
+			var key string
+			switch by {
+			case "day":
+				key = s.Day
+			case "project":
+				key = ls.Project
+			case "model":
+				key = s.Model
+			case "account":
+				key = ls.Account
+			}
+			if key == "" {
+				key = "(unknown)"
+			}
+			r := rows[key]
+			if r == nil {
+				r = &LedgerRow{Key: key}
+				rows[key] = r
+			}
+			r.CostUSD += s.CostUSD
+			if !seen[[2]string{key, ls.SessionID}] {
+				seen[[2]string{key, ls.SessionID}] = true
+				r.Sessions++
+			}
+		}
+	}
+	result := make([]LedgerRow, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if by != "day" && result[i].CostUSD != result[j].CostUSD {
+			return result[i].CostUSD > result[j].CostUSD
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// LedgerTotal sums the spend on or after the day from ("" for all) into one
+// row keyed "total"; Sessions counts the sessions that spent anything.
+func LedgerTotal(sessions []LedgerSession, from string) LedgerRow {
+	total := LedgerRow{Key: "total"}
+	for _, ls := range sessions {
+		spent := false
+		for _, s := range ls.Spend {
+			if s.Day >= from {
+				total.CostUSD += s.CostUSD
+				spent = true
+			}
+		}
+		if spent {
+			total.Sessions++
+		}
+	}
+	return total
+}
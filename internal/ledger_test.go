@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func spendEq(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLedgerSession_Add(t *testing.T) {
+	t.Parallel()
+
+	var ls LedgerSession
+	steps := []struct {
+		name    string
+		cost    float64
+		day     string
+		changed bool
+	}{
+		{"first cost counts whole", 1.0, "2026-06-15", true},
+		{"unchanged", 1.0, "2026-06-15", false},
+		{"increase", 1.5, "2026-06-15", true},
+		{"next day", 2.5, "2026-06-16", true},
+		{"resumed session restarts counters", 0.25, "2026-06-16", true},
+	}
+	for _, step := range steps {
+		if got := ls.add(step.cost, step.day, "Opus"); got != step.changed {
+			t.Errorf("%s: changed = %v, want %v", step.name, got, step.changed)
+		}
+	}
+	want := []LedgerSpend{
+		{Day: "2026-06-15", Model: "Opus", CostUSD: 1.5},
+		{Day: "2026-06-16", Model: "Opus", CostUSD: 1.25},
+	}
+	if len(ls.Spend) != len(want) {
+		t.Fatalf("Spend = %+v, want %+v", ls.Spend, want)
+	}
+	for i, w := range want {
+		if got := ls.Spend[i]; got.Day != w.Day || got.Model != w.Model || !spendEq(got.CostUSD, w.CostUSD) {
+			t.Errorf("Spend[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+	if ls.LastCost != 0.25 {
+		t.Errorf("LastCost = %v, want 0.25", ls.LastCost)
+	}
+
+	// A model switch starts a separate bucket for the same day.
+	ls.add(1.25, "2026-06-16", "Sonnet")
+	if n := len(ls.Spend); n != 3 || ls.Spend[2].Model != "Sonnet" || !spendEq(ls.Spend[2].CostUSD, 1) {
+		t.Errorf("model switch: Spend = %+v", ls.Spend)
+	}
+}
+
+func TestRecordLedger(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Date(2026, 6, 15, 23, 59, 0, 0, time.Local)
+	d := &StdinData{
+		SessionID: "sess-1",
+		CWD:       "/work/app/sub",
+		Model:     Model{DisplayName: "Opus"},
+		Workspace: Workspace{ProjectDir: "/work/app"},
+		Cost:      Cost{TotalCostUSD: 2},
+	}
+	if err := RecordLedger(dir, d, nil, "dev@example.com", now); err != nil {
+		t.Fatalf("RecordLedger: %v", err)
+	}
+	d.Cost.TotalCostUSD = 3
+	if err := RecordLedger(dir, d, nil, "", now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("RecordLedger: %v", err)
+	}
+
+	sessions, err := LoadLedger(dir, time.Time{})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("LoadLedger = %+v, %v", sessions, err)
+	}
+	ls := sessions[0]
+	if ls.SessionID != "sess-1" || ls.Project != "/work/app" || ls.Account != "dev@example.com" || ls.LastCost != 3 {
+		t.Errorf("record = %+v", ls)
+	}
+	if len(ls.Spend) != 2 || ls.Spend[0].CostUSD != 2 || ls.Spend[1].Day != "2026-06-16" || ls.Spend[1].CostUSD != 1 {
+		t.Errorf("spend across midnight = %+v", ls.Spend)
+	}
+
+	// Switching to an estimate rebases on it instead of counting the gap.
+	later := now.Add(3 * time.Minute)
+	for _, est := range []float64{1.2, 1.5} {
+		if err := RecordLedger(dir, d, &est, "", later); err != nil {
+			t.Fatalf("RecordLedger: %v", err)
+		}
+	}
+	sessions, _ = LoadLedger(dir, time.Time{})
+	if ls := sessions[0]; !ls.Estimated || ls.LastCost != 1.5 || len(ls.Spend) != 2 || !spendEq(ls.Spend[1].CostUSD, 1.3) {
+		t.Errorf("estimated record = %+v, want $0.30 more spend on 2026-06-16", ls)
+	}
+
+	if err := RecordLedger(dir, &StdinData{SessionID: "../x"}, nil, "", now); err == nil {
+		t.Error("invalid session id should fail")
+	}
+	if err := RecordLedger("", d, nil, "", now); err == nil {
+		t.Error("unknown dir should fail")
+	}
+}
+
+func TestLoadLedger(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	for _, id := range []string{"new", "old"} {
+		d := &StdinData{SessionID: id, Cost: Cost{TotalCostUSD: 1}}
+		if err := RecordLedger(dir, d, nil, "", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := now.Add(-40 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := LoadLedger(dir, time.Time{})
+	if err != nil || len(all) != 2 || all[0].SessionID != "new" || all[1].SessionID != "old" {
+		t.Errorf("all = %+v, %v; want new and old, broken skipped", all, err)
+	}
+	recent, _ := LoadLedger(dir, now.Add(-24*time.Hour))
+	if len(recent) != 1 || recent[0].SessionID != "new" {
+		t.Errorf("recent = %+v, want only new", recent)
+	}
+	if missing, err := LoadLedger(filepath.Join(dir, "none"), time.Time{}); missing != nil || err != nil {
+		t.Errorf("missing dir = %+v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestLoadLedgerTotals(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	for i, id := range []string{"a", "b"} {
+		d := &StdinData{SessionID: id, Workspace: Workspace{ProjectDir: "/work/" + id}, Cost: Cost{TotalCostUSD: float64(i + 1)}}
+		if err := RecordLedger(dir, d, nil, "", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := LedgerTotals{Today: 3, Week: 3, Month: 3, ProjectToday: 2, ProjectMonth: 2}
+	if got, err := LoadLedgerTotals(dir, now, "/work/b"); err != nil || got != want {
+		t.Errorf("LoadLedgerTotals = %+v, %v; want %+v", got, err, want)
+	}
+	if sessions, _ := LoadLedger(dir, time.Time{}); len(sessions) != 2 {
+		t.Errorf("LoadLedger should skip the totals cache, got %d sessions", len(sessions))
+	}
+
+	// A record the cache missed (a write lost to a concurrent writer) is
+	// picked up by the rescan once the cache expires, not on every refresh.
+	if err := os.WriteFile(filepath.Join(dir, "c.json"),
+		[]byte(`{"session_id":"c","last_cost":4,"spend":[{"day":"`+now.Format(ledgerDayLayout)+`","cost":4}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadLedgerTotals(dir, now.Add(time.Minute), ""); got.Today != 3 {
+		t.Errorf("fresh cache: Today = %v, want 3 (no rescan)", got.Today)
+	}
+	if got, _ := LoadLedgerTotals(dir, now.Add(ledgerCacheTTL), ""); got.Today != 7 {
+		t.Errorf("expired cache: Today = %v, want 7 after rescan", got.Today)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ledgerCacheName), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadLedgerTotals(dir, now.Add(ledgerCacheTTL), ""); got.Today != 7 {
+		t.Errorf("corrupt cache: Today = %v, want 7 after rescan", got.Today)
+	}
+	if got, err := LoadLedgerTotals(filepath.Join(dir, "none"), now, ""); err != nil || got != (LedgerTotals{}) {
+		t.Errorf("missing dir = %+v, %v; want zero totals", got, err)
+	}
+}
+
+func TestSumLedger(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday 2026-07-01: the week started Monday 2026-06-29, in June.
+	now := time.Date(2026, 7, 1, 10, 0, 0, 0, time.Local)
+	sessions := []LedgerSession{
+		{SessionID: "a", Project: "/work/app", Spend: []LedgerSpend{
+			{Day: "2026-06-28", CostUSD: 8}, // last week
+			{Day: "2026-06-30", CostUSD: 4}, // this week, last month
+			{Day: "2026-07-01", CostUSD: 2},
+		}},
+		{SessionID: "b", Spend: []LedgerSpend{{Day: "2026-07-01", CostUSD: 1}}},
+	}
+	got := SumLedger(sessions, now, "/work/app")
+	want := LedgerTotals{Today: 3, Week: 7, Month: 3, ProjectToday: 2, ProjectMonth: 2}
+	if got != want {
+		t.Errorf("SumLedger = %+v, want %+v", got, want)
+	}
+	if got := SumLedger(sessions, now, ""); got.ProjectToday != 0 || got.ProjectMonth != 0 {
+		t.Errorf("no project: %+v, want zero project totals", got)
+	}
+	if since := LedgerSince(now); !since.Equal(time.Date(2026, 6, 29, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("LedgerSince = %v, want Monday 2026-06-29", since)
+	}
+	// Mid-month, the month starts first.
+	mid := time.Date(2026, 7, 15, 10, 0, 0, 0, time.Local)
+	if since := LedgerSince(mid); !since.Equal(time.Date(2026, 7, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("LedgerSince(mid-month) = %v, want 2026-07-01", since)
+	}
+}
+
+func TestSummarizeLedger(t *testing.T) {
+	t.Parallel()
+
+	sessions := []LedgerSession{
+		{SessionID: "a", Project: "/work/app", Account: "me@example.com", Spend: []LedgerSpend{
+			{Day: "2026-06-14", Model: "Opus", CostUSD: 5},
+			{Day: "2026-06-15", Model: "Opus", CostUSD: 2},
+			{Day: "2026-06-15", Model: "Sonnet", CostUSD: 1},
+		}},
+		{SessionID: "b", Project: "/work/lib", Spend: []LedgerSpend{
+			{Day: "2026-06-15", Model: "Sonnet", CostUSD: 4},
+		}},
+	}
+	tests := []struct {
+		by   string
+		from string
+		want []LedgerRow
+	}{
+		{"day", "", []LedgerRow{{"2026-06-14", 5, 1}, {"2026-06-15", 7, 2}}},
+		{"project", "", []LedgerRow{{"/work/app", 8, 1}, {"/work/lib", 4, 1}}},
+		{"model", "2026-06-15", []LedgerRow{{"Sonnet", 5, 2}, {"Opus", 2, 1}}},
+		{"account", "", []LedgerRow{{"me@example.com", 8, 1}, {"(unknown)", 4, 1}}},
+	}
+	for _, tt := range tests {
+		got, err := SummarizeLedger(sessions, tt.by, tt.from)
+		if err != nil {
+			t.Errorf("%s: %v", tt.by, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.by, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s[%d] = %+v, want %+v", tt.by, i, got[i], tt.want[i])
+			}
+		}
+	}
+	if _, err := SummarizeLedger(nil, "week", ""); err == nil {
+		t.Error("unknown group should fail")
+	}
+
+	total := LedgerTotal(sessions, "2026-06-15")
+	if total.CostUSD != 7 || total.Sessions != 2 {
+		t.Errorf("LedgerTotal = %+v, want $7 over 2 sessions", total)
+	}
+	if total := LedgerTotal(sessions, "2026-06-16"); total.CostUSD != 0 || total.Sessions != 0 {
+		t.Errorf("LedgerTotal(future) = %+v, want empty", total)
+	}
+}
@@ -1,11 +1,33 @@
 package internal
 
+import "time"
+
 // Metrics holds all derived values computed from StdinData.
 type Metrics struct {
-	ContextPercent  int  // 0-100
-	CacheEfficiency *int // nil if insufficient data
-	APIWaitRatio    *int // nil if duration=0
-	CostPerMinute   *float64
+	ContextPercent  int      `json:"context_percent"`  // 0-100
+	CacheEfficiency *int     `json:"cache_efficiency"` // nil if insufficient data
+	APIWaitRatio    *int     `json:"api_wait_ratio"`   // nil if duration=0
+	CostPerMinute   *float64 `json:"cost_per_minute"`  // lifetime average; nil under one minute
+	// Rates over the last Thresholds.VelocityWindowMinutes of session history
+	// (see ApplyHistory); nil with less than a minute of history.
+	WindowCostPerMinute   *float64 `json:"window_cost_per_minute"`
+	WindowOutputPerMinute *float64 `json:"window_output_tokens_per_minute"`
+	// Minutes until the context is full at the recent growth rate; nil when
+	// history is too short or context is not growing.
+	ContextETAMinutes *float64 `json:"context_eta_minutes"`
+	// ContextETAFitted means the history settled the trend, so a nil
+	// ContextETAMinutes is a flat, shrinking or just-compacted context rather
+	// than too little history to tell.
+	ContextETAFitted bool `json:"-"`
+	// Compactions this session (see ApplyCompactions) and when the latest
+	// happened (nil when unknown).
+	Compactions    int        `json:"compactions"`
+	LastCompaction *time.Time `json:"last_compaction"`
+	// Dollars prompt caching saved on the latest call and over the session,
+	// net of cache writes (see ApplyCacheSavings); nil without a model price
+	// or, for the session total, without history.
+	CacheSavings      *float64 `json:"cache_savings_usd"`
+	CacheSavingsTotal *float64 `json:"cache_savings_total_usd"`
 }
 
 // ComputeMetrics calculates derived KPIs from raw session data.
@@ -68,3 +90,159 @@ func calcCostPerMinute(c *Cost) *float64 {
 	v := c.TotalCostUSD / minutes
 	return &v
 }
+
+// ApplyHistory fills the history-based metrics from the session's recent
+// samples. A nil or short history leaves them nil, so renderers fall back to
+// the lifetime values; the danger-mode context ETA does so unless the history
+// settled the trend (ContextETAFitted).
+func ApplyHistory(m *Metrics, h *SessionState, t Thresholds) {
+	if h == nil {
+		return
+	}
+	window := time.Duration(t.VelocityWindowMinutes) * time.Minute
+	if window <= 0 {
+		window = VelocityWindowMinutes * time.Minute
+	}
+	m.WindowCostPerMinute, m.WindowOutputPerMinute = calcWindowRates(h.Samples, window)
+	m.ContextETAMinutes, m.ContextETAFitted = calcContextETA(h.Samples, float64(m.ContextPercent), window)
+}
+
+// calcWindowRates returns cost ($/min) and output tokens (/min) between the
+// newest sample and the oldest one inside window. Needs at least one minute
+// of span so a burst right after startup does not read as a runaway rate.
+func calcWindowRates(samples []Sample, window time.Duration) (costPerMin, outPerMin *float64) {
+	if len(samples) < 2 {
+		return nil, nil
+	}
+	last := samples[len(samples)-1]
+	cutoff := last.Time - window.Milliseconds()
+	first := last
+	for _, s := range samples {
+		if s.Time >= cutoff {
+			first = s
+			break
+		}
+	}
+	spanMS := last.Time - first.Time
+	if spanMS < msPerMinute {
+		return nil, nil
+	}
+	minutes := float64(spanMS) / msPerMinute
+	c := max(last.CostUSD-first.CostUSD, 0) / minutes
+	o := float64(max(last.OutputTokens-first.OutputTokens, 0)) / minutes
+	return &c, &o
+}
+
+// costVelocity returns the windowed $/min when history allows, else the
+// lifetime average (nil when neither is known).
+func (m Metrics) costVelocity() *float64 {
+	if m.WindowCostPerMinute != nil {
+		return m.WindowCostPerMinute
+	}
+	return m.CostPerMinute
+}
+
+// lastCompaction returns the index of the first sample after the most recent
+// compaction (a context drop of at least CompactionDropPercent between
+// consecutive samples), or 0 when there was none.
+func lastCompaction(samples []Sample) int {
+	for i := len(samples) - 1; i > 0; i-- {
+		if samples[i-1].ContextPercent-samples[i].ContextPercent >= CompactionDropPercent {
+			return i
+		}
+	}
+	return 0
+}
+
+// calcContextETA estimates minutes until the context is full from the
+// least-squares slope of context % over the samples inside window since the
+// last compaction, so growth before a compaction or /clear never counts.
+// Needs a minute of span and a positive slope. fitted is false when the
+// samples are too few to tell and none is a compaction, so callers can fall
+// back to the lifetime estimate; otherwise a nil ETA means no growth.
+func calcContextETA(samples []Sample, current float64, window time.Duration) (eta *float64, fitted bool) {
+	start := lastCompaction(samples)
+	compacted := start > 0
+	samples = samples[start:]
+	if len(samples) < 2 {
+		return nil, compacted
+	}
+	cutoff := samples[len(samples)-1].Time - window.Milliseconds()
+	for len(samples) > 0 && samples[0].Time < cutoff {
+		samples = samples[1:]
+	}
+	if len(samples) < 2 || samples[len(samples)-1].Time-samples[0].Time < msPerMinute {
+		return nil, compacted
+	}
+
+	// Fit ctx = a + slope*t with t in minutes relative to the first sample.
+	var sumT, sumC, sumTT, sumTC float64
+	n := float64(len(samples))
+	for _, s := range samples {
+		x := float64(s.Time-samples[0].Time) / msPerMinute
+		sumT += x
+		sumC += s.ContextPercent
+		sumTT += x * x
+		sumTC += x * s.ContextPercent
+	}
+	denom := n*sumTT - sumT*sumT
+	if denom == 0 {
+		return nil, true
+	}
+	slope := (n*sumTC - sumT*sumC) / denom // % per minute
+	if slope <= 0 {
+		return nil, true
+	}
+	v := max(100-current, 0) / slope
+	return &v, true
+}
+
+// ApplyCompactions counts the session's compactions from both sources: sharp
+// context drops recorded in the history and compaction entries in the
+// transcript. Each source can miss some (history starts with Howl, the
+// transcript is read partially), so the larger count and the latest time win.
+func ApplyCompactions(m *Metrics, h *SessionState, ti *ToolInfo) {
+	m.Compactions, m.LastCompaction = 0, nil
+	if h != nil && len(h.Compactions) > 0 {
+		m.Compactions = len(h.Compactions)
+		t := time.UnixMilli(h.Compactions[len(h.Compactions)-1])
+		m.LastCompaction = &t
+	}
+	if ti != nil {
+		m.Compactions = max(m.Compactions, ti.Compactions)
+		if ti.LastCompaction != nil && (m.LastCompaction == nil || ti.LastCompaction.After(*m.LastCompaction)) {
+			t := *ti.LastCompaction
+			m.LastCompaction = &t
+		}
+	}
+}
+
+// ApplyCostEstimate bases the lifetime cost velocity on the estimated
+// session cost when EstimateCost returned one.
+func ApplyCostEstimate(m *Metrics, d *StdinData, estimate *float64) {
+	if estimate == nil {
+		return
+	}
+	c := d.Cost
+	c.TotalCostUSD = *estimate
+	m.CostPerMinute = calcCostPerMinute(&c)
+}
+
+// ApplyCacheSavings sets the cache savings of the latest call from d at the
+// model's price, and the session total from h's running estimate (kept up to
+// date by EstimateCost).
+func ApplyCacheSavings(m *Metrics, d *StdinData, h *SessionState, p Pricing) {
+	m.CacheSavings, m.CacheSavingsTotal = nil, nil
+	price, ok := p.priceFor(d.Model)
+	if !ok {
+		return
+	}
+	if u := d.ContextWindow.CurrentUsage; u != nil && u.CacheReadInputTokens+u.CacheCreationInputTokens > 0 {
+		v := cacheSavings(u, price, d.Exceeds200KTokens)
+		m.CacheSavings = &v
+	}
+	if h != nil && h.Estimate != nil {
+		v := h.Estimate.SavedUSD
+		m.CacheSavingsTotal = &v
+	}
+}
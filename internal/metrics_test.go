@@ -2,7 +2,9 @@ package internal
 
 import (
 	"fmt"
+	"math"
 	"testing"
+	"time"
 )
 
 // Helper: compare *int pointers
@@ -431,6 +433,208 @@ func TestCalcCostPerMinute(t *testing.T) {
 	}
 }
 
+func TestCalcWindowRates(t *testing.T) {
+	t.Parallel()
+
+	at := func(m float64, cost float64, out int) Sample {
+		return Sample{Time: int64(m * msPerMinute), CostUSD: cost, OutputTokens: out}
+	}
+	tests := []struct {
+		name     string
+		samples  []Sample
+		window   time.Duration
+		wantCost *float64
+		wantOut  *float64
+	}{
+		{"no samples", nil, 10 * time.Minute, nil, nil},
+		{"single sample", []Sample{at(0, 1, 0)}, 10 * time.Minute, nil, nil},
+		{"under a minute of span", []Sample{at(0, 1, 0), at(0.5, 2, 100)}, 10 * time.Minute, nil, nil},
+		{
+			name:     "whole history inside window",
+			samples:  []Sample{at(0, 1, 1000), at(2, 2, 3000), at(4, 3, 5000)},
+			window:   10 * time.Minute,
+			wantCost: floatPtr(0.5),
+			wantOut:  floatPtr(1000),
+		},
+		{
+			name: "only the window counts",
+			// Cheap first hour, expensive last 10 minutes.
+			samples:  []Sample{at(0, 0, 0), at(50, 1, 10000), at(55, 3, 12000), at(60, 5, 14000)},
+			window:   10 * time.Minute,
+			wantCost: floatPtr(0.4),
+			wantOut:  floatPtr(400),
+		},
+		{
+			name:     "counter going backwards clamps to zero",
+			samples:  []Sample{at(0, 2, 500), at(2, 1, 100)},
+			window:   10 * time.Minute,
+			wantCost: floatPtr(0),
+			wantOut:  floatPtr(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cost, out := calcWindowRates(tt.samples, tt.window)
+			if !floatPtrEq(cost, tt.wantCost) || !floatPtrEq(out, tt.wantOut) {
+				t.Errorf("calcWindowRates() = %s, %s; want %s, %s",
+					ptrFloatToString(cost), ptrFloatToString(out), ptrFloatToString(tt.wantCost), ptrFloatToString(tt.wantOut))
+			}
+		})
+	}
+}
+
+func TestApplyHistory(t *testing.T) {
+	t.Parallel()
+
+	h := &SessionState{Samples: []Sample{
+		{Time: 0, CostUSD: 0},
+		{Time: 20 * msPerMinute, CostUSD: 1},
+		{Time: 30 * msPerMinute, CostUSD: 3},
+	}}
+
+	var m Metrics
+	ApplyHistory(&m, nil, DefaultThresholds())
+	if m.WindowCostPerMinute != nil || m.costVelocity() != nil {
+		t.Errorf("nil history should leave windowed metrics nil")
+	}
+
+	m = Metrics{CostPerMinute: floatPtr(0.1)}
+	ApplyHistory(&m, h, DefaultThresholds()) // 10-minute window
+	if !floatPtrEq(m.WindowCostPerMinute, floatPtr(0.2)) || !floatPtrEq(m.costVelocity(), floatPtr(0.2)) {
+		t.Errorf("10m window: got %s, want 0.2", ptrFloatToString(m.WindowCostPerMinute))
+	}
+
+	th := DefaultThresholds()
+	th.VelocityWindowMinutes = 30
+	ApplyHistory(&m, h, th)
+	if !floatPtrEq(m.WindowCostPerMinute, floatPtr(0.1)) {
+		t.Errorf("30m window: got %s, want 0.1", ptrFloatToString(m.WindowCostPerMinute))
+	}
+
+	// Zero window (thresholds not resolved) uses the default.
+	ApplyHistory(&m, h, Thresholds{})
+	if !floatPtrEq(m.WindowCostPerMinute, floatPtr(0.2)) {
+		t.Errorf("default window: got %s, want 0.2", ptrFloatToString(m.WindowCostPerMinute))
+	}
+}
+
+func TestApplyCompactions(t *testing.T) {
+	t.Parallel()
+
+	early := time.Date(2026, 6, 15, 10, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	tests := []struct {
+		name      string
+		h         *SessionState
+		ti        *ToolInfo
+		wantCount int
+		wantLast  *time.Time
+	}{
+		{"no sources", nil, nil, 0, nil},
+		{"history only", &SessionState{Compactions: []int64{early.UnixMilli(), late.UnixMilli()}}, nil, 2, &late},
+		{"transcript only", nil, &ToolInfo{Compactions: 1, LastCompaction: &early}, 1, &early},
+		{"larger count and latest time win",
+			&SessionState{Compactions: []int64{late.UnixMilli()}},
+			&ToolInfo{Compactions: 3, LastCompaction: &early}, 3, &late},
+		{"transcript count without time", nil, &ToolInfo{Compactions: 1}, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := Metrics{Compactions: 9}
+			ApplyCompactions(&m, tt.h, tt.ti)
+			if m.Compactions != tt.wantCount {
+				t.Errorf("Compactions = %d, want %d", m.Compactions, tt.wantCount)
+			}
+			if (m.LastCompaction == nil) != (tt.wantLast == nil) ||
+				(tt.wantLast != nil && !m.LastCompaction.Equal(*tt.wantLast)) {
+				t.Errorf("LastCompaction = %v, want %v", m.LastCompaction, tt.wantLast)
+			}
+		})
+	}
+}
+
+// ctxSamples builds one sample per minute with the given context percentages.
+func ctxSamples(pcts ...float64) []Sample {
+	samples := make([]Sample, len(pcts))
+	for i, p := range pcts {
+		samples[i] = Sample{Time: int64(i) * msPerMinute, ContextPercent: p}
+	}
+	return samples
+}
+
+func TestLastCompaction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		samples []Sample
+		want    int
+	}{
+		{"empty", nil, 0},
+		{"steady growth", ctxSamples(10, 20, 30), 0},
+		{"small dip is noise", ctxSamples(50, 45, 55), 0},
+		{"compaction", ctxSamples(70, 80, 20, 25), 2},
+		{"most recent of two", ctxSamples(70, 10, 60, 15, 20), 3},
+	}
+	for _, tt := range tests {
+		if got := lastCompaction(tt.samples); got != tt.want {
+			t.Errorf("%s: lastCompaction() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCalcContextETA(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		samples []Sample
+		current float64
+		window  time.Duration
+		want    *float64
+		fitted  bool
+	}{
+		{"no history", nil, 50, 10 * time.Minute, nil, false},
+		{"single sample", ctxSamples(50), 50, 10 * time.Minute, nil, false},
+		{"steady 2%/min", ctxSamples(60, 62, 64, 66, 68, 70), 70, 10 * time.Minute, floatPtr(15), true},
+		{"flat", ctxSamples(70, 70, 70), 70, 10 * time.Minute, nil, true},
+		{"shrinking", ctxSamples(72, 71, 70), 70, 10 * time.Minute, nil, true},
+		{
+			name: "fit restarts after compaction",
+			// Fast growth to 90%, compaction to 20%, then 5%/min.
+			samples: ctxSamples(30, 60, 90, 20, 25, 30),
+			current: 30,
+			window:  10 * time.Minute,
+			want:    floatPtr(14),
+			fitted:  true,
+		},
+		{"compaction leaves too little history", ctxSamples(60, 80, 20), 20, 10 * time.Minute, nil, true},
+		{
+			name: "only the window counts",
+			// Slow start, then 4%/min over the last three minutes.
+			samples: ctxSamples(10, 11, 12, 13, 14, 18, 22, 26),
+			current: 26,
+			window:  3 * time.Minute,
+			want:    floatPtr(18.5),
+			fitted:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, fitted := calcContextETA(tt.samples, tt.current, tt.window)
+			if (got == nil) != (tt.want == nil) || (got != nil && math.Abs(*got-*tt.want) > 1e-9) {
+				t.Errorf("calcContextETA() = %s, want %s", ptrFloatToString(got), ptrFloatToString(tt.want))
+			}
+			if fitted != tt.fitted {
+				t.Errorf("calcContextETA() fitted = %v, want %v", fitted, tt.fitted)
+			}
+		})
+	}
+}
+
 func TestComputeMetrics(t *testing.T) {
 	t.Parallel()
 
@@ -600,3 +804,39 @@ func ptrFloatToString(p *float64) string {
 	}
 	return fmt.Sprintf("%.2f", *p)
 }
+
+func TestApplyCacheSavings(t *testing.T) {
+	t.Parallel()
+
+	// Sonnet: input $3, cache read $0.30, cache write $3.75 per MTok.
+	d := &StdinData{
+		Model: Model{ID: "claude-sonnet-4-5"},
+		ContextWindow: ContextWindow{CurrentUsage: &CurrentUsage{
+			InputTokens: 1000, CacheReadInputTokens: 100_000, CacheCreationInputTokens: 20_000,
+		}},
+	}
+	h := &SessionState{Estimate: &CostEstimate{SavedUSD: 3.2}}
+
+	var m Metrics
+	ApplyCacheSavings(&m, d, h, Pricing{})
+	// 100K reads save 0.27, 20K writes cost 0.015 extra.
+	if m.CacheSavings == nil || !spendEq(*m.CacheSavings, 0.255) {
+		t.Errorf("CacheSavings = %v, want 0.255", m.CacheSavings)
+	}
+	if m.CacheSavingsTotal == nil || *m.CacheSavingsTotal != 3.2 {
+		t.Errorf("CacheSavingsTotal = %v, want 3.2 from history", m.CacheSavingsTotal)
+	}
+
+	ApplyCacheSavings(&m, d, nil, Pricing{})
+	if m.CacheSavings == nil || m.CacheSavingsTotal != nil {
+		t.Errorf("without history: turn %v, total %v; want turn only", m.CacheSavings, m.CacheSavingsTotal)
+	}
+	ApplyCacheSavings(&m, &StdinData{Model: Model{ID: "claude-sonnet-4-5"}}, nil, Pricing{})
+	if m.CacheSavings != nil {
+		t.Errorf("no cache tokens: CacheSavings = %v, want nil", *m.CacheSavings)
+	}
+	ApplyCacheSavings(&m, &StdinData{Model: Model{ID: "gpt-5"}, ContextWindow: d.ContextWindow}, h, Pricing{})
+	if m.CacheSavings != nil || m.CacheSavingsTotal != nil {
+		t.Error("a model without a price should have no savings")
+	}
+}
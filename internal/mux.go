@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MuxFormat is a terminal multiplexer status-bar dialect. Both use inline
+// "#[fg=...,bold]" style directives instead of SGR escapes; they differ in
+// how 256-color indexes are spelled and whether "#" must be escaped.
+type MuxFormat int
+
+const (
+	MuxTmux   MuxFormat = iota // tmux status-left/status-right #() output
+	MuxZellij                  // zellij zjstatus command widget output
+)
+
+var basicColorNames = [...]string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// MuxStatus turns rendered statusline lines into one status-bar string.
+// line selects a single line (1-based); 0 joins all lines with the segment
+// separator, since status bars show one line per command. Returns "" when
+// line is out of range.
+func MuxStatus(lines []string, f MuxFormat, line int) string {
+	if line > 0 {
+		if line > len(lines) {
+			return ""
+		}
+		lines = lines[line-1 : line]
+	}
+	var nonEmpty []string
+	for _, l := range lines {
+		if l != "" {
+			nonEmpty = append(nonEmpty, l)
+		}
+	}
+	return toMuxStyle(joinParts(nonEmpty), f)
+}
+
+// toMuxStyle rewrites SGR escapes in s as #[...] style directives. Other
+// escape sequences are dropped. A trailing #[default] is appended when the
+// string leaves a style active so it cannot bleed into the rest of the bar.
+func toMuxStyle(s string, f MuxFormat) string {
+	var b strings.Builder
+	styled := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\033' {
+			end := i + 1
+			if end < len(s) && s[end] == '[' {
+				end++
+				for end < len(s) && !(s[end] >= 0x40 && s[end] <= 0x7e) {
+					end++
+				}
+			}
+			if end >= len(s) {
+				break
+			}
+			if s[i+1] == '[' && s[end] == 'm' {
+				attrs := sgrToMuxAttrs(s[i+2:end], f)
+				if len(attrs) > 0 {
+					b.WriteString("#[" + strings.Join(attrs, ",") + "]")
+					styled = attrs[len(attrs)-1] != "default"
+				}
+			}
+			i = end
+			continue
+		}
+		if c == '#' && f == MuxTmux {
+			b.WriteString("##")
+			continue
+		}
+		b.WriteByte(c)
+	}
+	if styled {
+		b.WriteString("#[default]")
+	}
+	return b.String()
+}
+
+// sgrToMuxAttrs maps SGR parameters ("1;31", "38;5;208", "0") to style
+// attributes. Unsupported parameters are skipped.
+func sgrToMuxAttrs(params string, f MuxFormat) []string {
+	if params == "" {
+		return []string{"default"}
+	}
+	codes := strings.Split(params, ";")
+	var attrs []string
+	for i := 0; i < len(codes); i++ {
+		n, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 0:
+			attrs = append(attrs[:0], "default")
+		case n == 1:
+			attrs = append(attrs, "bold")
+		case n == 2:
+			attrs = append(attrs, "dim")
+		case n == 22:
+			attrs = append(attrs, "nobold", "nodim")
+		case n >= 30 && n <= 37:
+			attrs = append(attrs, "fg="+basicColorNames[n-30])
+		case n == 39:
+			attrs = append(attrs, "fg=default")
+		case n >= 90 && n <= 97:
+			attrs = append(attrs, "fg="+muxColor(n-90+8, f))
+		case n == 38 && i+2 < len(codes) && codes[i+1] == "5":
+			if idx, err := strconv.Atoi(codes[i+2]); err == nil && idx >= 0 && idx <= 255 {
+				attrs = append(attrs, "fg="+muxColor(idx, f))
+			}
+			i += 2
+		}
+	}
+	return attrs
+}
+
+// muxColor spells a 256-color palette index: tmux uses "colourN" (with
+// "brightX" names for 8-15), zjstatus takes the bare index.
+func muxColor(idx int, f MuxFormat) string {
+	if f == MuxZellij {
+		return strconv.Itoa(idx)
+	}
+	if idx >= 8 && idx <= 15 {
+		return "bright" + basicColorNames[idx-8]
+	}
+	return "colour" + strconv.Itoa(idx)
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToMuxStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		f    MuxFormat
+		want string
+	}{
+		{"plain", "Opus 4.6", MuxTmux, "Opus 4.6"},
+		{"basic color", green + "42%" + Reset, MuxTmux, "#[fg=green]42%#[default]"},
+		{"bold red", boldRed + "91%" + Reset, MuxTmux, "#[bold,fg=red]91%#[default]"},
+		{"256 color tmux", orange + "x" + Reset, MuxTmux, "#[fg=colour208]x#[default]"},
+		{"256 color zellij", orange + "x" + Reset, MuxZellij, "#[fg=208]x#[default]"},
+		{"bright tmux", "\033[91mx", MuxTmux, "#[fg=brightred]x#[default]"},
+		{"bright zellij", "\033[91mx", MuxZellij, "#[fg=9]x#[default]"},
+		{"unterminated style closed", cyan + "main", MuxTmux, "#[fg=cyan]main#[default]"},
+		{"hash escaped for tmux", "fix-#12", MuxTmux, "fix-##12"},
+		{"hash kept for zellij", "fix-#12", MuxZellij, "fix-#12"},
+		{"non-SGR escape dropped", "a\033[2Kb", MuxTmux, "ab"},
+		{"truncated escape dropped", "a\033[3", MuxTmux, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := toMuxStyle(tt.in, tt.f); got != tt.want {
+				t.Errorf("toMuxStyle(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMuxStatus(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{green + "a" + Reset, "", "b"}
+
+	all := MuxStatus(lines, MuxTmux, 0)
+	if all != "#[fg=green]a#[default] #[fg=colour245]|#[default] b" {
+		t.Errorf("all lines = %q", all)
+	}
+	if got := MuxStatus(lines, MuxTmux, 3); got != "b" {
+		t.Errorf("line 3 = %q, want b", got)
+	}
+	if got := MuxStatus(lines, MuxTmux, 4); got != "" {
+		t.Errorf("out of range line = %q, want empty", got)
+	}
+}
+
+// A full render must convert without leaving raw escapes behind.
+func TestMuxStatus_NoRawEscapes(t *testing.T) {
+	t.Parallel()
+
+	rc, _ := PreviewContext("danger", time.Now(), PresetConfig("full"))
+	for _, f := range []MuxFormat{MuxTmux, MuxZellij} {
+		got := MuxStatus(Render(rc), f, 0)
+		if strings.Contains(got, "\033") {
+			t.Errorf("format %d: output contains raw escapes: %q", f, got)
+		}
+		if !strings.Contains(got, "#[") {
+			t.Errorf("format %d: output has no style directives: %q", f, got)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"sort"
+	"time"
+)
+
+// previewScenario is a synthetic session used by `howl preview` to render a
+// realistic statusline without a running Claude Code session.
+type previewScenario struct {
+	description string
+	build       func(now time.Time) RenderContext
+}
+
+// DefaultScenario is the scenario `howl preview` renders when none is given.
+const DefaultScenario = "normal"
+
+var previewScenarios = map[string]previewScenario{
+	"normal": {"subscriber mid-session, quota comfortable", func(now time.Time) RenderContext {
+		return previewContext(previewData(now, 42), now)
+	}},
+	"danger": {"context above the danger threshold", func(now time.Time) RenderContext {
+		d := previewData(now, 91)
+		d.Cost.TotalCostUSD = 4.87
+		d.Cost.TotalDurationMS = 2*3600000 + 14*60000
+		return previewContext(d, now)
+	}},
+	"quota-low": {"5h quota nearly exhausted", func(now time.Time) RenderContext {
+		d := previewData(now, 58)
+		d.RateLimits.FiveHour.UsedPercentage = 93
+		d.RateLimits.SevenDay.UsedPercentage = 71
+		return previewContext(d, now)
+	}},
+	"no-git": {"API-key user outside a git repository", func(now time.Time) RenderContext {
+		d := previewData(now, 35)
+		d.RateLimits = nil
+		rc := previewContext(d, now)
+		rc.Git = nil
+		rc.Account = nil
+		return rc
+	}},
+	"1m-context": {"1M context window model", func(now time.Time) RenderContext {
+		d := previewData(now, 21)
+		d.Model = Model{ID: "claude-sonnet-4-5[1m]", DisplayName: "Sonnet 4.5"}
+		d.ContextWindow.ContextWindowSize = 1000000
+		d.ContextWindow.CurrentUsage.CacheReadInputTokens = 180000
+		return previewContext(d, now)
+	}},
+}
+
+// ScenarioNames returns the built-in preview scenarios, sorted.
+func ScenarioNames() []string {
+	names := make([]string, 0, len(previewScenarios))
+	for name := range previewScenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScenarioDescription returns a one-line summary of a preview scenario.
+func ScenarioDescription(name string) string {
+	return previewScenarios[name].description
+}
+
+// PreviewContext builds the render inputs for a named scenario relative to
+// now (quota reset countdowns), enriched under cfg the way the live
+// statusline is: cost estimate, windowed metrics and cache savings. The
+// scenario has no recorded history, so the estimate prices it whole.
+// Returns false for an unknown scenario.
+func PreviewContext(name string, now time.Time, cfg Config) (RenderContext, bool) {
+	s, ok := previewScenarios[name]
+	if !ok {
+		return RenderContext{}, false
+	}
+	rc := s.build(now)
+	rc.Config = cfg
+	history := &SessionState{SessionID: rc.Data.SessionID}
+	rc.CostEstimate = EstimateCost(rc.Data, history, cfg.Pricing)
+	ApplyCostEstimate(&rc.Metrics, rc.Data, rc.CostEstimate)
+	ApplyHistory(&rc.Metrics, history, cfg.Thresholds)
+	ApplyCacheSavings(&rc.Metrics, rc.Data, history, cfg.Pricing)
+	return rc, true
+}
+
+// previewData returns the shared synthetic session: Opus on a 200K window at
+// the given context percentage, with quota and vim mode.
+func previewData(now time.Time, contextPercent float64) StdinData {
+	return StdinData{
+		SessionID: "preview",
+		Version:   "2.1.80",
+		Model:     Model{ID: "claude-opus-4-6", DisplayName: "Opus 4.6"},
+		Workspace: Workspace{CurrentDir: "/home/user/projects/howl", ProjectDir: "/home/user/projects/howl"},
+		Cost: Cost{
+			TotalCostUSD:       1.84,
+			TotalDurationMS:    47 * 60000,
+			TotalAPIDurationMS: 14 * 60000,
+			TotalLinesAdded:    356,
+			TotalLinesRemoved:  48,
+		},
+		ContextWindow: ContextWindow{
+			TotalInputTokens:  84200,
+			TotalOutputTokens: 12600,
+			ContextWindowSize: 200000,
+			UsedPercentage:    &contextPercent,
+			CurrentUsage: &CurrentUsage{
+				InputTokens:              2100,
+				OutputTokens:             1350,
+				CacheCreationInputTokens: 4800,
+				CacheReadInputTokens:     61000,
+			},
+		},
+		Vim: &Vim{Mode: "NORMAL"},
+		RateLimits: &RateLimits{
+			FiveHour: &RateLimitWindow{UsedPercentage: 38, ResetsAt: now.Add(2*time.Hour + 15*time.Minute).Unix()},
+			SevenDay: &RateLimitWindow{UsedPercentage: 22, ResetsAt: now.Add(3*24*time.Hour + 6*time.Hour).Unix()},
+		},
+	}
+}
+
+func previewContext(d StdinData, now time.Time) RenderContext {
+	usage := UsageFromRateLimits(d.RateLimits)
+	ProjectQuota(usage, nil, now)
+	reviewStart, exploreStart := now.Add(-4*time.Minute-12*time.Second), now.Add(-38*time.Second)
+	return RenderContext{
+		Data:    &d,
+		Metrics: ComputeMetrics(&d),
+		Git:     &GitInfo{Branch: "main", Dirty: true},
+		Usage:   usage,
+		Tools: &ToolInfo{
+			Tools:  map[string]int{"Read": 24, "Edit": 11, "Bash": 9, "Grep": 6, "Write": 2},
+			Agents: []string{"code-reviewer", "Explore"},
+			AgentTree: []RunningAgent{
+				{Name: "code-reviewer", Started: &reviewStart},
+				{Name: "Explore", Depth: 1, Started: &exploreStart},
+			},
+			AgentsDone: 2,
+			Todos:      &TodoProgress{Done: 4, Total: 9, Active: "Running tests"},
+		},
+		Account: &AccountInfo{EmailAddress: "user@example.com"},
+	}
+}
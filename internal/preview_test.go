@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviewContext_AllScenariosRender(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	for _, name := range ScenarioNames() {
+		rc, ok := PreviewContext(name, now, PresetConfig("full"))
+		if !ok {
+			t.Fatalf("PreviewContext(%q) not found", name)
+		}
+		if ScenarioDescription(name) == "" {
+			t.Errorf("scenario %q has no description", name)
+		}
+		if lines := Render(rc); len(lines) < 2 {
+			t.Errorf("scenario %q rendered %d lines, want at least 2: %v", name, len(lines), lines)
+		}
+	}
+
+	if _, ok := PreviewContext("bogus", now, Config{}); ok {
+		t.Errorf("unknown scenario should not be found")
+	}
+}
+
+func TestPreviewContext_Scenarios(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	render := func(name string) string {
+		rc, _ := PreviewContext(name, now, PresetConfig("full"))
+		return strings.Join(Render(rc), "\n")
+	}
+
+	if out := render("danger"); !strings.Contains(out, "🔴") {
+		t.Errorf("danger scenario should render danger mode:\n%s", out)
+	}
+	if out := render("normal"); strings.Contains(out, "🔴") || !strings.Contains(out, "main*") {
+		t.Errorf("normal scenario should render normal mode with git:\n%s", out)
+	}
+	if out := render("no-git"); strings.Contains(out, "main") || strings.Contains(out, "5h") {
+		t.Errorf("no-git scenario should have no branch and no quota:\n%s", out)
+	}
+	if out := render("quota-low"); !strings.Contains(out, "  7%") {
+		t.Errorf("quota-low scenario should show 7%% remaining:\n%s", out)
+	}
+	if out := render("1m-context"); !strings.Contains(out, "/1M)") {
+		t.Errorf("1m-context scenario should show a 1M window:\n%s", out)
+	}
+}
+
+// The preview runs the live statusline's enrichment, so segments derived from
+// the price table show.
+func TestPreviewContext_Enriched(t *testing.T) {
+	t.Parallel()
+
+	rc, _ := PreviewContext("normal", time.Now(), PresetConfig("cost-focused"))
+	if out := strings.Join(Render(rc), "\n"); !strings.Contains(out, "Saved:") {
+		t.Errorf("cost-focused preview should show cache savings:\n%s", out)
+	}
+	if rc.CostEstimate != nil {
+		t.Errorf("a reported first-party cost should not be estimated, got %v", *rc.CostEstimate)
+	}
+
+	cfg := PresetConfig("full")
+	cfg.Pricing.Estimate = PricingAlways
+	rc, _ = PreviewContext("normal", time.Now(), cfg)
+	if rc.CostEstimate == nil || !strings.Contains(strings.Join(Render(rc), "\n"), "est.") {
+		t.Errorf("pricing.estimate always should estimate the preview cost, got %v", rc.CostEstimate)
+	}
+}
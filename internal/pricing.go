@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Claude Code's total_cost_usd is missing for some setups and is list price,
+// not what Bedrock, Vertex or discounted API keys are billed. Pricing lets
+// Howl estimate the cost from token counts instead.
+
+// Estimate modes for Pricing.Estimate.
+const (
+	PricingAuto   = "auto"   // estimate for Bedrock/Vertex models or when no cost is reported
+	PricingAlways = "always" // always estimate, ignoring the reported cost
+	PricingNever  = "never"  // always show the reported cost
+)
+
+// Pricing controls token-based cost estimation.
+type Pricing struct {
+	// Estimate is one of PricingAuto (default), PricingAlways, PricingNever.
+	Estimate string `json:"estimate"`
+	// Models adds to or overrides the built-in price table. Keys match model
+	// IDs by substring, e.g. "sonnet-4"; the longest matching key wins.
+	Models map[string]ModelPrice `json:"models"`
+}
+
+// ModelPrice is a model's price in USD per million tokens. Zero cache prices
+// default to the usual multiples of Input (1.25x write, 0.1x read). The Long
+// prices apply while the context exceeds 200K tokens; zero means no
+// long-context premium.
+type ModelPrice struct {
+	Input          float64 `json:"input"`
+	Output         float64 `json:"output"`
+	CacheWrite     float64 `json:"cache_write,omitempty"`
+	CacheRead      float64 `json:"cache_read,omitempty"`
+	LongInput      float64 `json:"long_input,omitempty"`
+	LongOutput     float64 `json:"long_output,omitempty"`
+	LongCacheWrite float64 `json:"long_cache_write,omitempty"`
+	LongCacheRead  float64 `json:"long_cache_read,omitempty"`
+}
+
+// builtinPrices are Anthropic list prices, keyed like Pricing.Models. Family
+// keys ("opus", "sonnet", "haiku") carry current-generation prices and catch
+// models newer than the table; older generations are listed explicitly, by
+// model ID ("3-opus") and display name ("opus-3") order.
+var builtinPrices = map[string]ModelPrice{
+	"opus":        {Input: 5, Output: 25},
+	"3-opus":      {Input: 15, Output: 75},
+	"opus-3":      {Input: 15, Output: 75},
+	"opus-4-0":    {Input: 15, Output: 75},
+	"opus-4-2025": {Input: 15, Output: 75}, // claude-opus-4-20250514
+	"opus-4-1":    {Input: 15, Output: 75},
+	"opus-4-5":    {Input: 5, Output: 25},
+	"opus-4-6":    {Input: 5, Output: 25, LongInput: 10, LongOutput: 37.5},
+	"sonnet":      {Input: 3, Output: 15, LongInput: 6, LongOutput: 22.5},
+	"3-sonnet":    {Input: 3, Output: 15}, // claude-3-sonnet, 3-5-sonnet, 3-7-sonnet: no long-context tier
+	"3-5-sonnet":  {Input: 3, Output: 15},
+	"3-7-sonnet":  {Input: 3, Output: 15},
+	"sonnet-3":    {Input: 3, Output: 15},
+	"haiku":       {Input: 1, Output: 5},
+	"3-5-haiku":   {Input: 0.8, Output: 4},
+	"haiku-3-5":   {Input: 0.8, Output: 4},
+	"3-haiku":     {Input: 0.25, Output: 1.25},
+	"haiku-3":     {Input: 0.25, Output: 1.25},
+}
+
+// rates returns the per-MTok prices of input, output, cache writes and cache
+// reads, at the long-context tier when long is set.
+func (p ModelPrice) rates(long bool) (input, output, cacheWrite, cacheRead float64) {
+	input, output, cacheWrite, cacheRead = p.Input, p.Output, p.CacheWrite, p.CacheRead
+	if long && p.LongInput > 0 {
+		input, cacheWrite, cacheRead = p.LongInput, p.LongCacheWrite, p.LongCacheRead
+		if p.LongOutput > 0 {
+			output = p.LongOutput
+		}
+	}
+	if cacheWrite == 0 {
+		cacheWrite = input * 1.25
+	}
+	if cacheRead == 0 {
+		cacheRead = input * 0.1
+	}
+	return input, output, cacheWrite, cacheRead
+}
+
+// priceKey normalizes a model ID, display name or table key for matching:
+// "Opus 4.5" and "claude-opus-4-5" both contain "opus-4-5".
+func priceKey(s string) string {
+	return strings.NewReplacer(" ", "-", ".", "-", "_", "-").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// matchPrice returns the entry of table whose key is the longest substring
+// of model.
+func matchPrice(table map[string]ModelPrice, model string) (ModelPrice, bool) {
+	best, found := "", false
+	var price ModelPrice
+	for key, p := range table {
+		k := priceKey(key)
+		if k == "" || !strings.Contains(model, k) {
+			continue
+		}
+		if !found || len(k) > len(best) || (len(k) == len(best) && k < best) {
+			best, price, found = k, p, true
+		}
+	}
+	return price, found
+}
+
+// priceFor looks up the price of m, configured entries before built-in ones.
+func (p Pricing) priceFor(m Model) (ModelPrice, bool) {
+	name := m.ID
+	if name == "" {
+		name = m.DisplayName
+	}
+	model := priceKey(name)
+	if model == "" {
+		return ModelPrice{}, false
+	}
+	if price, ok := matchPrice(p.Models, model); ok {
+		return price, true
+	}
+	return matchPrice(builtinPrices, model)
+}
+
+// shouldEstimate reports whether the estimate should replace d's reported
+// cost under the configured mode.
+func (p Pricing) shouldEstimate(d *StdinData) bool {
+	switch p.Estimate {
+	case PricingAlways:
+		return true
+	case PricingNever:
+		return false
+	}
+	return modelProvider(d.Model) != "" || d.Cost.TotalCostUSD == 0
+}
+
+// mergePricing layers override on top of base: Estimate is replaced when set,
+// Models merge per key.
+func mergePricing(base, override Pricing) Pricing {
+	result := base
+	if strings.TrimSpace(override.Estimate) != "" {
+		result.Estimate = override.Estimate
+	}
+	if len(override.Models) > 0 {
+		models := make(map[string]ModelPrice, len(base.Models)+len(override.Models))
+		for k, v := range base.Models {
+			models[k] = v
+		}
+		for k, v := range override.Models {
+			models[k] = v
+		}
+		result.Models = models
+	}
+	return result
+}
+
+// validatePricing normalizes the estimate mode and drops model entries with
+// negative prices. Returns a message for each correction.
+func validatePricing(p *Pricing) []string {
+	var fixes []string
+	mode := strings.ToLower(strings.TrimSpace(p.Estimate))
+	switch mode {
+	case "":
+		mode = PricingAuto
+	case PricingAuto, PricingAlways, PricingNever:
+	default:
+		fixes = append(fixes, fmt.Sprintf("pricing.estimate: unknown mode %q, using %q", p.Estimate, PricingAuto))
+		mode = PricingAuto
+	}
+	p.Estimate = mode
+
+	keys := make([]string, 0, len(p.Models))
+	for k := range p.Models {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		m := p.Models[k]
+		if min(m.Input, m.Output, m.CacheWrite, m.CacheRead, m.LongInput, m.LongOutput, m.LongCacheWrite, m.LongCacheRead) < 0 {
+			fixes = append(fixes, fmt.Sprintf("pricing.models.%s: negative price, entry ignored", k))
+			delete(p.Models, k)
+		}
+	}
+	return fixes
+}
+
+// CostEstimate is the running token-based cost of a session, kept in its
+// state so each invocation only prices the tokens used since the last.
+type CostEstimate struct {
+	InputTokens  int     `json:"in"`  // cumulative input tokens priced so far
+	OutputTokens int     `json:"out"` // cumulative output tokens priced so far
+	CostUSD      float64 `json:"cost"`
+	// SavedUSD is what prompt caching saved against uncached input, net of
+	// cache-write overhead (negative when writes cost more than reads saved).
+	SavedUSD float64 `json:"saved"`
+}
+
+// advance prices the tokens d reports beyond those already in e. The
+// cumulative counters cover uncached input and output only, so cache writes
+// and reads are priced from the latest call's counts, once per turn: a turn
+// is new when the counters moved, so refreshes within a turn count it once.
+// Calls between two refreshes other than the latest are not seen, so
+// cache-heavy sessions can still read low. Lower counts than e means the
+// counters restarted (a resumed session), so they are priced whole; the
+// estimate never decreases. Savings accrue per turn the same way.
+func (e CostEstimate) advance(d *StdinData, p ModelPrice) CostEstimate {
+	cw := d.ContextWindow
+	in, out := cw.TotalInputTokens-e.InputTokens, cw.TotalOutputTokens-e.OutputTokens
+	if in < 0 || out < 0 {
+		in, out = cw.TotalInputTokens, cw.TotalOutputTokens
+	}
+	input, output, cacheWrite, cacheRead := p.rates(d.Exceeds200KTokens)
+	cost := float64(in)*input + float64(out)*output
+	saved := e.SavedUSD
+	if u := cw.CurrentUsage; u != nil && (in > 0 || out > 0) {
+		cost += float64(u.CacheCreationInputTokens)*cacheWrite + float64(u.CacheReadInputTokens)*cacheRead
+		saved += cacheSavings(u, p, d.Exceeds200KTokens)
+	}
+	return CostEstimate{
+		InputTokens:  cw.TotalInputTokens,
+		OutputTokens: cw.TotalOutputTokens,
+		CostUSD:      e.CostUSD + cost/1e6,
+		SavedUSD:     saved,
+	}
+}
+
+// cacheSavings is what caching saved on one call's input compared with
+// sending it all uncached: cache reads save the difference to the input
+// price, cache writes cost their premium over it.
+func cacheSavings(u *CurrentUsage, p ModelPrice, long bool) float64 {
+	input, _, cacheWrite, cacheRead := p.rates(long)
+	return (float64(u.CacheReadInputTokens)*(input-cacheRead) -
+		float64(u.CacheCreationInputTokens)*(cacheWrite-input)) / 1e6
+}
+
+// EstimateCost prices d's tokens when the model's price is known, continuing
+// the running totals in st and storing the new ones there; without session
+// history (nil st) the whole session is priced at the current rates. It
+// returns the estimated session cost when p calls for an estimate, nil
+// otherwise. d is not modified: its reported cost stays available next to
+// the estimate (see SessionCost).
+func EstimateCost(d *StdinData, st *SessionState, p Pricing) *float64 {
+	price, ok := p.priceFor(d.Model)
+	if !ok {
+		return nil
+	}
+	var prev CostEstimate
+	if st != nil && st.Estimate != nil {
+		prev = *st.Estimate
+	}
+	next := prev.advance(d, price)
+	if st != nil {
+		st.Estimate = &next
+	}
+	if !p.shouldEstimate(d) {
+		return nil
+	}
+	cost := next.CostUSD
+	return &cost
+}
+
+// SessionCost is the session cost to show, record and budget against: the
+// estimate from EstimateCost when there is one, otherwise the reported cost.
+func SessionCost(d *StdinData, estimate *float64) float64 {
+	if estimate != nil {
+		return *estimate
+	}
+	return d.Cost.TotalCostUSD
+}
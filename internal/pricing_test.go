@@ -0,0 +1,287 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestModelPrice_Rates(t *testing.T) {
+	t.Parallel()
+
+	p := ModelPrice{Input: 3, Output: 15, LongInput: 6, LongOutput: 22.5}
+	tests := []struct {
+		name                       string
+		price                      ModelPrice
+		long                       bool
+		input, output, write, read float64
+	}{
+		{"derived cache prices", p, false, 3, 15, 3.75, 0.3},
+		{"long-context tier", p, true, 6, 22.5, 7.5, 0.6},
+		{"no long tier", ModelPrice{Input: 1, Output: 5}, true, 1, 5, 1.25, 0.1},
+		{"explicit cache prices", ModelPrice{Input: 2, Output: 8, CacheWrite: 2, CacheRead: 1}, false, 2, 8, 2, 1},
+	}
+	for _, tt := range tests {
+		input, output, write, read := tt.price.rates(tt.long)
+		if !spendEq(input, tt.input) || !spendEq(output, tt.output) || !spendEq(write, tt.write) || !spendEq(read, tt.read) {
+			t.Errorf("%s: rates = %v %v %v %v, want %v %v %v %v",
+				tt.name, input, output, write, read, tt.input, tt.output, tt.write, tt.read)
+		}
+	}
+}
+
+func TestPricing_PriceFor(t *testing.T) {
+	t.Parallel()
+
+	custom := Pricing{Models: map[string]ModelPrice{"Sonnet 4.5": {Input: 2.4, Output: 12}}}
+	tests := []struct {
+		name    string
+		pricing Pricing
+		model   Model
+		input   float64
+		ok      bool
+	}{
+		{"dated opus 4", Pricing{}, Model{ID: "claude-opus-4-20250514"}, 15, true},
+		{"opus 4.1", Pricing{}, Model{ID: "claude-opus-4-1-20250805"}, 15, true},
+		{"opus 4.6", Pricing{}, Model{ID: "claude-opus-4-6"}, 5, true},
+		{"bedrock sonnet", Pricing{}, Model{ID: "us.anthropic.claude-sonnet-4-20250514-v1:0"}, 3, true},
+		{"haiku 3.5", Pricing{}, Model{ID: "claude-3-5-haiku-20241022"}, 0.8, true},
+		{"haiku 3.5 display name", Pricing{}, Model{DisplayName: "Haiku 3.5"}, 0.8, true},
+		{"haiku 3", Pricing{}, Model{ID: "claude-3-haiku-20240307"}, 0.25, true},
+		{"sonnet 3.7", Pricing{}, Model{ID: "claude-3-7-sonnet-20250219"}, 3, true},
+		{"display name only", Pricing{}, Model{DisplayName: "Opus 4.5"}, 5, true},
+		{"newer model falls back to family", Pricing{}, Model{ID: "claude-haiku-9"}, 1, true},
+		{"configured entry wins", custom, Model{ID: "claude-sonnet-4-5-20250929"}, 2.4, true},
+		{"configured entry does not match", custom, Model{ID: "claude-sonnet-4-20250514"}, 3, true},
+		{"unknown model", Pricing{}, Model{ID: "gpt-5"}, 0, false},
+		{"no model", Pricing{}, Model{}, 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := tt.pricing.priceFor(tt.model)
+		if ok != tt.ok || price.Input != tt.input {
+			t.Errorf("%s: priceFor = %+v, %v; want input %v, %v", tt.name, price, ok, tt.input, tt.ok)
+		}
+	}
+
+	// Claude 3 Opus is priced at $15/$75, not the current "opus" family price.
+	for _, m := range []Model{{ID: "claude-3-opus-20240229"}, {DisplayName: "Opus 3"}, {DisplayName: "Claude 3 Opus"}} {
+		if price, ok := (Pricing{}).priceFor(m); !ok || price.Input != 15 || price.Output != 75 {
+			t.Errorf("priceFor(%+v) = %+v, %v; want $15/$75", m, price, ok)
+		}
+	}
+	if price, _ := (Pricing{}).priceFor(Model{ID: "claude-3-5-sonnet-20241022"}); price.LongInput != 0 {
+		t.Errorf("claude-3-5-sonnet should have no long-context tier, got %+v", price)
+	}
+}
+
+func TestPricing_ShouldEstimate(t *testing.T) {
+	t.Parallel()
+
+	api := &StdinData{Model: Model{ID: "claude-opus-4-6"}, Cost: Cost{TotalCostUSD: 1}}
+	noCost := &StdinData{Model: Model{ID: "claude-opus-4-6"}}
+	vertex := &StdinData{Model: Model{ID: "projects/p/locations/l/publishers/anthropic/models/claude-opus-4-6"}, Cost: Cost{TotalCostUSD: 1}}
+	tests := []struct {
+		mode string
+		d    *StdinData
+		want bool
+	}{
+		{PricingAuto, api, false},
+		{PricingAuto, noCost, true},
+		{PricingAuto, vertex, true},
+		{"", vertex, true},
+		{PricingAlways, api, true},
+		{PricingNever, vertex, false},
+	}
+	for _, tt := range tests {
+		if got := (Pricing{Estimate: tt.mode}).shouldEstimate(tt.d); got != tt.want {
+			t.Errorf("shouldEstimate(%q, %s) = %v, want %v", tt.mode, tt.d.Model.ID, got, tt.want)
+		}
+	}
+}
+
+func TestCostEstimate_Advance(t *testing.T) {
+	t.Parallel()
+
+	price := ModelPrice{Input: 3, Output: 15, LongInput: 6, LongOutput: 22.5}
+	d := &StdinData{ContextWindow: ContextWindow{TotalInputTokens: 1_000_000, TotalOutputTokens: 100_000}}
+
+	// No breakdown: all input at the base input price. 3 + 1.5
+	e := CostEstimate{}.advance(d, price)
+	if !spendEq(e.CostUSD, 4.5) || e.InputTokens != 1_000_000 || e.OutputTokens != 100_000 {
+		t.Fatalf("first advance = %+v, want $4.50 at 1M/100K", e)
+	}
+
+	// Only the new tokens are priced at the uncached input price (3.00),
+	// plus the turn's cache reads at 0.30/MTok.
+	d.ContextWindow.TotalInputTokens = 2_000_000
+	d.ContextWindow.CurrentUsage = &CurrentUsage{InputTokens: 500, CacheReadInputTokens: 500_000}
+	e = e.advance(d, price)
+	if !spendEq(e.CostUSD, 4.5+3+0.15) {
+		t.Errorf("second advance = %v, want %v", e.CostUSD, 4.5+3+0.15)
+	}
+	// A refresh within the turn does not price its cache reads again.
+	if again := e.advance(d, price); !spendEq(again.CostUSD, e.CostUSD) {
+		t.Errorf("refresh advance = %v, want %v", again.CostUSD, e.CostUSD)
+	}
+	// Those cache reads saved 2.70/MTok against uncached input.
+	if !spendEq(e.SavedUSD, 1.35) {
+		t.Errorf("SavedUSD = %v, want 1.35", e.SavedUSD)
+	}
+
+	// Past 200K the long-context prices apply to the new tokens.
+	d.Exceeds200KTokens = true
+	d.ContextWindow.CurrentUsage = nil
+	d.ContextWindow.TotalOutputTokens = 200_000
+	before := e.CostUSD
+	e = e.advance(d, price)
+	if !spendEq(e.CostUSD-before, 2.25) {
+		t.Errorf("long-context advance added %v, want 2.25", e.CostUSD-before)
+	}
+
+	// Restarted counters are priced whole on top; the estimate never drops.
+	d.Exceeds200KTokens = false
+	d.ContextWindow = ContextWindow{TotalInputTokens: 100_000}
+	before = e.CostUSD
+	e = e.advance(d, price)
+	if !spendEq(e.CostUSD-before, 0.3) || e.InputTokens != 100_000 {
+		t.Errorf("restart advance = %+v, want +0.30 at 100K input", e)
+	}
+}
+
+func TestCostEstimate_AdvanceSavings(t *testing.T) {
+	t.Parallel()
+
+	price := ModelPrice{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	turns := []struct {
+		in, out int
+		usage   CurrentUsage
+		want    float64 // session savings after the turn
+	}{
+		{50, 400, CurrentUsage{InputTokens: 50, CacheReadInputTokens: 20_000, CacheCreationInputTokens: 2_000}, 0.0525},
+		// A refresh within the same turn does not count it again.
+		{50, 400, CurrentUsage{InputTokens: 50, CacheReadInputTokens: 20_000, CacheCreationInputTokens: 2_000}, 0.0525},
+		// A tool-use turn adds output only; its cache reads still count.
+		{50, 900, CurrentUsage{CacheReadInputTokens: 30_000}, 0.1335},
+		{120, 1_500, CurrentUsage{InputTokens: 70, CacheReadInputTokens: 40_000, CacheCreationInputTokens: 1_000}, 0.24075},
+	}
+	var e CostEstimate
+	for i, tt := range turns {
+		d := &StdinData{ContextWindow: ContextWindow{TotalInputTokens: tt.in, TotalOutputTokens: tt.out, CurrentUsage: &tt.usage}}
+		e = e.advance(d, price)
+		if !spendEq(e.SavedUSD, tt.want) {
+			t.Errorf("turn %d: SavedUSD = %v, want %v", i, e.SavedUSD, tt.want)
+		}
+		if turn := cacheSavings(&tt.usage, price, false); e.SavedUSD < turn {
+			t.Errorf("turn %d: session savings %v below the turn's %v", i, e.SavedUSD, turn)
+		}
+	}
+}
+
+func TestCacheSavings(t *testing.T) {
+	t.Parallel()
+
+	price := ModelPrice{Input: 3, Output: 15, LongInput: 6, LongOutput: 22.5}
+	tests := []struct {
+		name string
+		u    CurrentUsage
+		long bool
+		want float64
+	}{
+		{"reads save the input price minus the read price", CurrentUsage{CacheReadInputTokens: 1_000_000}, false, 2.7},
+		{"writes cost their premium", CurrentUsage{CacheCreationInputTokens: 1_000_000}, false, -0.75},
+		{"poor hit rate is a net loss", CurrentUsage{CacheReadInputTokens: 100_000, CacheCreationInputTokens: 500_000}, false, 0.27 - 0.375},
+		{"long-context prices", CurrentUsage{CacheReadInputTokens: 1_000_000}, true, 5.4},
+		{"uncached input saves nothing", CurrentUsage{InputTokens: 1_000_000}, false, 0},
+	}
+	for _, tt := range tests {
+		if got := cacheSavings(&tt.u, price, tt.long); !spendEq(got, tt.want) {
+			t.Errorf("%s: cacheSavings = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	t.Parallel()
+
+	d := &StdinData{
+		Model:         Model{ID: "us.anthropic.claude-haiku-4-5-20251001-v1:0"},
+		Cost:          Cost{TotalCostUSD: 9},
+		ContextWindow: ContextWindow{TotalInputTokens: 1_000_000, TotalOutputTokens: 200_000},
+	}
+	st := &SessionState{SessionID: "s1"}
+	est := EstimateCost(d, st, Pricing{Estimate: PricingAuto})
+	if est == nil {
+		t.Fatal("Bedrock model should be estimated")
+	}
+	if !spendEq(*est, 2) || st.Estimate == nil || !spendEq(st.Estimate.CostUSD, 2) {
+		t.Errorf("estimate = %v, state estimate = %+v; want $2 in both", *est, st.Estimate)
+	}
+	if d.Cost.TotalCostUSD != 9 || !spendEq(SessionCost(d, est), 2) {
+		t.Errorf("reported cost = %v, session cost = %v; want the reported $9 kept and $2 used", d.Cost.TotalCostUSD, SessionCost(d, est))
+	}
+
+	// The next invocation continues from the stored estimate.
+	d.ContextWindow.TotalOutputTokens = 400_000
+	if est := EstimateCost(d, st, Pricing{}); est == nil || !spendEq(*est, 3) {
+		t.Errorf("continued estimate = %v, want 3", est)
+	}
+
+	// Without an estimate the running totals still advance for cache savings.
+	d.ContextWindow.TotalOutputTokens = 600_000
+	if est := EstimateCost(d, st, Pricing{Estimate: PricingNever}); est != nil || SessionCost(d, est) != 9 {
+		t.Errorf("never mode returned estimate %v", est)
+	}
+	if !spendEq(st.Estimate.CostUSD, 4) {
+		t.Errorf("state estimate = %v, want 4", st.Estimate.CostUSD)
+	}
+	unknown := &StdinData{Model: Model{ID: "anthropic.titan"}, Cost: Cost{TotalCostUSD: 9}}
+	if est := EstimateCost(unknown, nil, Pricing{Estimate: PricingAlways}); est != nil {
+		t.Errorf("a model without a price should keep the reported cost, got estimate %v", *est)
+	}
+}
+
+func TestValidatePricing(t *testing.T) {
+	t.Parallel()
+
+	p := Pricing{
+		Estimate: " Always ",
+		Models: map[string]ModelPrice{
+			"good": {Input: 1, Output: 2},
+			"bad":  {Input: 1, Output: 2, LongCacheRead: -1},
+		},
+	}
+	if fixes := validatePricing(&p); len(fixes) != 1 || !strings.Contains(fixes[0], "pricing.models.bad") {
+		t.Errorf("fixes = %v, want one for pricing.models.bad", fixes)
+	}
+	if p.Estimate != PricingAlways || len(p.Models) != 1 {
+		t.Errorf("validated = %+v, want always with only the good entry", p)
+	}
+
+	p = Pricing{Estimate: "sometimes"}
+	if fixes := validatePricing(&p); len(fixes) != 1 || p.Estimate != PricingAuto {
+		t.Errorf("unknown mode: %+v, fixes %v; want auto with one fix", p, fixes)
+	}
+	p = Pricing{}
+	if fixes := validatePricing(&p); len(fixes) != 0 || p.Estimate != PricingAuto {
+		t.Errorf("empty mode: %+v, fixes %v; want auto silently", p, fixes)
+	}
+}
+
+func TestMergePricing(t *testing.T) {
+	t.Parallel()
+
+	base := Pricing{Estimate: PricingAlways, Models: map[string]ModelPrice{
+		"opus":   {Input: 10, Output: 50},
+		"sonnet": {Input: 2, Output: 10},
+	}}
+	override := Pricing{Models: map[string]ModelPrice{"sonnet": {Input: 1, Output: 5}}}
+	got := mergePricing(base, override)
+	if got.Estimate != PricingAlways {
+		t.Errorf("Estimate = %q, want the base mode kept", got.Estimate)
+	}
+	if got.Models["opus"].Input != 10 || got.Models["sonnet"].Input != 1 {
+		t.Errorf("Models = %+v, want base opus and overridden sonnet", got.Models)
+	}
+	if base.Models["sonnet"].Input != 2 {
+		t.Error("merge must not modify the base map")
+	}
+}
@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Rate-limit windows belong to the account, not the session, so every
+// session of an account feeds and reads one shared quota history under
+// ~/.claude/hud/quota/<account>.json. Its recent burn rate projects when each
+// window runs out; concurrent writers at worst drop a sample.
+const (
+	QuotaSampleInterval = 5 * time.Minute    // minimum spacing between stored samples
+	QuotaMaxAge         = 7 * 24 * time.Hour // histories idle this long are pruned
+	quotaMaxBytes       = 64 * 1024          // refuse to load larger history files
+	// The burn rate is measured over the last 1/quotaBurnShare of a window
+	// (30m of the 5h window, ~17h of the 7d one) and needs at least
+	// 1/quotaMinSpanShare of it (5m, ~3h) to be trusted.
+	quotaBurnShare    = 10
+	quotaMinSpanShare = 60
+	// Samples whose resets_at differ by less than this belong to the same
+	// window instance.
+	quotaResetTolerance = time.Minute
+)
+
+const (
+	fiveHourWindow = 5 * time.Hour
+	sevenDayWindow = 7 * 24 * time.Hour
+)
+
+// QuotaSample is one snapshot of an account's rate limits.
+type QuotaSample struct {
+	Time     int64            `json:"ts"` // Unix milliseconds
+	FiveHour *RateLimitWindow `json:"five_hour,omitempty"`
+	SevenDay *RateLimitWindow `json:"seven_day,omitempty"`
+}
+
+// QuotaHistory is the stored quota history of one account, oldest sample
+// first.
+type QuotaHistory struct {
+	Account string        `json:"account"` // QuotaAccountKey, never the email itself
+	Samples []QuotaSample `json:"samples"`
+}
+
+// QuotaDir returns ~/.claude/hud/quota, or "" when the home directory is
+// unknown.
+func QuotaDir() string {
+	return hudDir("quota")
+}
+
+// QuotaAccountKey names an account's quota history: its UUID when usable as
+// a file name, else a hash of its email, else "default".
+func QuotaAccountKey(a *AccountInfo) string {
+	if a == nil {
+		return "default"
+	}
+	if validSessionID(a.AccountUUID) {
+		return a.AccountUUID
+	}
+	if email := strings.ToLower(strings.TrimSpace(a.EmailAddress)); email != "" {
+		sum := sha256.Sum256([]byte(email))
+		return hex.EncodeToString(sum[:8])
+	}
+	return "default"
+}
+
+// add appends s and reports whether the history changed. Samples closer than
+// QuotaSampleInterval to the previous one are dropped unless a window reset
+// in between; samples older than the longest burn-rate span are trimmed.
+func (h *QuotaHistory) add(s QuotaSample) bool {
+	if n := len(h.Samples); n > 0 {
+		last := h.Samples[n-1]
+		if s.Time <= last.Time {
+			return false
+		}
+		if s.Time-last.Time < QuotaSampleInterval.Milliseconds() &&
+			sameQuotaWindow(s.FiveHour, last.FiveHour) && sameQuotaWindow(s.SevenDay, last.SevenDay) {
+			return false
+		}
+	}
+	h.Samples = append(h.Samples, s)
+	cutoff := s.Time - (sevenDayWindow / quotaBurnShare).Milliseconds()
+	i := 0
+	for i < len(h.Samples) && h.Samples[i].Time < cutoff {
+		i++
+	}
+	h.Samples = append(h.Samples[:0], h.Samples[i:]...)
+	return true
+}
+
+// sameQuotaWindow reports whether a and b are the same window instance (both
+// absent, or resetting at the same time).
+func sameQuotaWindow(a, b *RateLimitWindow) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	d := time.Duration(a.ResetsAt-b.ResetsAt) * time.Second
+	return d.Abs() < quotaResetTolerance
+}
+
+// LoadQuotaHistory reads the stored quota history of an account. A missing
+// file returns an empty history and no error.
+func LoadQuotaHistory(dir, key string) (*QuotaHistory, error) {
+	if !validSessionID(key) {
+		return nil, fmt.Errorf("invalid account key %q", key)
+	}
+	h := &QuotaHistory{Account: key}
+	if dir == "" {
+		return h, errors.New("quota dir unknown")
+	}
+	path := filepath.Join(dir, key+".json")
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	if info.Size() > quotaMaxBytes {
+		return h, fmt.Errorf("%s: quota history larger than %d bytes", path, quotaMaxBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		// A corrupt file is replaced by the next write.
+		return &QuotaHistory{Account: key}, fmt.Errorf("%s: %w", path, err)
+	}
+	h.Account = key
+	return h, nil
+}
+
+// RecordQuota adds the rate limits in rl to the account's shared history and
+// returns it. Creating a new account's file prunes histories untouched for
+// QuotaMaxAge. On error the returned history is still usable.
+func RecordQuota(dir, key string, rl *RateLimits, now time.Time) (*QuotaHistory, error) {
+	h, err := LoadQuotaHistory(dir, key)
+	if h == nil || rl == nil || (rl.FiveHour == nil && rl.SevenDay == nil) {
+		return h, err
+	}
+	if !h.add(QuotaSample{Time: now.UnixMilli(), FiveHour: rl.FiveHour, SevenDay: rl.SevenDay}) || dir == "" {
+		return h, err
+	}
+	data, mErr := json.Marshal(h)
+	if mErr != nil {
+		return h, mErr
+	}
+	existed, wErr := writeFileAtomic(dir, key+".json", data)
+	if wErr != nil {
+		return h, wErr
+	}
+	if !existed {
+		pruneStaleFiles(dir, now.Add(-QuotaMaxAge))
+	}
+	return h, nil
+}
+
+// ProjectQuota sets EmptyAt on each window of u that is projected to run out
+// before it resets. The burn rate comes from h's samples of the same window
+// over its recent share; without enough history it falls back to the
+// average rate since the window started.
+func ProjectQuota(u *UsageData, h *QuotaHistory, now time.Time) {
+	if u == nil {
+		return
+	}
+	project := func(w *UsageWindow, windowLen time.Duration, pick func(QuotaSample) *RateLimitWindow) {
+		if w == nil {
+			return
+		}
+		w.EmptyAt = time.Time{}
+		rate, ok := recentBurnRate(w, h, windowLen, pick, now)
+		if !ok {
+			rate, ok = averageBurnRate(w, windowLen, now)
+		}
+		if !ok || rate <= 0 || w.RemainingPercent <= 0 {
+			return
+		}
+		emptyAt := now.Add(time.Duration(w.RemainingPercent / rate * float64(time.Minute)))
+		if emptyAt.Before(w.ResetsAt) {
+			w.EmptyAt = emptyAt
+		}
+	}
+	project(u.FiveHour, fiveHourWindow, func(s QuotaSample) *RateLimitWindow { return s.FiveHour })
+	project(u.SevenDay, sevenDayWindow, func(s QuotaSample) *RateLimitWindow { return s.SevenDay })
+}
+
+// recentBurnRate is the used-percent growth per minute between the oldest
+// sample of w's window instance within the recent share of windowLen and
+// now. ok is false when the span is too short to trust.
+func recentBurnRate(w *UsageWindow, h *QuotaHistory, windowLen time.Duration, pick func(QuotaSample) *RateLimitWindow, now time.Time) (float64, bool) {
+	if h == nil || w.ResetsAt.IsZero() {
+		return 0, false
+	}
+	current := &RateLimitWindow{ResetsAt: w.ResetsAt.Unix()}
+	cutoff := now.Add(-windowLen / quotaBurnShare).UnixMilli()
+	for _, s := range h.Samples {
+		sw := pick(s)
+		if s.Time < cutoff || sw == nil || !sameQuotaWindow(sw, current) {
+			continue
+		}
+		span := now.Sub(time.UnixMilli(s.Time))
+		if span < windowLen/quotaMinSpanShare {
+			return 0, false
+		}
+		used := 100 - w.RemainingPercent
+		return (used - sw.UsedPercentage) / span.Minutes(), true
+	}
+	return 0, false
+}
+
+// averageBurnRate is the used-percent growth per minute since w's window
+// started, assuming even use: the single-snapshot estimate. ok is false in
+// the first 5% of the window, where it would be noise.
+func averageBurnRate(w *UsageWindow, windowLen time.Duration, now time.Time) (float64, bool) {
+	if w.ResetsAt.IsZero() {
+		return 0, false
+	}
+	timeToReset := w.ResetsAt.Sub(now)
+	elapsed := windowLen - timeToReset
+	if timeToReset <= 0 || elapsed < windowLen/20 {
+		return 0, false
+	}
+	return (100 - w.RemainingPercent) / elapsed.Minutes(), true
+}
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuotaAccountKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		account *AccountInfo
+		want    string
+	}{
+		{"uuid", &AccountInfo{AccountUUID: "0f1e2d3c-aaaa-bbbb", EmailAddress: "dev@example.com"}, "0f1e2d3c-aaaa-bbbb"},
+		{"unusable uuid falls back to email", &AccountInfo{AccountUUID: "../x", EmailAddress: "dev@example.com"}, QuotaAccountKey(&AccountInfo{EmailAddress: " DEV@example.com "})},
+		{"no account", nil, "default"},
+		{"empty account", &AccountInfo{}, "default"},
+	}
+	for _, tt := range tests {
+		if got := QuotaAccountKey(tt.account); got != tt.want {
+			t.Errorf("%s: QuotaAccountKey = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if key := QuotaAccountKey(&AccountInfo{EmailAddress: "dev@example.com"}); len(key) != 16 || !validSessionID(key) {
+		t.Errorf("email key = %q, want 16 hex characters", key)
+	}
+}
+
+func TestQuotaHistory_Add(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	reset := base.Add(3 * time.Hour).Unix()
+	sample := func(at time.Duration, used float64, resetsAt int64) QuotaSample {
+		return QuotaSample{Time: base.Add(at).UnixMilli(), FiveHour: &RateLimitWindow{UsedPercentage: used, ResetsAt: resetsAt}}
+	}
+	var h QuotaHistory
+	steps := []struct {
+		name    string
+		s       QuotaSample
+		changed bool
+	}{
+		{"first sample", sample(0, 10, reset), true},
+		{"within the interval", sample(2*time.Minute, 11, reset), false},
+		{"after the interval", sample(5*time.Minute, 12, reset), true},
+		{"clock went back", sample(time.Minute, 12, reset), false},
+		{"window reset inside the interval", sample(6*time.Minute, 0, reset+5*3600), true},
+	}
+	for _, step := range steps {
+		if got := h.add(step.s); got != step.changed {
+			t.Errorf("%s: changed = %v, want %v", step.name, got, step.changed)
+		}
+	}
+	if len(h.Samples) != 3 {
+		t.Fatalf("len(Samples) = %d, want 3", len(h.Samples))
+	}
+
+	// Samples older than the longest burn-rate span are trimmed.
+	h.add(sample(20*time.Hour, 5, reset+5*3600))
+	if len(h.Samples) != 1 {
+		t.Errorf("after a day: len(Samples) = %d, want 1", len(h.Samples))
+	}
+}
+
+func TestRecordQuota(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	rl := &RateLimits{FiveHour: &RateLimitWindow{UsedPercentage: 40, ResetsAt: now.Add(2 * time.Hour).Unix()}}
+
+	// Two sessions of one account feed the same history.
+	if _, err := RecordQuota(dir, "acct", rl, now); err != nil {
+		t.Fatalf("RecordQuota: %v", err)
+	}
+	rl.FiveHour.UsedPercentage = 46
+	h, err := RecordQuota(dir, "acct", rl, now.Add(10*time.Minute))
+	if err != nil {
+		t.Fatalf("RecordQuota: %v", err)
+	}
+	if len(h.Samples) != 2 {
+		t.Fatalf("len(Samples) = %d, want 2", len(h.Samples))
+	}
+	loaded, err := LoadQuotaHistory(dir, "acct")
+	if err != nil || len(loaded.Samples) != 2 || loaded.Samples[1].FiveHour.UsedPercentage != 46 {
+		t.Errorf("LoadQuotaHistory = %+v, %v; want both samples", loaded, err)
+	}
+
+	// Another account has its own history.
+	if other, _ := LoadQuotaHistory(dir, "other"); len(other.Samples) != 0 {
+		t.Errorf("other account has %d samples, want 0", len(other.Samples))
+	}
+
+	// No rate limits: nothing is written.
+	if h, err := RecordQuota(dir, "empty", nil, now); err != nil || len(h.Samples) != 0 {
+		t.Errorf("RecordQuota(nil) = %+v, %v", h, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "empty.json")); !os.IsNotExist(err) {
+		t.Error("RecordQuota(nil) created a file")
+	}
+
+	if _, err := RecordQuota(dir, "../escape", rl, now); err == nil {
+		t.Error("RecordQuota with an invalid key: want an error")
+	}
+}
+
+func TestLoadQuotaHistory_Corrupt(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "acct.json"), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	h, err := LoadQuotaHistory(dir, "acct")
+	if err == nil || h == nil || len(h.Samples) != 0 {
+		t.Errorf("LoadQuotaHistory(corrupt) = %+v, %v; want an empty history and an error", h, err)
+	}
+}
+
+func TestProjectQuota(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(2 * time.Hour)
+	history := func(ago time.Duration, used float64, resetsAt time.Time) *QuotaHistory {
+		return &QuotaHistory{Samples: []QuotaSample{{
+			Time:     now.Add(-ago).UnixMilli(),
+			FiveHour: &RateLimitWindow{UsedPercentage: used, ResetsAt: resetsAt.Unix()},
+		}}}
+	}
+	tests := []struct {
+		name      string
+		remaining float64
+		resetsAt  time.Time
+		h         *QuotaHistory
+		emptyIn   time.Duration // 0 means no projected exhaustion
+	}{
+		// 40% used over 3h is on pace to last the 2h left...
+		{"on average pace", 60, reset, nil, 0},
+		// ...but 20% in the last 20m runs out the remaining 60% in an hour.
+		{"recent burn from history", 60, reset, history(20*time.Minute, 20, reset), time.Hour},
+		{"idle recently", 60, reset, history(20*time.Minute, 40, reset), 0},
+		// 90% used over 3h: the remaining 10% lasts 20m.
+		{"single-snapshot fallback", 10, reset, nil, 20 * time.Minute},
+		// Samples from the previous window instance are ignored.
+		{"previous window falls back", 10, reset, history(20*time.Minute, 95, reset.Add(-5*time.Hour)), 20 * time.Minute},
+		// Samples older than 30m or newer than 5m are ignored.
+		{"stale sample falls back", 60, reset, history(45*time.Minute, 0, reset), 0},
+		{"too recent falls back", 60, reset, history(2*time.Minute, 59, reset), 0},
+		{"too early in the window", 95, now.Add(4*time.Hour + 59*time.Minute), nil, 0},
+		{"exhausted", 0, reset, nil, 0},
+		{"no reset time", 10, time.Time{}, nil, 0},
+	}
+	for _, tt := range tests {
+		u := &UsageData{FiveHour: &UsageWindow{RemainingPercent: tt.remaining, ResetsAt: tt.resetsAt}}
+		ProjectQuota(u, tt.h, now)
+		got := u.FiveHour.EmptyAt
+		switch {
+		case tt.emptyIn == 0 && !got.IsZero():
+			t.Errorf("%s: EmptyAt = %v, want none", tt.name, got)
+		case tt.emptyIn != 0 && (got.Sub(now)-tt.emptyIn).Abs() > time.Second:
+			t.Errorf("%s: empties in %v, want %v", tt.name, got.Sub(now), tt.emptyIn)
+		}
+	}
+
+	// The 7d window uses its own samples and span.
+	weekReset := now.Add(4 * 24 * time.Hour)
+	u := &UsageData{SevenDay: &UsageWindow{RemainingPercent: 50, ResetsAt: weekReset}}
+	h := &QuotaHistory{Samples: []QuotaSample{{
+		Time:     now.Add(-10 * time.Hour).UnixMilli(),
+		SevenDay: &RateLimitWindow{UsedPercentage: 30, ResetsAt: weekReset.Unix()},
+	}}}
+	ProjectQuota(u, h, now)
+	if got := u.SevenDay.EmptyAt.Sub(now); (got - 25*time.Hour).Abs() > time.Second {
+		t.Errorf("7d empties in %v, want 25h", got)
+	}
+	ProjectQuota(nil, h, now) // must not panic
+}
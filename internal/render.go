@@ -29,202 +29,39 @@ const (
 	grey    = "\033[38;5;245m"
 )
 
-// Render produces lines for the statusline display.
+// Render produces lines for the statusline display, arranged by rc.Config.Layout.
 // Normal mode: 2-4 lines (depending on active features). Danger mode (configurable, default 85%+): 2 dense lines.
 func Render(rc RenderContext) []string {
 	if rc.Config.Thresholds == (Thresholds{}) {
 		rc.Config.Thresholds = DefaultThresholds()
 	}
-	if rc.Metrics.ContextPercent >= rc.Config.Thresholds.ContextDanger {
+	if rc.Metrics.ContextPercent >= rc.Config.Thresholds.ContextDanger ||
+		budgetDanger(activeBudgets(rc.Data, rc.CostEstimate, rc.Ledger, rc.Config.Thresholds), rc.Config.Thresholds) {
 		return renderDangerMode(rc)
 	}
 	return renderNormalMode(rc)
 }
 
+// renderNormalMode renders the configured normal layout. The compact variant
+// is used when no quota bars will be shown, so the context bar moves onto L1.
 func renderNormalMode(rc RenderContext) []string {
-	d, m, git, usage, tools, account, cfg := rc.Data, rc.Metrics, rc.Git, rc.Usage, rc.Tools, rc.Account, rc.Config
-	t := cfg.Thresholds
-
-	// Determine if quota bars will be shown
-	hasQuotaBars := cfg.Features.Quota && usage != nil
-
-	// Line 1: model(+context size) | account | git | speed | cost | duration
-	// When no quota bars, context bar is inlined into L1 (minimal/developer compact)
-	line1 := make([]string, 0, 8)
-	if hasQuotaBars {
-		line1 = append(line1, renderModelBadge(d.Model, d.ContextWindow.ContextWindowSize))
-	} else {
-		line1 = append(line1, renderModelBadge(d.Model, 0))
-		line1 = append(line1, renderContextBar(m.ContextPercent, d.ContextWindow, t))
-	}
-	if cfg.Features.Account && account != nil && account.EmailAddress != "" {
-		line1 = append(line1, renderAccount(account))
-	}
-	if cfg.Features.Git && git != nil && git.Branch != "" {
-		line1 = append(line1, renderGitCompact(git))
-	}
-	if cfg.Features.OutputTokens {
-		if s := renderOutputTokens(d.ContextWindow.CurrentUsage); s != "" {
-			line1 = append(line1, s)
-		}
-	}
-	if costStr := renderCost(d.Cost.TotalCostUSD, t); costStr != "" {
-		line1 = append(line1, costStr)
-	}
-	line1 = append(line1, renderDuration(d.Cost.TotalDurationMS))
-
-	// Line 2: context bar | 5h quota bar | 7d quota bar (only when quota bars exist)
-	var line2 []string
-	if hasQuotaBars {
-		line2 = make([]string, 0, 3)
-		line2 = append(line2, renderContextBar(m.ContextPercent, d.ContextWindow, t))
-		if s := renderQuotaWindow(usage.FiveHour, "5h", t); s != "" {
-			line2 = append(line2, s)
-		}
-		if s := renderQuotaWindow(usage.SevenDay, "7d", t); s != "" {
-			line2 = append(line2, s)
-		}
-	}
-
-	// Line 3: line changes | cache | wait | cost velocity (+ vim/agent conditionally)
-	line3 := make([]string, 0, 7)
-	if cfg.Features.LineChanges {
-		if lines := renderLineChanges(d.Cost); lines != "" {
-			line3 = append(line3, lines)
-		}
-	}
-	if cfg.Features.CacheEfficiency && m.CacheEfficiency != nil && *m.CacheEfficiency > 0 {
-		line3 = append(line3, renderCacheEfficiencyLabeled(*m.CacheEfficiency, t, d.ContextWindow.CurrentUsage))
-	}
-	if cfg.Features.APIWaitRatio && m.APIWaitRatio != nil && *m.APIWaitRatio > 0 {
-		line3 = append(line3, renderAPIRatioLabeled(*m.APIWaitRatio, t))
-	}
-	if cfg.Features.CostVelocity && m.CostPerMinute != nil {
-		line3 = append(line3, renderCostVelocityLabeled(*m.CostPerMinute, t))
-	}
-	if cfg.Features.VimMode && d.Vim != nil && d.Vim.Mode != "" {
-		line3 = append(line3, renderVimCompact(d.Vim.Mode))
-	}
-	if cfg.Features.AgentName && d.Agent != nil && d.Agent.Name != "" {
-		line3 = append(line3, renderAgentCompact(d.Agent.Name))
-	}
-	if cfg.Features.Effort {
-		if s := renderEffort(d.Effort); s != "" {
-			line3 = append(line3, s)
-		}
-	}
-	if cfg.Features.Thinking {
-		if s := renderThinking(d.Thinking); s != "" {
-			line3 = append(line3, s)
-		}
-	}
-	if cfg.Features.SessionName {
-		if s := renderSessionName(d.SessionName); s != "" {
-			line3 = append(line3, s)
-		}
-	}
-	if cfg.Features.PullRequest {
-		if s := renderPR(d.PR); s != "" {
-			line3 = append(line3, s)
-		}
-	}
-	if cfg.Features.Worktree {
-		if s := renderWorktreeName(d.Worktree); s != "" {
-			line3 = append(line3, s)
-		}
-	}
-	if v := renderVersion(d.Version); v != "" {
-		line3 = append(line3, v)
-	}
-
-	// Line 4: tools and agents with shared width budget
-	line4 := make([]string, 0, 3)
-
-	// Pre-render agents to calculate remaining budget for tools
-	var agentStr string
-	if cfg.Features.Agents && tools != nil && len(tools.Agents) > 0 {
-		agentStr = renderAgents(tools.Agents)
+	layout := layoutOrDefault(rc.Config)
+	lines := layout.Normal
+	if !rc.Config.Features.Quota || rc.Usage == nil {
+		lines = layout.Compact
 	}
-
-	toolBudget := terminalColumns()
-	if agentStr != "" {
-		toolBudget -= visibleLen(agentStr) + 3 // 3 for " | " separator
-	}
-	if toolBudget < 20 {
-		toolBudget = 20
-	}
-
-	if cfg.Features.Tools && tools != nil && len(tools.Tools) > 0 {
-		line4 = append(line4, renderTools(tools.Tools, toolBudget))
-	}
-	if agentStr != "" {
-		line4 = append(line4, agentStr)
-	}
-
-	lines := []string{joinParts(line1)}
-	if len(line2) > 0 {
-		lines = append(lines, joinParts(line2))
-	}
-	if len(line3) > 0 {
-		lines = append(lines, joinParts(line3))
-	}
-	if len(line4) > 0 {
-		lines = append(lines, joinParts(line4))
-	}
-	return lines
+	return renderLayout(&rc, lines, false)
 }
 
+// renderDangerMode renders the danger layout. Feature toggles are ignored so
+// every segment in the layout shows whenever its data is present.
 func renderDangerMode(rc RenderContext) []string {
-	d, m, git, usage, t := rc.Data, rc.Metrics, rc.Git, rc.Usage, rc.Config.Thresholds
-	// L1: model | 🔴 danger context bar (remaining+ETA) | 5h quota bar | 7d quota bar
-	line1 := make([]string, 0, 4)
-	line1 = append(line1, renderModelBadge(d.Model, 0))
-	line1 = append(line1, renderContextBarDanger(m.ContextPercent, d.ContextWindow, d.Cost.TotalDurationMS, t))
-
-	if usage != nil {
-		if s := renderQuotaWindow(usage.FiveHour, "5h", t); s != "" {
-			line1 = append(line1, s)
-		}
-		if s := renderQuotaWindow(usage.SevenDay, "7d", t); s != "" {
-			line1 = append(line1, s)
-		}
-	}
-
-	// L2: workspace/git | Δchanges | In:XK Out:XK | C:X% | speed | $cost $cost/h | duration
-	line2 := make([]string, 0, 8)
-
-	if ws := renderWorkspace(d); ws != "" {
-		if git != nil && git.Branch != "" {
-			line2 = append(line2, ws+renderGitCompact(git))
-		} else {
-			line2 = append(line2, ws)
-		}
-	}
-
-	if lines := renderLineChanges(d.Cost); lines != "" {
-		line2 = append(line2, lines)
-	}
-
-	if cu := d.ContextWindow.CurrentUsage; cu != nil {
-		line2 = append(line2, renderTokenIO(cu))
-	}
-
-	if m.CacheEfficiency != nil {
-		line2 = append(line2, renderCacheEfficiencyCompact(*m.CacheEfficiency, t))
-	}
-
-	if costStr := renderCost(d.Cost.TotalCostUSD, t); costStr != "" {
-		costParts := costStr
-		if m.CostPerMinute != nil {
-			hourly := *m.CostPerMinute * 60
-			costParts += " " + fmt.Sprintf("%s$%.1f/h%s", yellow, hourly, Reset)
-		}
-		line2 = append(line2, costParts)
-	}
-
-	line2 = append(line2, renderDuration(d.Cost.TotalDurationMS))
+	return renderLayout(&rc, layoutOrDefault(rc.Config).Danger, true)
+}
 
-	return []string{joinParts(line1), joinParts(line2)}
+// layoutOrDefault fills modes missing from cfg.Layout with the preset's.
+func layoutOrDefault(cfg Config) Layout {
+	return mergeLayout(PresetLayout(cfg.Preset), cfg.Layout)
 }
 
 func renderModelBadge(m Model, contextSize int) string {
@@ -236,13 +73,9 @@ func renderModelBadge(m Model, contextSize int) string {
 		name = "?"
 	}
 
-	// Detect provider
 	suffix := ""
-	lowerID := strings.ToLower(m.ID)
-	if strings.Contains(lowerID, "anthropic.claude-") {
-		suffix = " BR" // Bedrock
-	} else if strings.Contains(lowerID, "publishers/anthropic") {
-		suffix = " VX" // Vertex
+	if p := modelProvider(m); p != "" {
+		suffix = " " + p
 	}
 
 	// Append context window size (only for large windows like 1M+)
@@ -265,7 +98,9 @@ func renderModelBadge(m Model, contextSize int) string {
 	return fmt.Sprintf("%s[%s%s]%s", color, name, suffix, Reset)
 }
 
-func renderContextBar(percent int, cw ContextWindow, t Thresholds) string {
+// renderContextBar renders the normal-mode context bar. eta (minutes until
+// full) is appended when non-nil; the caller decides when it is worth showing.
+func renderContextBar(percent int, cw ContextWindow, eta *float64, t Thresholds) string {
 	const width = 10
 	filled := min(width*percent/100, width)
 	bar := buildBar(filled, width)
@@ -287,11 +122,11 @@ func renderContextBar(percent int, cw ContextWindow, t Thresholds) string {
 	total := formatTokenCount(totalTokens)
 	used := fmt.Sprintf("%*s", len(total), formatTokenCount(usedTokens))
 
-	return fmt.Sprintf("%s%s%s%s %3d%% (%s/%s)", prefix, color, bar, Reset, percent, used, total)
+	return fmt.Sprintf("%s%s%s%s %3d%% (%s/%s%s)", prefix, color, bar, Reset, percent, used, total, formatETA(eta))
 }
 
 // renderContextBarDanger renders context bar with remaining tokens and ETA for danger mode.
-func renderContextBarDanger(percent int, cw ContextWindow, durationMS int64, t Thresholds) string {
+func renderContextBarDanger(percent int, cw ContextWindow, eta *float64, t Thresholds) string {
 	const width = 10
 	filled := min(width*percent/100, width)
 	bar := buildBar(filled, width)
@@ -304,29 +139,41 @@ func renderContextBarDanger(percent int, cw ContextWindow, durationMS int64, t T
 	usedTokens := totalTokens * percent / 100
 	remainTokens := totalTokens - usedTokens
 
-	// ETA: estimate minutes until context is full
-	eta := ""
+	return fmt.Sprintf("%s%s%s%s %3d%% (%s left%s)", prefix, color, bar, Reset, percent,
+		formatTokenCount(remainTokens), formatETA(eta))
+}
+
+// lifetimeContextETA extrapolates linearly from session start: the fallback
+// when no session history is available. Wrong after a compaction, which is
+// why calcContextETA is preferred.
+func lifetimeContextETA(percent int, durationMS int64) *float64 {
 	durationMin := float64(durationMS) / msPerMinute
-	if percent > 0 && durationMin > 0 {
-		remainPct := float64(100 - percent)
-		etaMin := remainPct * durationMin / float64(percent)
-		if etaMin < 1 {
-			eta = " ~<1m"
-		} else if etaMin < 60 {
-			eta = fmt.Sprintf(" ~%.0fm", etaMin)
-		} else {
-			h := int(etaMin) / 60
-			m := int(etaMin) % 60
-			if m == 0 {
-				eta = fmt.Sprintf(" ~%dh", h)
-			} else {
-				eta = fmt.Sprintf(" ~%dh%dm", h, m)
-			}
-		}
+	if percent <= 0 || durationMin <= 0 {
+		return nil
 	}
+	v := float64(100-percent) * durationMin / float64(percent)
+	return &v
+}
 
-	return fmt.Sprintf("%s%s%s%s %3d%% (%s left%s)", prefix, color, bar, Reset, percent,
-		formatTokenCount(remainTokens), eta)
+// formatETA renders minutes until the context is full as " ~12m", " ~1h5m"
+// or " ~<1m"; "" when eta is nil.
+func formatETA(eta *float64) string {
+	if eta == nil {
+		return ""
+	}
+	etaMin := *eta
+	if etaMin < 1 {
+		return " ~<1m"
+	}
+	if etaMin < 60 {
+		return fmt.Sprintf(" ~%.0fm", etaMin)
+	}
+	h := int(etaMin) / 60
+	m := int(etaMin) % 60
+	if m == 0 {
+		return fmt.Sprintf(" ~%dh", h)
+	}
+	return fmt.Sprintf(" ~%dh%dm", h, m)
 }
 
 // renderTokenIO renders only In/Out token counts (without cache, used in danger mode).
@@ -337,30 +184,16 @@ func renderTokenIO(cu *CurrentUsage) string {
 }
 
 func contextColor(p int, t Thresholds) string {
-	switch {
-	case p >= t.ContextDanger:
-		return boldRed
-	case p >= t.ContextWarning:
-		return orange
-	case p >= t.ContextModerate:
-		return yellow
-	default:
-		return green
-	}
+	return contextSeverity(p, t).color()
 }
 
 func renderCost(usd float64, t Thresholds) string {
 	if usd < 0.001 {
 		return ""
 	}
-	var color string
-	switch {
-	case usd >= t.SessionCostHigh:
-		color = boldRed
-	case usd >= t.SessionCostMedium:
-		color = yellow
-	default:
-		color = Reset
+	color := Reset
+	if sev := sessionCostSeverity(usd, t); sev != SeverityOK {
+		color = sev.color()
 	}
 	if usd < 10 {
 		return fmt.Sprintf("%s$%.2f%s", color, usd, Reset)
@@ -429,14 +262,7 @@ func renderLineChanges(c Cost) string {
 }
 
 func cacheColor(pct int, t Thresholds) string {
-	switch {
-	case pct >= t.CacheExcellent:
-		return green
-	case pct >= t.CacheGood:
-		return yellow
-	default:
-		return red
-	}
+	return cacheSeverity(pct, t).color()
 }
 
 func renderCacheEfficiencyCompact(pct int, t Thresholds) string {
@@ -454,14 +280,7 @@ func renderCacheEfficiencyLabeled(pct int, t Thresholds, cu *CurrentUsage) strin
 }
 
 func apiRatioColor(pct int, t Thresholds) string {
-	switch {
-	case pct >= t.WaitHigh:
-		return red
-	case pct >= t.WaitMedium:
-		return yellow
-	default:
-		return green
-	}
+	return apiWaitSeverity(pct, t).color()
 }
 
 func renderAPIRatioLabeled(pct int, t Thresholds) string {
@@ -469,16 +288,96 @@ func renderAPIRatioLabeled(pct int, t Thresholds) string {
 }
 
 func renderCostVelocityLabeled(perMin float64, t Thresholds) string {
-	var color string
+	color := costVelocitySeverity(perMin, t).color()
+	return fmt.Sprintf("%sCost:%s$%.2f/m%s", grey, color, perMin, Reset)
+}
+
+// renderCacheSavings shows what prompt caching saved over the session, green,
+// or red once cache writes cost more than reads saved, followed by the latest
+// call's share: "Saved:+$3.20(+$0.16/turn)". Either value may be nil.
+func renderCacheSavings(turn, total *float64) string {
+	s := grey + "Saved:"
+	if total != nil {
+		color := green
+		if *total < 0 {
+			color = red
+		}
+		s += color + formatSignedUSD(*total)
+	}
+	if turn != nil {
+		if total != nil {
+			s += grey + "(" + formatSignedUSD(*turn) + "/turn)"
+		} else {
+			s += formatSignedUSD(*turn) + "/turn"
+		}
+	}
+	return s + Reset
+}
+
+// formatSignedUSD formats dollars with an explicit sign: "+$0.16", "-$1.05",
+// "+$12.4".
+func formatSignedUSD(usd float64) string {
+	sign := "+"
+	if usd < 0 {
+		sign, usd = "-", -usd
+	}
+	if usd < 10 {
+		return fmt.Sprintf("%s$%.2f", sign, usd)
+	}
+	return fmt.Sprintf("%s$%.1f", sign, usd)
+}
+
+// renderVelocityAverage is the lifetime $/min shown after a windowed
+// velocity, e.g. "(avg:$0.12)".
+func renderVelocityAverage(perMin float64) string {
+	return fmt.Sprintf("%s(avg:$%.2f)%s", grey, perMin, Reset)
+}
+
+// renderThroughput shows windowed output tokens per minute, e.g. "Out:2K/m"
+// or "Out:640/m" below a thousand.
+func renderThroughput(perMin float64) string {
+	n := int(perMin + 0.5)
+	count := strconv.Itoa(n)
+	if n >= 1000 {
+		count = formatTokenCount(n)
+	}
+	return grey + "Out:" + count + "/m" + Reset
+}
+
+// renderLedgerTotal shows cross-session spend for a period, e.g.
+// "$12.40 today".
+func renderLedgerTotal(usd float64, period string) string {
+	return fmt.Sprintf("$%.2f%s %s%s", usd, grey, period, Reset)
+}
+
+// renderCompactions shows the session's compaction count and how long ago
+// the last one was, e.g. "⟲2 12m ago". A single compaction is grey; more turn
+// yellow, then red. ago < 0 means the time is unknown.
+func renderCompactions(n int, ago time.Duration) string {
+	color := grey
+	if sev := compactionSeverity(n); sev > SeverityOK {
+		color = sev.color()
+	}
+	s := fmt.Sprintf("%s⟲%d%s", color, n, Reset)
+	if ago >= 0 {
+		s += " " + grey + formatAgo(ago) + " ago" + Reset
+	}
+	return s
+}
+
+// formatAgo renders an elapsed time as "<1m", "12m" or "2h5m".
+func formatAgo(d time.Duration) string {
+	mins := int(d.Minutes())
 	switch {
-	case perMin >= t.CostVelocityHigh:
-		color = boldRed
-	case perMin >= t.CostVelocityMedium:
-		color = yellow
+	case mins < 1:
+		return "<1m"
+	case mins < 60:
+		return fmt.Sprintf("%dm", mins)
+	case mins%60 == 0:
+		return fmt.Sprintf("%dh", mins/60)
 	default:
-		color = green
+		return fmt.Sprintf("%dh%dm", mins/60, mins%60)
 	}
-	return fmt.Sprintf("%sCost:%s$%.2f/m%s", grey, color, perMin, Reset)
 }
 
 // renderOutputTokens shows the output token count of the current/last API
@@ -509,6 +408,21 @@ func renderVimCompact(mode string) string {
 	}
 }
 
+// renderConfigWarning flags a config that failed to load or was corrected
+// with a yellow ⚙!, or one with only unknown keys, features or segments (a
+// typo, ignored) with a dim ⚙?. `howl config validate` lists the details.
+func renderConfigWarning(problems []ConfigProblem) string {
+	if len(problems) == 0 {
+		return ""
+	}
+	for _, p := range problems {
+		if p.Kind != ProblemUnknown {
+			return boldYlw + "⚙!" + Reset
+		}
+	}
+	return grey + "⚙?" + Reset
+}
+
 func renderVersion(version string) string {
 	if version == "" {
 		return ""
@@ -590,6 +504,8 @@ func renderAgentCompact(name string) string {
 const (
 	maxToolNameLen   = 12
 	maxToolLineWidth = 80
+	maxTodosWidth    = 48 // TodoWrite progress including the active item
+	maxAgentsWidth   = 72 // running agent tree and counts
 )
 
 // visibleLen computes the display width of a string, excluding ANSI escape sequences.
@@ -624,7 +540,7 @@ func truncateToolName(name string, maxLen int) string {
 	return string(runes[:maxLen-1]) + "…"
 }
 
-func renderTools(tools map[string]int, maxWidth int) string {
+func renderTools(tools, errors map[string]int, maxWidth int) string {
 	if len(tools) == 0 {
 		return ""
 	}
@@ -649,6 +565,9 @@ func renderTools(tools map[string]int, maxWidth int) string {
 	for _, e := range entries {
 		name := truncateToolName(e.name, maxToolNameLen)
 		part := fmt.Sprintf("%s%s%s(%d)", blue, name, Reset, e.count)
+		if n := errors[e.name]; n > 0 {
+			part = fmt.Sprintf("%s%s%s(%d %s✗%d%s)", blue, name, Reset, e.count, red, n, Reset)
+		}
 
 		candidate := result
 		if shown > 0 {
@@ -674,23 +593,161 @@ func renderTools(tools map[string]int, maxWidth int) string {
 	return result
 }
 
-func renderAgents(agents []string) string {
-	if len(agents) == 0 {
+// renderToolErrors shows the share of the session's tool calls that failed,
+// e.g. "Fail:6%(3/47)". Returns "" without failures.
+func renderToolErrors(failed, calls int) string {
+	if failed <= 0 || calls <= 0 {
 		return ""
 	}
-	// Show first 2 running agents
-	display := agents
-	if len(display) > 2 {
-		display = display[:2]
+	rate := float64(failed) / float64(calls) * 100
+	return fmt.Sprintf("%sFail:%s%.0f%%%s%s(%d/%d)%s",
+		grey, toolErrorSeverity(rate).color(), rate, Reset, grey, failed, calls, Reset)
+}
+
+// renderErrorStreak warns that the latest streak tool calls all failed, e.g.
+// "✗4 in a row" — usually an agent stuck retrying. Returns "" below limit.
+func renderErrorStreak(streak, limit int) string {
+	if limit <= 0 || streak < limit {
+		return ""
 	}
+	return fmt.Sprintf("%s✗%d in a row%s", boldRed, streak, Reset)
+}
+
+// renderTodos shows TodoWrite progress and the item in progress, e.g.
+// "☑ 4/9 Running tests", truncating the item to fit maxWidth. The count turns
+// green once every item is done. Returns "" without a list.
+func renderTodos(p *TodoProgress, maxWidth int) string {
+	if p == nil || p.Total == 0 {
+		return ""
+	}
+	color := cyan
+	if p.Done == p.Total {
+		color = green
+	}
+	s := fmt.Sprintf("%s☑ %d/%d%s", color, p.Done, p.Total, Reset)
+	// Only worth showing with room for a few characters and the ellipsis.
+	if room := maxWidth - visibleLen(s) - 1; p.Active != "" && room >= 4 {
+		s += " " + truncateToolName(p.Active, room)
+	}
+	return s
+}
+
+// maxRunningSummaryLen caps the input summary of the in-flight tool.
+const maxRunningSummaryLen = 32
+
+// renderRunningTool shows the oldest tool call in flight and how long it has
+// run, e.g. "⏵ Bash 2m14s: npm test", with "+1" for more parallel calls. The
+// time turns yellow past 2 minutes and red past 10, where a call is likely
+// hung. Returns "" when nothing runs.
+func renderRunningTool(running []RunningTool, now time.Time) string {
+	if len(running) == 0 {
+		return ""
+	}
+	t := running[0]
+	s := fmt.Sprintf("%s⏵%s %s%s%s", yellow, Reset, blue, truncateToolName(t.Name, maxToolNameLen), Reset)
+	if t.Started != nil {
+		elapsed := max(now.Sub(*t.Started), 0)
+		color := grey
+		switch {
+		case elapsed >= 10*time.Minute:
+			color = red
+		case elapsed >= 2*time.Minute:
+			color = yellow
+		}
+		s += " " + color + formatElapsed(elapsed) + Reset
+	}
+	if t.Summary != "" {
+		s += grey + ": " + truncateToolName(t.Summary, maxRunningSummaryLen) + Reset
+	}
+	if len(running) > 1 {
+		s += fmt.Sprintf(" %s+%d%s", grey, len(running)-1, Reset)
+	}
+	return s
+}
+
+// formatElapsed renders a running time with seconds: "14s", "2m14s", "1h5m".
+func formatElapsed(d time.Duration) string {
+	secs := int(d.Seconds())
+	switch {
+	case secs < 60:
+		return fmt.Sprintf("%ds", secs)
+	case secs < 3600:
+		return fmt.Sprintf("%dm%02ds", secs/60, secs%60)
+	default:
+		return fmt.Sprintf("%dh%dm", secs/3600, secs%3600/60)
+	}
+}
+
+// renderAgents shows the running agents with their running time, nesting the
+// agents each launched in parentheses, then how many returned and failed:
+// "▶reviewer 4m12s(explore 38s) tester 1m05s ✓3 ✗1". Top-level agents that
+// do not fit maxWidth collapse to "+N"; the first always shows. Without
+// running agents only the counts remain ("Agents:✓3 ✗1").
+func renderAgents(agents []RunningAgent, done, failed int, now time.Time, maxWidth int) string {
+	var counts string
+	if done > 0 {
+		counts += fmt.Sprintf(" %s✓%d%s", green, done, Reset)
+	}
+	if failed > 0 {
+		counts += fmt.Sprintf(" %s✗%d%s", red, failed, Reset)
+	}
+	if len(agents) == 0 {
+		if counts == "" {
+			return ""
+		}
+		return grey + "Agents:" + Reset + counts[1:]
+	}
+
+	// Group the depth-first list into one subtree per top-level agent.
+	type subtree struct {
+		text   string
+		agents int
+	}
+	var trees []subtree
+	depth := 0
+	for _, a := range agents {
+		label := fmt.Sprintf("%s%s%s", cyan, a.Name, Reset)
+		if a.Started != nil {
+			label += " " + grey + formatElapsed(max(now.Sub(*a.Started), 0)) + Reset
+		}
+		switch {
+		case a.Depth == 0 || len(trees) == 0:
+			if len(trees) > 0 {
+				trees[len(trees)-1].text += strings.Repeat(")", depth)
+			}
+			trees = append(trees, subtree{text: label})
+			depth = 0
+			continue
+		case a.Depth > depth:
+			trees[len(trees)-1].text += "(" + label
+			depth++
+		default:
+			trees[len(trees)-1].text += strings.Repeat(")", depth-a.Depth) + ", " + label
+			depth = a.Depth
+		}
+		trees[len(trees)-1].agents++
+	}
+	trees[len(trees)-1].text += strings.Repeat(")", depth)
+
 	result := fmt.Sprintf("%s▶%s", yellow, Reset)
-	for i, a := range display {
+	budget := maxWidth - visibleLen(counts)
+	hidden := 0
+	for i, t := range trees {
+		candidate := result
 		if i > 0 {
-			result += ","
+			candidate += " "
+		}
+		candidate += t.text
+		if i > 0 && (hidden > 0 || visibleLen(candidate)+len(" +99") > budget) {
+			hidden += 1 + t.agents
+			continue
 		}
-		result += fmt.Sprintf("%s%s%s", cyan, a, Reset)
+		result = candidate
 	}
-	return result
+	if hidden > 0 {
+		result += fmt.Sprintf(" %s+%d%s", grey, hidden, Reset)
+	}
+	return result + counts
 }
 
 func formatCount(n int) string {
@@ -718,58 +775,44 @@ func renderQuotaWindow(w *UsageWindow, label string, t Thresholds) string {
 	if w == nil {
 		return ""
 	}
-	return renderQuotaBar(w.RemainingPercent, w.ResetsAt, label, t)
+	return renderQuotaBar(w, label, t, time.Now())
 }
 
-func renderQuotaBar(remainPct float64, resetTime time.Time, label string, t Thresholds) string {
+func renderQuotaBar(w *UsageWindow, label string, t Thresholds, now time.Time) string {
 	const width = 10
+	remainPct := w.RemainingPercent
 	filled := max(0, min(int(remainPct)*width/100, width))
 	bar := buildBar(filled, width)
 
 	color := quotaColor(remainPct, t)
-	now := time.Now()
 	var until string
-	var windowLen time.Duration
 	if label == "5h" {
-		until = formatTimeUntilWithMinutes(now, resetTime)
-		windowLen = 5 * time.Hour
+		until = formatTimeUntilWithMinutes(now, w.ResetsAt)
 	} else {
-		until = formatTimeUntil(now, resetTime)
-		windowLen = 7 * 24 * time.Hour
+		until = formatTimeUntil(now, w.ResetsAt)
 	}
 
-	marker := quotaPaceMarker(remainPct, resetTime, now, windowLen)
+	marker := quotaEmptyMarker(w.EmptyAt, now)
 	return fmt.Sprintf("%s%s%s %3.0f%% (%s/%s)%s", color, bar, Reset, remainPct, until, label, marker)
 }
 
-// quotaPaceMarker returns a "🔥" warning when the window is projected to be
-// exhausted before it resets — i.e. consumption is ahead of the even time-based
-// pace. Pure function of a single stdin snapshot (no history needed): it
-// compares the average burn rate so far against the rate that would last until
-// reset. Returns "" when on pace, already exhausted, or signal is too weak.
-func quotaPaceMarker(remainPct float64, resetsAt, now time.Time, windowLen time.Duration) string {
-	if resetsAt.IsZero() || remainPct <= 0 || windowLen <= 0 {
+// quotaEmptyMarker returns " ⏳1h20m" when the window is projected to run out
+// before it resets (EmptyAt set by ProjectQuota), or "" otherwise.
+func quotaEmptyMarker(emptyAt, now time.Time) string {
+	if emptyAt.IsZero() {
 		return ""
 	}
-	timeToReset := resetsAt.Sub(now)
-	if timeToReset <= 0 {
-		return ""
-	}
-	elapsed := windowLen - timeToReset
-	if elapsed < windowLen/20 { // need >=5% elapsed to project meaningfully
-		return ""
-	}
-	used := 100 - remainPct
-	if used <= 0 {
-		return ""
-	}
-	// Ahead of pace iff time-to-exhaust < time-to-reset. Cross-multiplied to
-	// avoid float-duration construction: remainPct/burn < timeToReset, where
-	// burn = used/elapsed  =>  remainPct*elapsed < used*timeToReset.
-	if remainPct*elapsed.Seconds() < used*timeToReset.Seconds() {
-		return boldRed + "🔥" + Reset
+	return " " + boldRed + "⏳" + formatEmptyIn(emptyAt.Sub(now)) + Reset
+}
+
+// formatEmptyIn formats the time left until a quota runs out: "<1m", "38m",
+// "1h20m", or "2d5h" beyond a day.
+func formatEmptyIn(d time.Duration) string {
+	if d < 24*time.Hour {
+		return formatAgo(d)
 	}
-	return ""
+	hours := int(d.Hours())
+	return fmt.Sprintf("%dd%dh", hours/24, hours%24)
 }
 
 func formatTimeUntil(now, target time.Time) string {
@@ -804,18 +847,7 @@ func formatTimeUntilWith(now, target time.Time, includeMinutes bool) string {
 }
 
 func quotaColor(remaining float64, t Thresholds) string {
-	switch {
-	case remaining < t.QuotaCritical:
-		return boldRed
-	case remaining < t.QuotaLow:
-		return red
-	case remaining < t.QuotaMedium:
-		return orange
-	case remaining < t.QuotaHigh:
-		return yellow
-	default:
-		return green
-	}
+	return quotaSeverity(remaining, t).color()
 }
 
 // buildBar creates a fixed-width progress bar with filled (█) and empty (░) characters.
@@ -547,6 +547,99 @@ func TestRenderAPIRatioLabeled(t *testing.T) {
 	}
 }
 
+func TestFormatETA(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		eta  *float64
+		want string
+	}{
+		{nil, ""},
+		{floatPtr(0.4), " ~<1m"},
+		{floatPtr(12.4), " ~12m"},
+		{floatPtr(120), " ~2h"},
+		{floatPtr(125), " ~2h5m"},
+	}
+	for _, tt := range tests {
+		if got := formatETA(tt.eta); got != tt.want {
+			t.Errorf("formatETA(%s) = %q, want %q", ptrFloatToString(tt.eta), got, tt.want)
+		}
+	}
+}
+
+func TestLifetimeContextETA(t *testing.T) {
+	t.Parallel()
+
+	// 80% in 40 minutes → 20% more takes 10 minutes.
+	if got := lifetimeContextETA(80, 40*msPerMinute); !floatPtrEq(got, floatPtr(10)) {
+		t.Errorf("lifetimeContextETA(80, 40m) = %s, want 10", ptrFloatToString(got))
+	}
+	if got := lifetimeContextETA(0, 40*msPerMinute); got != nil {
+		t.Errorf("zero percent should have no ETA, got %s", ptrFloatToString(got))
+	}
+	if got := lifetimeContextETA(80, 0); got != nil {
+		t.Errorf("zero duration should have no ETA, got %s", ptrFloatToString(got))
+	}
+}
+
+func TestRenderThroughput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		perMin float64
+		want   string
+	}{
+		{0, grey + "Out:0/m" + Reset},
+		{639.6, grey + "Out:640/m" + Reset},
+		{12400, grey + "Out:12K/m" + Reset},
+	}
+	for _, tt := range tests {
+		if got := renderThroughput(tt.perMin); got != tt.want {
+			t.Errorf("renderThroughput(%v) = %q, want %q", tt.perMin, got, tt.want)
+		}
+	}
+}
+
+func TestRenderCompactions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n    int
+		ago  time.Duration
+		want string
+	}{
+		{1, -1, grey + "⟲1" + Reset},
+		{1, 12 * time.Minute, grey + "⟲1" + Reset + " " + grey + "12m ago" + Reset},
+		{2, 30 * time.Second, yellow + "⟲2" + Reset + " " + grey + "<1m ago" + Reset},
+		{4, 125 * time.Minute, red + "⟲4" + Reset + " " + grey + "2h5m ago" + Reset},
+	}
+	for _, tt := range tests {
+		if got := renderCompactions(tt.n, tt.ago); got != tt.want {
+			t.Errorf("renderCompactions(%d, %v) = %q, want %q", tt.n, tt.ago, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAgo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "<1m"},
+		{59 * time.Second, "<1m"},
+		{47 * time.Minute, "47m"},
+		{2 * time.Hour, "2h"},
+		{134 * time.Minute, "2h14m"},
+	}
+	for _, tt := range tests {
+		if got := formatAgo(tt.d); got != tt.want {
+			t.Errorf("formatAgo(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
 func TestRenderCostVelocityLabeled(t *testing.T) {
 	t.Parallel()
 
@@ -623,7 +716,7 @@ func TestRenderTools(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := renderTools(tt.tools, tt.maxWidth)
+			got := renderTools(tt.tools, nil, tt.maxWidth)
 			if tt.wantEmpty {
 				if got != "" {
 					t.Errorf("renderTools(%v, %d) = %q, want empty", tt.tools, tt.maxWidth, got)
@@ -699,39 +792,49 @@ func TestTruncateToolName(t *testing.T) {
 func TestRenderAgents(t *testing.T) {
 	t.Parallel()
 
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) *time.Time {
+		ts := now.Add(-ago)
+		return &ts
+	}
+	plain := strings.NewReplacer(yellow, "", cyan, "", grey, "", green, "", red, "", Reset, "")
 	tests := []struct {
-		name      string
-		agents    []string
-		wantEmpty bool
-		wantParts []string
-		wantMiss  []string
+		name         string
+		agents       []RunningAgent
+		done, failed int
+		maxWidth     int
+		want         string // visible text
 	}{
-		{"nil", nil, true, nil, nil},
-		{"empty", []string{}, true, nil, nil},
-		{"single agent", []string{"code-writer"}, false, []string{"code-writer"}, nil},
-		{"three agents shows only first 2", []string{"a", "b", "c"}, false, []string{"a", "b"}, []string{"c"}},
+		{"nothing", nil, 0, 0, 72, ""},
+		{"single agent", []RunningAgent{{Name: "code-writer"}}, 0, 0, 72, "▶code-writer"},
+		{"elapsed", []RunningAgent{{Name: "tester", Started: at(65 * time.Second)}}, 0, 0, 72, "▶tester 1m05s"},
+		{"no cap on the agent count", []RunningAgent{{Name: "a"}, {Name: "b"}, {Name: "c"}}, 0, 0, 72, "▶a b c"},
+		{
+			"nested tree",
+			[]RunningAgent{
+				{Name: "reviewer", Started: at(4*time.Minute + 12*time.Second)},
+				{Name: "explore", Depth: 1, Started: at(38 * time.Second)},
+				{Name: "grep-bot", Depth: 2},
+				{Name: "lint", Depth: 1},
+				{Name: "tester"},
+			},
+			3, 1, 72,
+			"▶reviewer 4m12s(explore 38s(grep-bot), lint) tester ✓3 ✗1",
+		},
+		{"counts only", nil, 3, 0, 72, "Agents:✓3"},
+		{"name shown whole", []RunningAgent{{Name: "Investigate the flaky test"}}, 0, 0, 72, "▶Investigate the flaky test"},
+		{
+			"collapsed past the width",
+			[]RunningAgent{{Name: "first-agent"}, {Name: "second-agent"}, {Name: "child", Depth: 1}, {Name: "third"}},
+			0, 0, 24,
+			"▶first-agent +3",
+		},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := renderAgents(tt.agents)
-			if tt.wantEmpty {
-				if got != "" {
-					t.Errorf("renderAgents(%v) = %q, want empty", tt.agents, got)
-				}
-				return
-			}
-			for _, part := range tt.wantParts {
-				if !strings.Contains(got, part) {
-					t.Errorf("renderAgents(%v) missing %q in %q", tt.agents, part, got)
-				}
-			}
-			for _, miss := range tt.wantMiss {
-				if strings.Contains(got, miss) {
-					t.Errorf("renderAgents(%v) should not contain %q in %q", tt.agents, miss, got)
-				}
-			}
-		})
+		got := plain.Replace(renderAgents(tt.agents, tt.done, tt.failed, now, tt.maxWidth))
+		if got != tt.want {
+			t.Errorf("%s: renderAgents = %q, want %q", tt.name, got, tt.want)
+		}
 	}
 }
 
@@ -1179,7 +1282,7 @@ func TestRenderContextBar(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cw := ContextWindow{ContextWindowSize: tt.cwSize}
-			got := renderContextBar(tt.percent, cw, DefaultThresholds())
+			got := renderContextBar(tt.percent, cw, nil, DefaultThresholds())
 			for _, want := range tt.wantIn {
 				if !strings.Contains(got, want) {
 					t.Errorf("renderContextBar(%d, %d) missing %q in %q", tt.percent, tt.cwSize, want, got)
@@ -1236,7 +1339,8 @@ func TestRenderNormalMode_WithAgents(t *testing.T) {
 	}
 	m := Metrics{ContextPercent: 50}
 	tools := &ToolInfo{
-		Agents: []string{"researcher", "coder"},
+		Agents:    []string{"researcher", "coder"},
+		AgentTree: []RunningAgent{{Name: "researcher"}, {Name: "coder"}},
 	}
 	cfg := Config{
 		Features: FeatureToggles{Agents: true},
@@ -1364,8 +1468,9 @@ func TestRenderNormalMode_AllLinesPresent(t *testing.T) {
 	}
 	git := &GitInfo{Branch: "main", Dirty: true}
 	tools := &ToolInfo{
-		Tools:  map[string]int{"Read": 15, "Write": 8},
-		Agents: []string{"coder", "tester"},
+		Tools:     map[string]int{"Read": 15, "Write": 8},
+		Agents:    []string{"coder", "tester"},
+		AgentTree: []RunningAgent{{Name: "coder"}, {Name: "tester"}},
 	}
 	account := &AccountInfo{EmailAddress: "test@example.com"}
 	usage := &UsageData{FiveHour: &UsageWindow{RemainingPercent: 60.0}}
@@ -1946,7 +2051,7 @@ func TestRenderContextBar_CustomThresholdIcons(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got := renderContextBar(tt.percent, cw, custom)
+			got := renderContextBar(tt.percent, cw, nil, custom)
 			if tt.wantIcon != "" && !strings.Contains(got, tt.wantIcon) {
 				t.Errorf("renderContextBar(%d, custom) missing icon %q in %q", tt.percent, tt.wantIcon, got)
 			}
@@ -1988,53 +2093,193 @@ func TestRenderOutputTokens(t *testing.T) {
 	})
 }
 
-func TestQuotaPaceMarker(t *testing.T) {
+func TestRenderQuotaBar_EmptyMarker(t *testing.T) {
 	t.Parallel()
 
 	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
-	windowLen := 5 * time.Hour
+	tests := []struct {
+		name    string
+		emptyAt time.Time
+		want    string // "" means no marker
+	}{
+		{"lasts until reset", time.Time{}, ""},
+		{"minutes", now.Add(38 * time.Minute), "⏳38m"},
+		{"hours and minutes", now.Add(80 * time.Minute), "⏳1h20m"},
+		{"days", now.Add(53 * time.Hour), "⏳2d5h"},
+	}
+	for _, tt := range tests {
+		w := &UsageWindow{RemainingPercent: 41, ResetsAt: now.Add(3 * time.Hour), EmptyAt: tt.emptyAt}
+		got := renderQuotaBar(w, "5h", DefaultThresholds(), now)
+		if !strings.Contains(got, "41% (3h00m/5h)") {
+			t.Errorf("%s: renderQuotaBar = %q, want percent and reset time", tt.name, got)
+		}
+		if tt.want == "" && strings.Contains(got, "⏳") {
+			t.Errorf("%s: renderQuotaBar = %q, want no marker", tt.name, got)
+		}
+		if tt.want != "" && !strings.Contains(got, tt.want) {
+			t.Errorf("%s: renderQuotaBar = %q, want to contain %q", tt.name, got, tt.want)
+		}
+	}
+}
 
-	t.Run("zero resetsAt returns empty", func(t *testing.T) {
-		t.Parallel()
-		got := quotaPaceMarker(80, time.Time{}, now, windowLen)
-		if got != "" {
-			t.Errorf("quotaPaceMarker(zero resetsAt) = %q, want empty", got)
+func TestRenderCacheSavings(t *testing.T) {
+	t.Parallel()
+
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name        string
+		turn, total *float64
+		want        string
+	}{
+		{"both", f(0.16), f(3.2), grey + "Saved:" + green + "+$3.20" + grey + "(+$0.16/turn)" + Reset},
+		{"net loss", f(-0.05), f(-1.05), grey + "Saved:" + red + "-$1.05" + grey + "(-$0.05/turn)" + Reset},
+		{"large total", nil, f(12.44), grey + "Saved:" + green + "+$12.4" + Reset},
+		{"turn only", f(0.16), nil, grey + "Saved:+$0.16/turn" + Reset},
+	}
+	for _, tt := range tests {
+		if got := renderCacheSavings(tt.turn, tt.total); got != tt.want {
+			t.Errorf("%s: renderCacheSavings = %q, want %q", tt.name, got, tt.want)
 		}
-	})
+	}
+}
 
-	t.Run("on pace returns empty", func(t *testing.T) {
-		t.Parallel()
-		// 5h window, resetsAt = now+4h => elapsed=1h, remainPct=90, used=10
-		// remainPct*elapsed = 90*3600 = 324000
-		// used*ttr = 10*14400 = 144000
-		// 324000 < 144000 is false => on pace => ""
-		resetsAt := now.Add(4 * time.Hour)
-		got := quotaPaceMarker(90, resetsAt, now, windowLen)
-		if got != "" {
-			t.Errorf("quotaPaceMarker(on pace) = %q, want empty", got)
+func TestRenderToolErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		failed, calls int
+		want          string // "" means empty output
+		color         string
+	}{
+		{0, 40, "", ""},
+		{0, 0, "", ""},
+		{1, 50, "Fail:" + green + "2%", green},
+		{3, 47, "(3/47)", yellow},
+		{8, 40, "20%", orange},
+		{12, 30, "40%", red},
+	}
+	for _, tt := range tests {
+		got := renderToolErrors(tt.failed, tt.calls)
+		if tt.want == "" {
+			if got != "" {
+				t.Errorf("renderToolErrors(%d, %d) = %q, want empty", tt.failed, tt.calls, got)
+			}
+			continue
 		}
-	})
+		if !strings.Contains(got, tt.want) || !strings.Contains(got, tt.color) {
+			t.Errorf("renderToolErrors(%d, %d) = %q, want %q in the right color", tt.failed, tt.calls, got, tt.want)
+		}
+	}
+}
 
-	t.Run("ahead of pace returns fire emoji", func(t *testing.T) {
-		t.Parallel()
-		// 5h window, resetsAt = now+1h => elapsed=4h, remainPct=10, used=90
-		// remainPct*elapsed = 10*14400 = 144000
-		// used*ttr = 90*3600 = 324000
-		// 144000 < 324000 is true => ahead of pace => 🔥
-		resetsAt := now.Add(1 * time.Hour)
-		got := quotaPaceMarker(10, resetsAt, now, windowLen)
-		if !strings.Contains(got, "🔥") {
-			t.Errorf("quotaPaceMarker(ahead of pace) = %q, want to contain '🔥'", got)
+func TestRenderErrorStreak(t *testing.T) {
+	t.Parallel()
+
+	if got := renderErrorStreak(2, 3); got != "" {
+		t.Errorf("below the limit: %q, want empty", got)
+	}
+	if got := renderErrorStreak(3, 3); !strings.Contains(got, "✗3 in a row") || !strings.Contains(got, boldRed) {
+		t.Errorf("at the limit: %q, want a bold red warning", got)
+	}
+}
+
+func TestRenderTodos(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		p        *TodoProgress
+		maxWidth int
+		want     string // visible text
+		color    string
+	}{
+		{"no list", nil, 48, "", ""},
+		{"empty list", &TodoProgress{}, 48, "", ""},
+		{"in progress", &TodoProgress{Done: 4, Total: 9, Active: "Running tests"}, 48, "☑ 4/9 Running tests", cyan},
+		{"truncated", &TodoProgress{Done: 4, Total: 9, Active: "Running the integration tests"}, 20, "☑ 4/9 Running the i…", cyan},
+		{"no room for the item", &TodoProgress{Done: 4, Total: 9, Active: "Running tests"}, 8, "☑ 4/9", cyan},
+		{"all done", &TodoProgress{Done: 9, Total: 9}, 48, "☑ 9/9", green},
+	}
+	plain := strings.NewReplacer(cyan, "", green, "", Reset, "")
+	for _, tt := range tests {
+		got := renderTodos(tt.p, tt.maxWidth)
+		if plain := plain.Replace(got); plain != tt.want {
+			t.Errorf("%s: renderTodos = %q, want %q", tt.name, plain, tt.want)
 		}
-	})
+		if tt.color != "" && !strings.HasPrefix(got, tt.color) {
+			t.Errorf("%s: %q, want the count in %q", tt.name, got, tt.color)
+		}
+	}
+}
 
-	t.Run("too early guard returns empty", func(t *testing.T) {
-		t.Parallel()
-		// 5h window, resetsAt = now+4h59m => elapsed=1m < windowLen/20=15m => ""
-		resetsAt := now.Add(4*time.Hour + 59*time.Minute)
-		got := quotaPaceMarker(95, resetsAt, now, windowLen)
-		if got != "" {
-			t.Errorf("quotaPaceMarker(too early) = %q, want empty", got)
+func TestRenderRunningTool(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) *time.Time {
+		ts := now.Add(-ago)
+		return &ts
+	}
+	tests := []struct {
+		name    string
+		running []RunningTool
+		want    []string
+		color   string
+	}{
+		{"nothing running", nil, nil, ""},
+		{"just started", []RunningTool{{Name: "Bash", Summary: "npm test", Started: at(14 * time.Second)}}, []string{"⏵", "Bash", "14s", ": npm test"}, grey},
+		{"slow", []RunningTool{{Name: "Bash", Summary: "npm test", Started: at(2*time.Minute + 14*time.Second)}}, []string{"2m14s"}, yellow},
+		{"likely hung", []RunningTool{{Name: "Bash", Started: at(12 * time.Minute)}}, []string{"12m00s"}, red},
+		{"parallel calls", []RunningTool{{Name: "Read", Started: at(time.Second)}, {Name: "Grep"}}, []string{"Read", "+1"}, grey},
+		{"no timestamp", []RunningTool{{Name: "Read", Summary: "app.ts"}}, []string{"Read", ": app.ts"}, ""},
+	}
+	for _, tt := range tests {
+		got := renderRunningTool(tt.running, now)
+		if tt.want == nil {
+			if got != "" {
+				t.Errorf("%s: got %q, want empty", tt.name, got)
+			}
+			continue
 		}
-	})
+		for _, w := range tt.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: %q missing %q", tt.name, got, w)
+			}
+		}
+		if tt.color != "" && !strings.Contains(got, tt.color+formatElapsed(now.Sub(*tt.running[0].Started))) {
+			t.Errorf("%s: %q, want the elapsed time in %q", tt.name, got, tt.color)
+		}
+	}
+
+	long := renderRunningTool([]RunningTool{{Name: "Bash", Summary: strings.Repeat("x", 60)}}, now)
+	if visibleLen(long) > len("⏵ Bash: ")+maxRunningSummaryLen {
+		t.Errorf("summary not truncated: %q", long)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{59 * time.Second, "59s"},
+		{2*time.Minute + 4*time.Second, "2m04s"},
+		{time.Hour + 5*time.Minute + 30*time.Second, "1h5m"},
+	}
+	for _, tt := range tests {
+		if got := formatElapsed(tt.d); got != tt.want {
+			t.Errorf("formatElapsed(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestRenderTools_Errors(t *testing.T) {
+	t.Parallel()
+
+	if got := renderTools(map[string]int{"Bash": 12, "Read": 4}, map[string]int{"Bash": 3}, 80); !strings.Contains(got, "(12 "+red+"✗3") || strings.Contains(got, "(4 ") {
+		t.Errorf("renderTools with errors = %q, want Bash(12 ✗3) and plain Read(4)", got)
+	}
 }
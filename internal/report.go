@@ -0,0 +1,164 @@
+package internal
+
+import "time"
+
+// StatusReport is the machine-readable form of one statusline refresh,
+// printed by `howl --format json`. It carries the same inputs and derived
+// values Render uses, so scripts never need to parse ANSI output.
+type StatusReport struct {
+	Mode           string          `json:"mode"` // "normal" or "danger"
+	Session        ReportSession   `json:"session"`
+	Metrics        Metrics         `json:"metrics"`
+	Severity       MetricSeverity  `json:"severity"`
+	Git            *GitInfo        `json:"git"`
+	Usage          *ReportUsage    `json:"usage"`
+	Tools          *ToolInfo       `json:"tools"`
+	Ledger         *LedgerTotals   `json:"ledger"`  // null unless the ledger feature or a day/month budget is on
+	Budgets        []Budget        `json:"budgets"` // configured budgets with data to compare against
+	Thresholds     Thresholds      `json:"thresholds"`
+	ConfigProblems []ConfigProblem `json:"config_problems"`
+}
+
+// ReportSession is the subset of StdinData worth exporting: identity,
+// model, workspace, cost and context window.
+type ReportSession struct {
+	SessionID         string        `json:"session_id"`
+	SessionName       string        `json:"session_name"`
+	Version           string        `json:"version"`
+	CWD               string        `json:"cwd"`
+	TranscriptPath    string        `json:"transcript_path"`
+	Model             Model         `json:"model"`
+	Workspace         Workspace     `json:"workspace"`
+	Cost              Cost          `json:"cost"` // as reported by Claude Code
+	CostEstimated     bool          `json:"cost_estimated"`
+	EstimatedCostUSD  *float64      `json:"estimated_cost_usd"` // used in place of cost.total_cost_usd; null when not estimated
+	ContextWindow     ContextWindow `json:"context_window"`
+	Exceeds200KTokens bool          `json:"exceeds_200k_tokens"`
+}
+
+// MetricSeverity is the level each metric maps to under the current
+// Thresholds (the color it renders in). Nil when the metric is absent.
+type MetricSeverity struct {
+	Context         Severity  `json:"context"`
+	SessionCost     Severity  `json:"session_cost"`
+	CacheEfficiency *Severity `json:"cache_efficiency"`
+	APIWaitRatio    *Severity `json:"api_wait_ratio"`
+	CostVelocity    *Severity `json:"cost_velocity"` // windowed rate when history allows
+	Compactions     *Severity `json:"compactions"`
+	ToolErrors      *Severity `json:"tool_errors"` // share of tool calls that failed
+	Quota5h         *Severity `json:"quota_5h"`
+	Quota7d         *Severity `json:"quota_7d"`
+}
+
+// ReportUsage is UsageData with absolute reset times.
+type ReportUsage struct {
+	FiveHour *ReportQuotaWindow `json:"five_hour"`
+	SevenDay *ReportQuotaWindow `json:"seven_day"`
+}
+
+// ReportQuotaWindow is one rate-limit window.
+type ReportQuotaWindow struct {
+	RemainingPercent float64   `json:"remaining_percent"`
+	ResetsAt         time.Time `json:"resets_at"`         // RFC 3339
+	ResetsInSeconds  int64     `json:"resets_in_seconds"` // relative to the report time, >= 0
+	// EmptiesAt is when the window is projected to run out before it
+	// resets; null when it is projected to last.
+	EmptiesAt        *time.Time `json:"empties_at"`
+	EmptiesInSeconds *int64     `json:"empties_in_seconds"`
+}
+
+// BuildStatusReport assembles the JSON report from the same RenderContext
+// passed to Render. now anchors the relative reset countdowns.
+func BuildStatusReport(rc RenderContext, now time.Time) StatusReport {
+	t := rc.Config.Thresholds
+	if t == (Thresholds{}) {
+		t = DefaultThresholds()
+	}
+	d, m := rc.Data, rc.Metrics
+
+	r := StatusReport{
+		Mode: "normal",
+		Session: ReportSession{
+			SessionID:         d.SessionID,
+			SessionName:       d.SessionName,
+			Version:           d.Version,
+			CWD:               d.CWD,
+			TranscriptPath:    d.TranscriptPath,
+			Model:             d.Model,
+			Workspace:         d.Workspace,
+			Cost:              d.Cost,
+			CostEstimated:     rc.CostEstimate != nil,
+			EstimatedCostUSD:  rc.CostEstimate,
+			ContextWindow:     d.ContextWindow,
+			Exceeds200KTokens: d.Exceeds200KTokens,
+		},
+		Metrics:        m,
+		Git:            rc.Git,
+		Tools:          rc.Tools,
+		Ledger:         rc.Ledger,
+		Thresholds:     t,
+		ConfigProblems: rc.ConfigProblems,
+	}
+	r.Budgets = activeBudgets(d, rc.CostEstimate, rc.Ledger, t)
+	if m.ContextPercent >= t.ContextDanger || budgetDanger(r.Budgets, t) {
+		r.Mode = "danger"
+	}
+
+	r.Severity = MetricSeverity{
+		Context:     contextSeverity(m.ContextPercent, t),
+		SessionCost: sessionCostSeverity(SessionCost(d, rc.CostEstimate), t),
+	}
+	if m.CacheEfficiency != nil {
+		s := cacheSeverity(*m.CacheEfficiency, t)
+		r.Severity.CacheEfficiency = &s
+	}
+	if m.APIWaitRatio != nil {
+		s := apiWaitSeverity(*m.APIWaitRatio, t)
+		r.Severity.APIWaitRatio = &s
+	}
+	if v := m.costVelocity(); v != nil {
+		s := costVelocitySeverity(*v, t)
+		r.Severity.CostVelocity = &s
+	}
+	if m.Compactions > 0 {
+		s := compactionSeverity(m.Compactions)
+		r.Severity.Compactions = &s
+	}
+	if ti := rc.Tools; ti != nil && ti.Calls > 0 {
+		s := toolErrorSeverity(float64(ti.Failed) / float64(ti.Calls) * 100)
+		r.Severity.ToolErrors = &s
+	}
+
+	if u := rc.Usage; u != nil {
+		r.Usage = &ReportUsage{
+			FiveHour: reportQuotaWindow(u.FiveHour, now),
+			SevenDay: reportQuotaWindow(u.SevenDay, now),
+		}
+		if u.FiveHour != nil {
+			s := quotaSeverity(u.FiveHour.RemainingPercent, t)
+			r.Severity.Quota5h = &s
+		}
+		if u.SevenDay != nil {
+			s := quotaSeverity(u.SevenDay.RemainingPercent, t)
+			r.Severity.Quota7d = &s
+		}
+	}
+	return r
+}
+
+func reportQuotaWindow(w *UsageWindow, now time.Time) *ReportQuotaWindow {
+	if w == nil {
+		return nil
+	}
+	r := &ReportQuotaWindow{
+		RemainingPercent: w.RemainingPercent,
+		ResetsAt:         w.ResetsAt.UTC(),
+		ResetsInSeconds:  max(int64(w.ResetsAt.Sub(now).Seconds()), 0),
+	}
+	if !w.EmptyAt.IsZero() {
+		at := w.EmptyAt.UTC()
+		in := max(int64(w.EmptyAt.Sub(now).Seconds()), 0)
+		r.EmptiesAt, r.EmptiesInSeconds = &at, &in
+	}
+	return r
+}
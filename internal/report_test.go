@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBuildStatusReport(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_800_000_000, 0)
+	rc, _ := PreviewContext("quota-low", now, PresetConfig("full"))
+
+	r := BuildStatusReport(rc, now)
+	if r.Mode != "normal" {
+		t.Errorf("Mode = %q, want normal", r.Mode)
+	}
+	if r.Session.Model.DisplayName != "Opus 4.6" || r.Metrics.ContextPercent != 58 {
+		t.Errorf("session/metrics not copied: %+v %+v", r.Session.Model, r.Metrics)
+	}
+	if r.Severity.Context != SeverityModerate {
+		t.Errorf("context severity = %s, want moderate", r.Severity.Context)
+	}
+	if r.Severity.Quota5h == nil || *r.Severity.Quota5h != SeverityCritical {
+		t.Errorf("5h quota at 7%% remaining should be critical, got %v", r.Severity.Quota5h)
+	}
+	if r.Usage == nil || r.Usage.FiveHour == nil {
+		t.Fatalf("usage should be present")
+	}
+	wantReset := now.Add(2*time.Hour + 15*time.Minute).UTC()
+	if !r.Usage.FiveHour.ResetsAt.Equal(wantReset) || r.Usage.FiveHour.ResetsInSeconds != int64((2*time.Hour+15*time.Minute).Seconds()) {
+		t.Errorf("five_hour reset = %v (%ds), want %v", r.Usage.FiveHour.ResetsAt, r.Usage.FiveHour.ResetsInSeconds, wantReset)
+	}
+	// 93% used in the 2h45m so far: the last 7% lasts about 12m.
+	if e := r.Usage.FiveHour.EmptiesInSeconds; e == nil || *e != 745 {
+		t.Errorf("five_hour empties_in_seconds = %v, want 745", e)
+	}
+	if at := r.Usage.FiveHour.EmptiesAt; at == nil || at.Sub(now).Round(time.Second) != 745*time.Second {
+		t.Errorf("five_hour empties_at = %v, want 745s after %v", at, now)
+	}
+	if r.Thresholds != DefaultThresholds() {
+		t.Errorf("thresholds should be the effective thresholds")
+	}
+}
+
+func TestBuildStatusReport_DangerAndAbsentMetrics(t *testing.T) {
+	t.Parallel()
+
+	pct := 92.0
+	d := &StdinData{ContextWindow: ContextWindow{ContextWindowSize: 200000, UsedPercentage: &pct}}
+	r := BuildStatusReport(RenderContext{Data: d, Metrics: ComputeMetrics(d)}, time.Now())
+
+	if r.Mode != "danger" || r.Severity.Context != SeverityCritical {
+		t.Errorf("92%% context should be danger/critical, got %s/%s", r.Mode, r.Severity.Context)
+	}
+	if r.Severity.CacheEfficiency != nil || r.Severity.CostVelocity != nil || r.Severity.Quota5h != nil {
+		t.Errorf("absent metrics should have nil severity: %+v", r.Severity)
+	}
+	if r.Usage != nil || r.Git != nil || r.Tools != nil {
+		t.Errorf("absent sources should be nil")
+	}
+	if r.Thresholds != DefaultThresholds() {
+		t.Errorf("zero thresholds should fall back to defaults")
+	}
+}
+
+func TestStatusReport_JSONShape(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	rc, _ := PreviewContext("normal", now, PresetConfig("full"))
+	data, err := json.Marshal(BuildStatusReport(rc, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]json.RawMessage
+	json.Unmarshal(data, &doc)
+	for _, key := range []string{"mode", "session", "metrics", "severity", "git", "usage", "tools", "thresholds", "config_problems"} {
+		if _, ok := doc[key]; !ok {
+			t.Errorf("report missing key %q", key)
+		}
+	}
+	var sev map[string]string
+	json.Unmarshal(doc["severity"], &sev)
+	if sev["context"] != "ok" || sev["cache_efficiency"] == "" {
+		t.Errorf("severity should be named levels, got %v", sev)
+	}
+}
@@ -0,0 +1,144 @@
+package internal
+
+// Severity is how alarming a metric value is under the current Thresholds.
+// Each level maps to one statusline color, so the JSON output and the ANSI
+// output always agree.
+type Severity int
+
+const (
+	SeverityOK       Severity = iota // green (plain for session cost)
+	SeverityModerate                 // yellow
+	SeverityWarning                  // orange
+	SeverityHigh                     // red
+	SeverityCritical                 // bold red
+)
+
+var severityNames = [...]string{"ok", "moderate", "warning", "high", "critical"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "unknown"
+	}
+	return severityNames[s]
+}
+
+// MarshalText encodes the severity as its name in JSON.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s Severity) color() string {
+	switch s {
+	case SeverityModerate:
+		return yellow
+	case SeverityWarning:
+		return orange
+	case SeverityHigh:
+		return red
+	case SeverityCritical:
+		return boldRed
+	default:
+		return green
+	}
+}
+
+func contextSeverity(p int, t Thresholds) Severity {
+	switch {
+	case p >= t.ContextDanger:
+		return SeverityCritical
+	case p >= t.ContextWarning:
+		return SeverityWarning
+	case p >= t.ContextModerate:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
+
+func sessionCostSeverity(usd float64, t Thresholds) Severity {
+	switch {
+	case usd >= t.SessionCostHigh:
+		return SeverityCritical
+	case usd >= t.SessionCostMedium:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
+
+func cacheSeverity(pct int, t Thresholds) Severity {
+	switch {
+	case pct >= t.CacheExcellent:
+		return SeverityOK
+	case pct >= t.CacheGood:
+		return SeverityModerate
+	default:
+		return SeverityHigh
+	}
+}
+
+func apiWaitSeverity(pct int, t Thresholds) Severity {
+	switch {
+	case pct >= t.WaitHigh:
+		return SeverityHigh
+	case pct >= t.WaitMedium:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
+
+func costVelocitySeverity(perMin float64, t Thresholds) Severity {
+	switch {
+	case perMin >= t.CostVelocityHigh:
+		return SeverityCritical
+	case perMin >= t.CostVelocityMedium:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
+
+// compactionSeverity rates how often the session has been compacted: each
+// compaction loses detail, so repeated ones suggest starting fresh.
+func compactionSeverity(n int) Severity {
+	switch {
+	case n >= 3:
+		return SeverityHigh
+	case n == 2:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
+
+// toolErrorSeverity rates the share of tool calls that failed, in percent.
+// The breakpoints are fixed: occasional failures are routine, a high rate
+// means the agent keeps trying things that do not work.
+func toolErrorSeverity(rate float64) Severity {
+	switch {
+	case rate >= 30:
+		return SeverityHigh
+	case rate >= 15:
+		return SeverityWarning
+	case rate >= 5:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
+
+func quotaSeverity(remaining float64, t Thresholds) Severity {
+	switch {
+	case remaining < t.QuotaCritical:
+		return SeverityCritical
+	case remaining < t.QuotaLow:
+		return SeverityHigh
+	case remaining < t.QuotaMedium:
+		return SeverityWarning
+	case remaining < t.QuotaHigh:
+		return SeverityModerate
+	default:
+		return SeverityOK
+	}
+}
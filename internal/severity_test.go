@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSeverity_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		s    Severity
+		want string
+	}{
+		{SeverityOK, "ok"},
+		{SeverityModerate, "moderate"},
+		{SeverityWarning, "warning"},
+		{SeverityHigh, "high"},
+		{SeverityCritical, "critical"},
+		{Severity(99), "unknown"},
+	}
+	for _, tt := range tests {
+		if got := tt.s.String(); got != tt.want {
+			t.Errorf("Severity(%d).String() = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+
+	data, _ := json.Marshal(map[string]Severity{"context": SeverityWarning})
+	if string(data) != `{"context":"warning"}` {
+		t.Errorf("Severity should marshal as its name, got %s", data)
+	}
+}
+
+// Severity levels must agree with the colors the renderer uses.
+func TestSeverity_MatchesColors(t *testing.T) {
+	t.Parallel()
+
+	th := DefaultThresholds()
+	for _, p := range []int{0, 49, 50, 69, 70, 84, 85, 100} {
+		if got, want := contextSeverity(p, th).color(), contextColor(p, th); got != want {
+			t.Errorf("context %d: severity color %q, render color %q", p, got, want)
+		}
+	}
+	for _, pct := range []int{0, 49, 50, 79, 80, 100} {
+		if got, want := cacheSeverity(pct, th).color(), cacheColor(pct, th); got != want {
+			t.Errorf("cache %d: severity color %q, render color %q", pct, got, want)
+		}
+	}
+	for _, pct := range []int{0, 34, 35, 59, 60, 100} {
+		if got, want := apiWaitSeverity(pct, th).color(), apiRatioColor(pct, th); got != want {
+			t.Errorf("wait %d: severity color %q, render color %q", pct, got, want)
+		}
+	}
+	for _, rem := range []float64{0, 9.9, 10, 24.9, 25, 49.9, 50, 74.9, 75, 100} {
+		if got, want := quotaSeverity(rem, th).color(), quotaColor(rem, th); got != want {
+			t.Errorf("quota %.1f: severity color %q, render color %q", rem, got, want)
+		}
+	}
+}
+
+func TestSessionCostSeverity(t *testing.T) {
+	t.Parallel()
+
+	th := DefaultThresholds()
+	tests := []struct {
+		usd  float64
+		want Severity
+	}{
+		{0.5, SeverityOK},
+		{1.0, SeverityModerate},
+		{4.99, SeverityModerate},
+		{5.0, SeverityCritical},
+	}
+	for _, tt := range tests {
+		if got := sessionCostSeverity(tt.usd, th); got != tt.want {
+			t.Errorf("sessionCostSeverity(%.2f) = %s, want %s", tt.usd, got, tt.want)
+		}
+	}
+}
+
+func TestCostVelocitySeverity(t *testing.T) {
+	t.Parallel()
+
+	th := DefaultThresholds()
+	tests := []struct {
+		perMin float64
+		want   Severity
+	}{
+		{0.05, SeverityOK},
+		{0.1, SeverityModerate},
+		{0.5, SeverityCritical},
+	}
+	for _, tt := range tests {
+		if got := costVelocitySeverity(tt.perMin, th); got != tt.want {
+			t.Errorf("costVelocitySeverity(%.2f) = %s, want %s", tt.perMin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Snapshots persist the latest stdin of each session so callers that run on
+// their own schedule (tmux status-right, zellij plugins) can render a session
+// without being piped its JSON.
+const (
+	snapshotMaxBytes = 64 * 1024          // refuse to load larger snapshot files
+	SnapshotMaxAge   = 7 * 24 * time.Hour // older snapshots are pruned
+)
+
+// ErrNoSnapshot is returned when no stored snapshot matches the request.
+var ErrNoSnapshot = errors.New("no session snapshot found")
+
+// SnapshotDir returns ~/.claude/hud/snapshots, or "" when the home directory
+// is unknown.
+func SnapshotDir() string {
+	return hudDir("snapshots")
+}
+
+// SaveSnapshot writes d to <dir>/<session_id>.json atomically (temp file +
+// rename), so concurrent readers never see a partial file. The first write
+// for a new session also prunes snapshots older than SnapshotMaxAge.
+func SaveSnapshot(dir string, d *StdinData) error {
+	if dir == "" {
+		return errors.New("snapshot dir unknown")
+	}
+	if !validSessionID(d.SessionID) {
+		return fmt.Errorf("invalid session id %q", d.SessionID)
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	existed, err := writeFileAtomic(dir, d.SessionID+".json", data)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		pruneStaleFiles(dir, time.Now().Add(-SnapshotMaxAge))
+	}
+	return nil
+}
+
+// LoadSnapshot reads the stored snapshot of one session.
+func LoadSnapshot(dir, sessionID string) (*StdinData, error) {
+	if !validSessionID(sessionID) {
+		return nil, fmt.Errorf("invalid session id %q", sessionID)
+	}
+	d, err := readSnapshot(filepath.Join(dir, sessionID+".json"))
+	if os.IsNotExist(err) {
+		return nil, ErrNoSnapshot
+	}
+	return d, err
+}
+
+// LatestSnapshot returns the most recently written snapshot. When projectDir
+// is non-empty, only sessions whose project dir (or cwd) is projectDir or
+// contains it are considered, so a tmux pane can follow the session of the
+// directory it is in.
+func LatestSnapshot(dir, projectDir string) (*StdinData, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoSnapshot
+		}
+		return nil, err
+	}
+	var best *StdinData
+	var bestTime time.Time
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || (best != nil && !info.ModTime().After(bestTime)) {
+			continue
+		}
+		d, err := readSnapshot(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if projectDir != "" && !snapshotMatchesDir(d, projectDir) {
+			continue
+		}
+		best, bestTime = d, info.ModTime()
+	}
+	if best == nil {
+		return nil, ErrNoSnapshot
+	}
+	return best, nil
+}
+
+func readSnapshot(path string) (*StdinData, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > snapshotMaxBytes {
+		return nil, fmt.Errorf("%s: snapshot larger than %d bytes", path, snapshotMaxBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var d StdinData
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &d, nil
+}
+
+// snapshotMatchesDir reports whether dir is the session's project dir or cwd,
+// or lies beneath one of them.
+func snapshotMatchesDir(d *StdinData, dir string) bool {
+	dir = filepath.Clean(dir)
+	for _, root := range []string{d.Workspace.ProjectDir, d.CWD} {
+		if root == "" {
+			continue
+		}
+		root = filepath.Clean(root)
+		if dir == root || strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	pct := 42.0
+	d := &StdinData{
+		SessionID:     "s1",
+		Model:         Model{DisplayName: "Opus 4.6"},
+		Workspace:     Workspace{ProjectDir: "/work/app"},
+		ContextWindow: ContextWindow{ContextWindowSize: 200000, UsedPercentage: &pct},
+	}
+	if err := SaveSnapshot(dir, d); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("snapshot dir mode = %v (err %v), want 0700", info.Mode().Perm(), err)
+	}
+
+	got, err := LoadSnapshot(dir, "s1")
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if got.Model.DisplayName != "Opus 4.6" || got.ContextWindow.UsedPercentage == nil || *got.ContextWindow.UsedPercentage != 42 {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+
+	if _, err := LoadSnapshot(dir, "missing"); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("missing session err = %v, want ErrNoSnapshot", err)
+	}
+	if _, err := LoadSnapshot(dir, "../s1"); err == nil {
+		t.Error("path traversal session id should be rejected")
+	}
+	if err := SaveSnapshot(dir, &StdinData{SessionID: "../x"}); err == nil {
+		t.Error("SaveSnapshot should reject an unsafe session id")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("snapshot dir has %d entries, want 1 (no leftover temp files)", len(entries))
+	}
+}
+
+func TestLatestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if _, err := LatestSnapshot(filepath.Join(dir, "none"), ""); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("missing dir err = %v, want ErrNoSnapshot", err)
+	}
+
+	now := time.Now()
+	sessions := []struct {
+		id, project string
+		age         time.Duration
+	}{
+		{"old-app", "/work/app", 3 * time.Minute},
+		{"new-app", "/work/app", 2 * time.Minute},
+		{"api", "/work/api", 1 * time.Minute},
+	}
+	for _, s := range sessions {
+		if err := SaveSnapshot(dir, &StdinData{SessionID: s.id, Workspace: Workspace{ProjectDir: s.project}}); err != nil {
+			t.Fatal(err)
+		}
+		ts := now.Add(-s.age)
+		if err := os.Chtimes(filepath.Join(dir, s.id+".json"), ts, ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600)
+
+	tests := []struct {
+		projectDir string
+		want       string
+	}{
+		{"", "api"},
+		{"/work/app", "new-app"},
+		{"/work/app/internal", "new-app"},
+		{"/work/application", ""},
+	}
+	for _, tt := range tests {
+		got, err := LatestSnapshot(dir, tt.projectDir)
+		if tt.want == "" {
+			if !errors.Is(err, ErrNoSnapshot) {
+				t.Errorf("LatestSnapshot(%q) err = %v, want ErrNoSnapshot", tt.projectDir, err)
+			}
+			continue
+		}
+		if err != nil || got.SessionID != tt.want {
+			t.Errorf("LatestSnapshot(%q) = %v, %v; want %s", tt.projectDir, got, err, tt.want)
+		}
+	}
+}
+
+func TestSaveSnapshot_PrunesStale(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := SaveSnapshot(dir, &StdinData{SessionID: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-SnapshotMaxAge - time.Hour)
+	os.Chtimes(filepath.Join(dir, "stale.json"), old, old)
+
+	// Rewriting an existing session does not prune.
+	if err := SaveSnapshot(dir, &StdinData{SessionID: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(filepath.Join(dir, "stale.json"), old, old)
+	if _, err := os.Stat(filepath.Join(dir, "stale.json")); err != nil {
+		t.Fatal("existing-session write should not prune")
+	}
+
+	// The first write of a new session does.
+	if err := SaveSnapshot(dir, &StdinData{SessionID: "fresh"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale.json")); !os.IsNotExist(err) {
+		t.Errorf("stale snapshot should be pruned, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "fresh.json")); err != nil {
+		t.Errorf("fresh snapshot missing: %v", err)
+	}
+}
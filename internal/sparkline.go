@@ -0,0 +1,103 @@
+package internal
+
+import "strings"
+
+// sparkLevels are the cell glyphs of a sparkline, lowest first.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders one cell per value, scaled so that top fills the cell.
+// Each cell takes the color colorOf returns for its value; runs of the same
+// color share one escape. Returns "" for fewer than two values, which is
+// no trend at all.
+func sparkline(values []float64, top float64, colorOf func(float64) string) string {
+	if len(values) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	current := ""
+	for _, v := range values {
+		level := 0
+		if top > 0 && v > 0 {
+			level = min(int(v/top*float64(len(sparkLevels))), len(sparkLevels)-1)
+		}
+		if c := colorOf(v); c != current {
+			b.WriteString(c)
+			current = c
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	b.WriteString(Reset)
+	return b.String()
+}
+
+// recentSamples returns the newest n samples of h, or nil without history.
+func recentSamples(h *SessionState, n int) []Sample {
+	if h == nil {
+		return nil
+	}
+	return h.Samples[max(len(h.Samples)-n, 0):]
+}
+
+// sparklineSamples is the number of samples a sparkline shows under t.
+func sparklineSamples(t Thresholds) int {
+	if t.SparklineSamples <= 0 {
+		return SparklineSamples
+	}
+	return t.SparklineSamples
+}
+
+// renderContextSparkline shows context % over the last sparkline_samples
+// samples on a fixed 0-100 scale, each cell in its context color.
+func renderContextSparkline(h *SessionState, t Thresholds) string {
+	samples := recentSamples(h, sparklineSamples(t))
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.ContextPercent
+	}
+	return sparkline(values, 100, func(v float64) string {
+		return contextColor(int(v), t)
+	})
+}
+
+// renderCostSparkline shows the spending rate ($/min) between consecutive
+// samples, scaled to the peak in view and colored by the cost velocity
+// thresholds, e.g. "$▁▁▂▅▇". Cumulative cost would only ever climb.
+func renderCostSparkline(h *SessionState, t Thresholds) string {
+	samples := recentSamples(h, sparklineSamples(t)+1)
+	var values []float64
+	peak := 0.0
+	for i := 1; i < len(samples); i++ {
+		minutes := float64(samples[i].Time-samples[i-1].Time) / msPerMinute
+		if minutes <= 0 {
+			continue
+		}
+		rate := max(samples[i].CostUSD-samples[i-1].CostUSD, 0) / minutes
+		values = append(values, rate)
+		peak = max(peak, rate)
+	}
+	line := sparkline(values, peak, func(v float64) string {
+		return costVelocitySeverity(v, t).color()
+	})
+	if line == "" {
+		return ""
+	}
+	return grey + "$" + Reset + line
+}
+
+// renderQuotaSparkline shows 5-hour quota usage over the samples that carry
+// it, on a fixed 0-100 scale colored by remaining quota, e.g. "5h:▂▂▃▄".
+func renderQuotaSparkline(h *SessionState, t Thresholds) string {
+	var values []float64
+	for _, s := range recentSamples(h, sparklineSamples(t)) {
+		if s.FiveHour != nil {
+			values = append(values, min(max(s.FiveHour.UsedPercentage, 0), 100))
+		}
+	}
+	line := sparkline(values, 100, func(v float64) string {
+		return quotaColor(100-v, t)
+	})
+	if line == "" {
+		return ""
+	}
+	return grey + "5h:" + Reset + line
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSparkline(t *testing.T) {
+	t.Parallel()
+
+	plain := func(float64) string { return "" }
+	tests := []struct {
+		name   string
+		values []float64
+		top    float64
+		want   string
+	}{
+		{"too few values", []float64{50}, 100, ""},
+		{"full scale", []float64{0, 12.5, 25, 50, 99, 100}, 100, "▁▂▃▅██"},
+		{"clamped", []float64{-5, 150}, 100, "▁█"},
+		{"zero top is flat", []float64{0, 0, 0}, 0, "▁▁▁"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := sparkline(tt.values, tt.top, plain)
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("sparkline() = %q, want empty", got)
+				}
+				return
+			}
+			if got != tt.want+Reset {
+				t.Errorf("sparkline() = %q, want %q", got, tt.want+Reset)
+			}
+		})
+	}
+}
+
+func TestSparkline_ColorRuns(t *testing.T) {
+	t.Parallel()
+
+	colorOf := func(v float64) string {
+		if v >= 50 {
+			return red
+		}
+		return green
+	}
+	got := sparkline([]float64{10, 20, 60, 70, 30}, 100, colorOf)
+	want := green + "▁▂" + red + "▅▆" + green + "▃" + Reset
+	if got != want {
+		t.Errorf("sparkline() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderContextSparkline(t *testing.T) {
+	t.Parallel()
+
+	th := DefaultThresholds()
+	if got := renderContextSparkline(nil, th); got != "" {
+		t.Errorf("nil history = %q, want empty", got)
+	}
+
+	pcts := make([]float64, SparklineSamples+4)
+	for i := range pcts {
+		pcts[i] = float64(i * 5)
+	}
+	got := renderContextSparkline(&SessionState{Samples: ctxSamples(pcts...)}, th)
+	if n := visibleLen(got); n != SparklineSamples {
+		t.Errorf("got %d cells, want %d: %q", n, SparklineSamples, got)
+	}
+	if !strings.HasPrefix(got, contextColor(20, th)) {
+		t.Errorf("first cell should use the context color of 20%%: %q", got)
+	}
+	if !strings.Contains(got, contextColor(75, th)+"▆") {
+		t.Errorf("75%% cell should use its context color: %q", got)
+	}
+
+	// sparkline_samples widens or narrows the window; unset uses the default.
+	th.SparklineSamples = 5
+	if n := visibleLen(renderContextSparkline(&SessionState{Samples: ctxSamples(pcts...)}, th)); n != 5 {
+		t.Errorf("sparkline_samples 5: got %d cells", n)
+	}
+	if n := visibleLen(renderContextSparkline(&SessionState{Samples: ctxSamples(pcts...)}, Thresholds{})); n != SparklineSamples {
+		t.Errorf("zero thresholds: got %d cells, want %d", n, SparklineSamples)
+	}
+}
+
+func TestRenderCostSparkline(t *testing.T) {
+	t.Parallel()
+
+	th := DefaultThresholds()
+	h := &SessionState{Samples: []Sample{
+		{Time: 0, CostUSD: 0},
+		{Time: msPerMinute, CostUSD: 0.05},     // $0.05/m
+		{Time: 2 * msPerMinute, CostUSD: 0.05}, // flat
+		{Time: 3 * msPerMinute, CostUSD: 0.65}, // $0.60/m peak
+	}}
+	want := grey + "$" + Reset + green + "▁▁" + boldRed + "█" + Reset
+	if got := renderCostSparkline(h, th); got != want {
+		t.Errorf("renderCostSparkline() = %q, want %q", got, want)
+	}
+	if got := renderCostSparkline(&SessionState{Samples: h.Samples[:2]}, th); got != "" {
+		t.Errorf("one rate should render nothing, got %q", got)
+	}
+}
+
+func TestRenderQuotaSparkline(t *testing.T) {
+	t.Parallel()
+
+	th := DefaultThresholds()
+	h := &SessionState{Samples: []Sample{
+		{Time: 0, FiveHour: &RateLimitWindow{UsedPercentage: 10}},
+		{Time: 1},
+		{Time: 2, FiveHour: &RateLimitWindow{UsedPercentage: 95}},
+	}}
+	want := grey + "5h:" + Reset + quotaColor(90, th) + "▁" + quotaColor(5, th) + "█" + Reset
+	if got := renderQuotaSparkline(h, th); got != want {
+		t.Errorf("renderQuotaSparkline() = %q, want %q", got, want)
+	}
+	if got := renderQuotaSparkline(&SessionState{Samples: h.Samples[1:2]}, th); got != "" {
+		t.Errorf("no quota samples should render nothing, got %q", got)
+	}
+}
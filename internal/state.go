@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Session state keeps a bounded ring of recent samples per session under
+// ~/.claude/hud/state/<session_id>.json, so metrics can use recent trends
+// instead of lifetime averages. Each invocation sees one stdin snapshot; the
+// ring is how consecutive invocations see each other.
+const (
+	StateSampleInterval = 10 * time.Second // minimum spacing between stored samples
+	StateMaxSamples     = 360              // ring size: one hour at StateSampleInterval
+	StateMaxAge         = 2 * 24 * time.Hour
+	stateMaxBytes       = 256 * 1024 // refuse to load larger state files
+)
+
+// Sample is one point of session history. Counters are cumulative, as piped
+// by Claude Code.
+type Sample struct {
+	Time           int64            `json:"ts"`  // Unix milliseconds
+	ContextPercent float64          `json:"ctx"` // 0-100
+	CostUSD        float64          `json:"cost"`
+	InputTokens    int              `json:"in"`
+	OutputTokens   int              `json:"out"`
+	LinesAdded     int              `json:"added"`
+	LinesRemoved   int              `json:"removed"`
+	FiveHour       *RateLimitWindow `json:"five_hour,omitempty"`
+	SevenDay       *RateLimitWindow `json:"seven_day,omitempty"`
+}
+
+// At returns the sample time.
+func (s Sample) At() time.Time {
+	return time.UnixMilli(s.Time)
+}
+
+// SessionState is the stored history of one session, oldest sample first.
+type SessionState struct {
+	SessionID string   `json:"session_id"`
+	Samples   []Sample `json:"samples"`
+	// Compactions are the Unix-millisecond times of context drops of at least
+	// CompactionDropPercent. Kept apart from the ring so they outlive it.
+	Compactions []int64 `json:"compactions,omitempty"`
+	// Estimate is the running token-based cost and cache savings (see
+	// EstimateCost), nil while the model has no known price.
+	Estimate *CostEstimate `json:"estimate,omitempty"`
+}
+
+const stateMaxCompactions = 100 // newest compaction times kept
+
+// Last returns the newest sample, or false when there is none.
+func (st *SessionState) Last() (Sample, bool) {
+	if st == nil || len(st.Samples) == 0 {
+		return Sample{}, false
+	}
+	return st.Samples[len(st.Samples)-1], true
+}
+
+// StateDir returns ~/.claude/hud/state, or "" when the home directory is
+// unknown.
+func StateDir() string {
+	return hudDir("state")
+}
+
+// NewSample captures the history-relevant fields of one stdin snapshot. The
+// cost is the estimate when there is one (see SessionCost).
+func NewSample(d *StdinData, estimate *float64, now time.Time) Sample {
+	s := Sample{
+		Time:         now.UnixMilli(),
+		CostUSD:      SessionCost(d, estimate),
+		InputTokens:  d.ContextWindow.TotalInputTokens,
+		OutputTokens: d.ContextWindow.TotalOutputTokens,
+		LinesAdded:   d.Cost.TotalLinesAdded,
+		LinesRemoved: d.Cost.TotalLinesRemoved,
+	}
+	if p := d.ContextWindow.UsedPercentage; p != nil {
+		s.ContextPercent = min(max(*p, 0), 100)
+	} else {
+		s.ContextPercent = float64(calcContextPercent(d))
+	}
+	if rl := d.RateLimits; rl != nil {
+		s.FiveHour, s.SevenDay = rl.FiveHour, rl.SevenDay
+	}
+	return s
+}
+
+// add appends s to the ring and reports whether the state changed. Samples
+// closer than StateSampleInterval to the previous one are dropped, except
+// when context shrank (compaction must be seen promptly); a sharp drop is
+// also recorded in Compactions. A cost drop means the counters restarted, so
+// older history no longer applies.
+func (st *SessionState) add(s Sample) bool {
+	if last, ok := st.Last(); ok {
+		if s.Time > last.Time && last.ContextPercent-s.ContextPercent >= CompactionDropPercent {
+			st.Compactions = append(st.Compactions, s.Time)
+			if n := len(st.Compactions); n > stateMaxCompactions {
+				st.Compactions = append(st.Compactions[:0], st.Compactions[n-stateMaxCompactions:]...)
+			}
+		}
+		switch {
+		case s.Time <= last.Time:
+			return false
+		case s.CostUSD < last.CostUSD:
+			st.Samples = st.Samples[:0]
+		case s.Time-last.Time < StateSampleInterval.Milliseconds() && s.ContextPercent >= last.ContextPercent:
+			return false
+		}
+	}
+	st.Samples = append(st.Samples, s)
+	if n := len(st.Samples); n > StateMaxSamples {
+		st.Samples = append(st.Samples[:0], st.Samples[n-StateMaxSamples:]...)
+	}
+	return true
+}
+
+// LoadState reads the stored history of a session. A session without a
+// state file yet returns an empty state and no error.
+func LoadState(dir, sessionID string) (*SessionState, error) {
+	if !validSessionID(sessionID) {
+		return nil, fmt.Errorf("invalid session id %q", sessionID)
+	}
+	st := &SessionState{SessionID: sessionID}
+	if dir == "" {
+		return st, errors.New("state dir unknown")
+	}
+	path := filepath.Join(dir, sessionID+".json")
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if info.Size() > stateMaxBytes {
+		return st, fmt.Errorf("%s: state larger than %d bytes", path, stateMaxBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		// A corrupt file is replaced by the next write rather than blocking history.
+		return &SessionState{SessionID: sessionID}, fmt.Errorf("%s: %w", path, err)
+	}
+	st.SessionID = sessionID
+	if len(st.Samples) > StateMaxSamples {
+		st.Samples = st.Samples[len(st.Samples)-StateMaxSamples:]
+	}
+	return st, nil
+}
+
+// RecordSample loads the history of d's session and records a sample of d
+// in it; see Record. A load error is only returned when the state could not
+// be saved either, since saving replaces a corrupt file.
+func RecordSample(dir string, d *StdinData, estimate *float64, now time.Time) (*SessionState, error) {
+	st, err := LoadState(dir, d.SessionID)
+	if st == nil {
+		return nil, err
+	}
+	rErr := st.Record(dir, d, estimate, now)
+	if dir == "" {
+		return st, err
+	}
+	return st, rErr
+}
+
+// Record adds a sample of d to the session's history and saves the state,
+// including any running cost estimate. The file is rewritten atomically
+// (temp file + rename), so a concurrent writer can at worst drop one sample,
+// never corrupt the ring. Creating a new session's file prunes state
+// untouched for StateMaxAge. On error st is still usable for this invocation.
+func (st *SessionState) Record(dir string, d *StdinData, estimate *float64, now time.Time) error {
+	if !st.add(NewSample(d, estimate, now)) || dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	existed, err := writeFileAtomic(dir, st.SessionID+".json", data)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		pruneStaleFiles(dir, now.Add(-StateMaxAge))
+	}
+	return nil
+}
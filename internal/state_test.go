@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewSample(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	pct := 42.5
+	d := &StdinData{
+		Cost: Cost{TotalCostUSD: 1.5, TotalLinesAdded: 10, TotalLinesRemoved: 3},
+		ContextWindow: ContextWindow{
+			TotalInputTokens:  1000,
+			TotalOutputTokens: 200,
+			UsedPercentage:    &pct,
+		},
+		RateLimits: &RateLimits{FiveHour: &RateLimitWindow{UsedPercentage: 30, ResetsAt: 1}},
+	}
+	s := NewSample(d, nil, now)
+	if !s.At().Equal(now) || s.ContextPercent != 42.5 || s.CostUSD != 1.5 || s.InputTokens != 1000 || s.OutputTokens != 200 ||
+		s.LinesAdded != 10 || s.LinesRemoved != 3 || s.FiveHour == nil || s.SevenDay != nil {
+		t.Errorf("unexpected sample: %+v", s)
+	}
+
+	// Without used_percentage the token fallback applies.
+	d = &StdinData{ContextWindow: ContextWindow{
+		ContextWindowSize: 1000,
+		CurrentUsage:      &CurrentUsage{InputTokens: 250},
+	}}
+	if got := NewSample(d, nil, now).ContextPercent; got != 25 {
+		t.Errorf("fallback context = %v, want 25", got)
+	}
+}
+
+func TestSessionState_Add(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration, ctx, cost float64) Sample {
+		return Sample{Time: base.Add(d).UnixMilli(), ContextPercent: ctx, CostUSD: cost}
+	}
+
+	var st SessionState
+	steps := []struct {
+		name  string
+		s     Sample
+		added bool
+		len   int
+	}{
+		{"first", at(0, 10, 1), true, 1},
+		{"too soon", at(5*time.Second, 11, 1.1), false, 1},
+		{"interval reached", at(StateSampleInterval, 12, 1.2), true, 2},
+		{"out of order", at(StateSampleInterval-time.Second, 12, 1.2), false, 2},
+		{"context drop recorded immediately", at(StateSampleInterval+time.Second, 3, 1.3), true, 3},
+		{"cost reset clears history", at(2*StateSampleInterval+time.Second, 3, 0.1), true, 1},
+	}
+	for _, step := range steps {
+		if got := st.add(step.s); got != step.added || len(st.Samples) != step.len {
+			t.Errorf("%s: added=%v len=%d, want %v %d", step.name, got, len(st.Samples), step.added, step.len)
+		}
+	}
+
+	// The ring keeps only the newest StateMaxSamples.
+	st = SessionState{}
+	for i := range StateMaxSamples + 5 {
+		st.add(at(time.Duration(i)*StateSampleInterval, 0, float64(i)))
+	}
+	if len(st.Samples) != StateMaxSamples || st.Samples[0].CostUSD != 5 {
+		t.Errorf("ring len=%d first cost=%v, want %d and 5", len(st.Samples), st.Samples[0].CostUSD, StateMaxSamples)
+	}
+	if last, ok := st.Last(); !ok || last.CostUSD != float64(StateMaxSamples+4) {
+		t.Errorf("Last() = %+v, %v", last, ok)
+	}
+	if _, ok := (*SessionState)(nil).Last(); ok {
+		t.Error("nil state should have no last sample")
+	}
+}
+
+func TestSessionState_AddRecordsCompactions(t *testing.T) {
+	t.Parallel()
+
+	at := func(min int64, ctx float64) Sample {
+		return Sample{Time: min * msPerMinute, ContextPercent: ctx, CostUSD: float64(min)}
+	}
+	var st SessionState
+	for _, s := range []Sample{
+		at(0, 60),
+		at(1, 85),
+		at(2, 20), // compaction
+		at(3, 15), // small drop: not a compaction
+		at(4, 40),
+		at(5, 5), // compaction
+	} {
+		st.add(s)
+	}
+	want := []int64{2 * msPerMinute, 5 * msPerMinute}
+	if !slices.Equal(st.Compactions, want) {
+		t.Errorf("Compactions = %v, want %v", st.Compactions, want)
+	}
+
+	// Only the newest stateMaxCompactions are kept.
+	st = SessionState{}
+	for i := range int64(stateMaxCompactions + 3) {
+		st.add(at(2*i, 90))
+		st.add(at(2*i+1, 10))
+	}
+	if len(st.Compactions) != stateMaxCompactions || st.Compactions[0] != 7*msPerMinute {
+		t.Errorf("len=%d first=%d, want %d and %d", len(st.Compactions), st.Compactions[0], stateMaxCompactions, 7*msPerMinute)
+	}
+}
+
+func TestRecordSample(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "state")
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	d := &StdinData{SessionID: "s1", Cost: Cost{TotalCostUSD: 1}}
+
+	st, err := LoadState(dir, "s1")
+	if err != nil || st == nil || len(st.Samples) != 0 {
+		t.Fatalf("missing state: %+v, %v; want empty state", st, err)
+	}
+
+	for i := range 3 {
+		d.Cost.TotalCostUSD = float64(i + 1)
+		if _, err := RecordSample(dir, d, nil, now.Add(time.Duration(i)*StateSampleInterval)); err != nil {
+			t.Fatalf("RecordSample: %v", err)
+		}
+	}
+	st, err = LoadState(dir, "s1")
+	if err != nil || len(st.Samples) != 3 || st.Samples[2].CostUSD != 3 {
+		t.Fatalf("reloaded state: %+v, %v", st, err)
+	}
+
+	if _, err := LoadState(dir, "../s1"); err == nil {
+		t.Error("unsafe session id should be rejected")
+	}
+
+	// A corrupt file is reported and then replaced.
+	os.WriteFile(filepath.Join(dir, "s1.json"), []byte("{"), 0o600)
+	if _, err := LoadState(dir, "s1"); err == nil {
+		t.Error("corrupt state should return an error")
+	}
+	st, err = RecordSample(dir, d, nil, now.Add(time.Hour))
+	if err != nil || len(st.Samples) != 1 {
+		t.Errorf("record over corrupt file: %+v, %v; want fresh state", st, err)
+	}
+}
+
+func TestRecordSample_PrunesStaleSessions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	if _, err := RecordSample(dir, &StdinData{SessionID: "old"}, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	old := now.Add(-StateMaxAge - time.Hour)
+	os.Chtimes(filepath.Join(dir, "old.json"), old, old)
+
+	if _, err := RecordSample(dir, &StdinData{SessionID: "new"}, nil, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.json")); !os.IsNotExist(err) {
+		t.Errorf("stale session state should be pruned, stat err = %v", err)
+	}
+}
+
+// Concurrent writers may drop samples but must never leave a corrupt file.
+func TestRecordSample_ConcurrentWriters(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d := &StdinData{SessionID: "s1", Cost: Cost{TotalCostUSD: float64(i)}}
+			RecordSample(dir, d, nil, base.Add(time.Duration(i)*StateSampleInterval))
+		}()
+	}
+	wg.Wait()
+
+	st, err := LoadState(dir, "s1")
+	if err != nil || len(st.Samples) == 0 {
+		t.Fatalf("state after concurrent writes: %+v, %v", st, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want 1", len(entries))
+	}
+}
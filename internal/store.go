@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// hudDir returns ~/.claude/hud/<sub>, or "" when the home directory is
+// unknown. Per-session files Howl writes (snapshots, state) live here.
+func hudDir(sub string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".claude", "hud", sub)
+}
+
+// writeFileAtomic writes data to dir/name via a temp file and rename, so
+// readers never see a partial file and concurrent writers never interleave:
+// the last rename wins. The directory is created 0700 and the file is 0600.
+// Returns whether the file existed before the write.
+func writeFileAtomic(dir, name string, data []byte) (existed bool, err error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return false, err
+	}
+	path := filepath.Join(dir, name)
+	_, statErr := os.Stat(path)
+
+	tmp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return false, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+	return statErr == nil, nil
+}
+
+// pruneStaleFiles removes .json files (and stray .tmp files) in dir last
+// written before cutoff. Errors are ignored: pruning is best effort.
+func pruneStaleFiles(dir string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !(strings.HasSuffix(e.Name(), ".json") || strings.HasSuffix(e.Name(), ".tmp")) {
+			continue
+		}
+		if info, err := e.Info(); err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// validSessionID reports whether id is safe to use as a file name: non-empty,
+// at most 128 characters of [A-Za-z0-9._-], and not "." or "..".
+func validSessionID(id string) bool {
+	if id == "" || len(id) > 128 || id == "." || id == ".." {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
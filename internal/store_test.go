@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidSessionID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0f3c9a2e-1b7d-4c55-9e0a-2d6f8b1c4e77", true},
+		{"abc_DEF.1", true},
+		{"", false},
+		{".", false},
+		{"..", false},
+		{"../etc/passwd", false},
+		{"a/b", false},
+		{"a b", false},
+	}
+	for _, tt := range tests {
+		if got := validSessionID(tt.id); got != tt.want {
+			t.Errorf("validSessionID(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "nested", "dir")
+	existed, err := writeFileAtomic(dir, "a.json", []byte(`{"v":1}`))
+	if err != nil || existed {
+		t.Fatalf("first write: existed=%v err=%v, want false, nil", existed, err)
+	}
+	existed, err = writeFileAtomic(dir, "a.json", []byte(`{"v":2}`))
+	if err != nil || !existed {
+		t.Fatalf("second write: existed=%v err=%v, want true, nil", existed, err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "a.json"))
+	if string(data) != `{"v":2}` {
+		t.Errorf("content = %s, want the last write", data)
+	}
+	info, _ := os.Stat(filepath.Join(dir, "a.json"))
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want 1 (temp file left behind)", len(entries))
+	}
+}
+
+func TestPruneStaleFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"old.json", ".old.json.1.tmp", "old.txt", "new.json"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0o600)
+		if name != "new.json" {
+			os.Chtimes(filepath.Join(dir, name), old, old)
+		}
+	}
+
+	pruneStaleFiles(dir, time.Now().Add(-time.Minute))
+
+	for name, want := range map[string]bool{"old.json": false, ".old.json.1.tmp": false, "old.txt": true, "new.json": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if got := err == nil; got != want {
+			t.Errorf("%s exists = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -1,24 +1,61 @@
 package internal
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // TranscriptEntry represents a single line in the Claude Code transcript JSONL file.
 type TranscriptEntry struct {
-	Message struct {
-		Content []ContentBlock `json:"content"`
+	Type             string `json:"type"`    // "user", "assistant", "system", "summary", ...
+	Subtype          string `json:"subtype"` // e.g. "compact_boundary" on system entries
+	Timestamp        string `json:"timestamp"`
+	IsCompactSummary bool   `json:"isCompactSummary"` // user entry carrying a compaction summary
+	// Entries of a subagent's conversation are sidechains; each entry names
+	// the one before it in its conversation by parentUuid.
+	IsSidechain bool   `json:"isSidechain"`
+	UUID        string `json:"uuid"`
+	ParentUUID  string `json:"parentUuid"`
+	Message     struct {
+		// ID groups the entries of one API message: Claude Code writes each
+		// content block of a response as its own line.
+		ID      string        `json:"id"`
+		Content ContentBlocks `json:"content"`
 	} `json:"message"`
 }
 
+// ContentBlocks is a message's content: an array of blocks, or a plain string
+// (typed prompts, compaction summaries), which decodes as one text block.
+type ContentBlocks []ContentBlock
+
+// UnmarshalJSON accepts both content forms.
+func (c *ContentBlocks) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*c = ContentBlocks{{Type: "text", Text: text}}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]ContentBlock)(c))
+}
+
 // ContentBlock represents a single content block within a transcript message.
 type ContentBlock struct {
 	Type      string                 `json:"type"`
+	Text      string                 `json:"text"`
 	ID        string                 `json:"id"`
 	Name      string                 `json:"name"`
 	Input     map[string]interface{} `json:"input"`
@@ -28,12 +65,53 @@ type ContentBlock struct {
 
 // ToolInfo represents the aggregated tool usage and running agents from the transcript.
 type ToolInfo struct {
-	Tools  map[string]int // tool name -> count
-	Agents []string       // running agent names
+	Tools  map[string]int `json:"tools"`  // tool name -> count
+	Agents []string       `json:"agents"` // running agent names, as in AgentTree
+	// AgentTree lists the running agents depth first, each followed by the
+	// agents it launched. AgentsDone and AgentsFailed count the agents that
+	// returned, by whether their result was an error.
+	AgentTree    []RunningAgent `json:"agent_tree"`
+	AgentsDone   int            `json:"agents_done"`
+	AgentsFailed int            `json:"agents_failed"`
+	// Errors counts failed calls (tool_result is_error) of the tools in
+	// Tools; Calls and Failed are the session totals over all tools.
+	// ErrorStreak is how many of the latest results failed in a row.
+	Errors      map[string]int `json:"errors"`
+	Calls       int            `json:"calls"`
+	Failed      int            `json:"failed"`
+	ErrorStreak int            `json:"error_streak"`
+	// Running are the tool calls of the latest response still awaiting
+	// their results, oldest first.
+	Running []RunningTool `json:"running"`
+	// Todos is the progress of the latest TodoWrite list; nil before any.
+	Todos *TodoProgress `json:"todos"`
+	// Compactions seen in the parsed part of the transcript, and when the
+	// latest happened (nil when unknown).
+	Compactions    int        `json:"compactions"`
+	LastCompaction *time.Time `json:"last_compaction"`
+}
+
+// RunningTool is a tool call in flight.
+type RunningTool struct {
+	Name    string     `json:"name"`
+	Summary string     `json:"summary"` // command, file name or pattern; "" when none
+	Started *time.Time `json:"started"` // nil when the transcript has no timestamp
+}
+
+// RunningAgent is an agent that has not returned yet.
+type RunningAgent struct {
+	Name    string     `json:"name"`
+	Depth   int        `json:"depth"`   // 0 for agents of the main conversation
+	Started *time.Time `json:"started"` // nil when the transcript has no timestamp
+}
+
+// TodoProgress summarizes a TodoWrite list.
+type TodoProgress struct {
+	Done   int    `json:"done"`   // completed items
+	Total  int    `json:"total"`  // all items
+	Active string `json:"active"` // the first in_progress item; "" when none
 }
 
-// ParseTranscript reads the last N lines of transcript to extract recent tools and agents.
-// Returns nil on any error (transcript parsing is optional).
 // shortenToolName extracts a readable short name from MCP tool names.
 // e.g. "mcp__plugin_serena_serena__find_symbol" → "find_symbol"
 // Non-MCP tools (Edit, Read, Bash) are returned as-is.
@@ -48,130 +126,562 @@ func shortenToolName(name string) string {
 	return parts[len(parts)-1]
 }
 
-func ParseTranscript(path string) *ToolInfo {
+// Transcripts only grow, so each one is indexed incrementally: the index
+// keeps the byte offset parsed so far and running aggregates for the whole
+// session, and every refresh parses only the bytes appended since. Indexes
+// live under ~/.claude/hud/transcripts/<path hash>.json; they are caches,
+// rebuilt from the transcript when missing, corrupt, or when the transcript
+// was truncated or replaced.
+const (
+	TranscriptIndexMaxAge   = 7 * 24 * time.Hour // indexes idle this long are pruned
+	transcriptIndexMaxBytes = 256 * 1024         // refuse to load larger index files
+	// transcriptReadBudget caps the bytes parsed per refresh, so a cold start
+	// on a huge transcript catches up over a few refreshes instead of
+	// stalling one (a single longer line is still read whole).
+	transcriptReadBudget = 8 << 20
+	transcriptHeadBytes  = 1024 // fingerprinted prefix that detects a replaced file
+	maxRunningAgents     = 32   // agents without a result kept, oldest dropped first
+	maxPendingTools      = 64   // tool calls awaiting a result kept, oldest dropped first
+	maxAgentTail         = 8    // sidechain entry UUIDs kept per agent
+)
+
+// TranscriptIndex is the parsed state of one transcript up to Offset.
+type TranscriptIndex struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"` // bytes parsed, always at a line start
+	// HeadLen and HeadSum fingerprint the first bytes of the file; a
+	// mismatch means it was replaced and is indexed from scratch.
+	HeadLen int64  `json:"head_len"`
+	HeadSum string `json:"head_sum"`
+	Lines   int    `json:"lines"`   // JSON lines parsed
+	Invalid int    `json:"invalid"` // non-empty lines that were not JSON
+
+	Tools  map[string]int    `json:"tools"`  // all tool calls by short name
+	Agents []TranscriptAgent `json:"agents"` // launched agents without a result yet, oldest first
+	// Returned agents, by whether their result was an error.
+	AgentsDone   int `json:"agents_done"`
+	AgentsFailed int `json:"agents_failed"`
+	// Pending tool calls await their tool_result, which names them only by
+	// tool_use_id; a failed result counts against the pending call's tool.
+	Pending []PendingTool  `json:"pending"`
+	Errors  map[string]int `json:"errors"`       // failed tool calls by short name
+	Streak  int            `json:"error_streak"` // latest results failed in a row
+	// Turn is the message of the latest tool calls; its pending calls are
+	// in flight. A typed prompt ends the turn, so calls that never got a
+	// result (an interrupted session) do not stay in flight.
+	Turn string `json:"turn"`
+	// Todos is the latest TodoWrite list, which replaces the whole list on
+	// every call.
+	Todos *TodoProgress `json:"todos,omitempty"`
+	// A compaction writes a compact_boundary system entry followed by a user
+	// entry holding the summary; older transcripts have only the latter.
+	Boundaries     int        `json:"boundaries"`
+	Summaries      int        `json:"summaries"`
+	LastCompaction *time.Time `json:"last_compaction,omitempty"`
+}
+
+// TranscriptAgent is an agent launched in the transcript.
+type TranscriptAgent struct {
+	ID     string     `json:"id"` // tool_use_id that launched it
+	Name   string     `json:"name"`
+	Parent string     `json:"parent,omitempty"` // ID of the launching agent; "" for the main conversation
+	Start  *time.Time `json:"start,omitempty"`
+	// PromptSum fingerprints the prompt, which opens the agent's sidechain;
+	// Tail holds the UUIDs of its latest sidechain entries, which the next
+	// one names as its parent.
+	PromptSum string   `json:"prompt_sum,omitempty"`
+	Tail      []string `json:"tail,omitempty"`
+}
+
+// PendingTool is a tool call without a result yet.
+type PendingTool struct {
+	ID      string     `json:"id"` // tool_use_id
+	Name    string     `json:"name"`
+	Msg     string     `json:"msg"` // message ID, or the line number without one
+	Start   *time.Time `json:"start,omitempty"`
+	Summary string     `json:"summary,omitempty"`
+}
+
+// TranscriptIndexDir returns ~/.claude/hud/transcripts, or "" when the home
+// directory is unknown.
+func TranscriptIndexDir() string {
+	return hudDir("transcripts")
+}
+
+// transcriptIndexName is the index file name for a transcript path.
+func transcriptIndexName(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:8]) + ".json"
+}
+
+// ParseTranscript brings the index of the transcript at path up to date and
+// returns the tools and agents of the whole session. The index is kept in
+// dir between calls; with dir "" the transcript is parsed from the start.
+// Returns nil on any error (transcript parsing is optional).
+func ParseTranscript(dir, path string) *ToolInfo {
 	if path == "" {
 		return nil
 	}
-
-	lines, err := tailLines(path, 64*1024, 100)
+	idx := loadTranscriptIndex(dir, path)
+	changed, err := idx.update(path)
 	if err != nil {
 		return nil
 	}
+	if changed && dir != "" {
+		if data, err := json.Marshal(idx); err == nil {
+			if existed, err := writeFileAtomic(dir, transcriptIndexName(path), data); err == nil && !existed {
+				pruneStaleFiles(dir, time.Now().Add(-TranscriptIndexMaxAge))
+			}
+		}
+	}
+	return idx.toolInfo()
+}
+
+// loadTranscriptIndex returns the stored index of path, or a fresh one when
+// there is none or it cannot be used.
+func loadTranscriptIndex(dir, path string) *TranscriptIndex {
+	fresh := &TranscriptIndex{Path: path}
+	if dir == "" {
+		return fresh
+	}
+	file := filepath.Join(dir, transcriptIndexName(path))
+	if info, err := os.Stat(file); err != nil || info.Size() > transcriptIndexMaxBytes {
+		return fresh
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fresh
+	}
+	var idx TranscriptIndex
+	if json.Unmarshal(data, &idx) != nil || idx.Path != path || idx.Offset < 0 {
+		return fresh
+	}
+	return &idx
+}
 
-	toolCounts := make(map[string]int)
-	runningAgents := make(map[string]bool)
-	agentNames := make(map[string]string) // tool_use_id -> agent description
+// update parses the lines appended to the transcript since the index was
+// last updated, up to transcriptReadBudget bytes, and reports whether the
+// index changed. A transcript shorter than Offset or with a different head
+// was truncated or replaced, so the index restarts from its beginning. A
+// trailing line without a newline is only parsed once it is complete JSON.
+func (idx *TranscriptIndex) update(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	size := info.Size()
 
-	for _, line := range lines {
-		if line == "" {
-			continue
+	changed := false
+	if idx.Offset > 0 || idx.HeadLen > 0 {
+		sum, err := headSum(f, idx.HeadLen, size)
+		if err != nil {
+			return false, err
+		}
+		if size < idx.Offset || sum != idx.HeadSum {
+			*idx = TranscriptIndex{Path: path}
+			changed = true
+		}
+	}
+	if idx.HeadLen < transcriptHeadBytes && size > idx.HeadLen {
+		idx.HeadLen = min(size, transcriptHeadBytes)
+		if idx.HeadSum, err = headSum(f, idx.HeadLen, size); err != nil {
+			return false, err
 		}
+		changed = true
+	}
+	if size == idx.Offset {
+		return changed, nil
+	}
 
-		var entry TranscriptEntry
-		if err := json.Unmarshal([]byte(line), &entry); err != nil {
-			continue
+	if _, err := f.Seek(idx.Offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	r := bufio.NewReaderSize(f, 64*1024)
+	var read int64
+	for read < transcriptReadBudget {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			if line[len(line)-1] != '\n' && !json.Valid(line) {
+				break // still being written
+			}
+			idx.apply(line)
+			idx.Offset += int64(len(line))
+			read += int64(len(line))
+			changed = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// headSum hashes the first n bytes of f, or returns "" when f is shorter.
+func headSum(f *os.File, n, size int64) (string, error) {
+	if n <= 0 || size < n {
+		return "", nil
+	}
+	buf := make([]byte, n)
+	if _, err := f.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return "", err
+	}
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// apply adds one transcript line to the index.
+func (idx *TranscriptIndex) apply(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	var entry TranscriptEntry
+	if err := json.Unmarshal(line, &entry); err != nil {
+		idx.Invalid++
+		return
+	}
+	idx.Lines++
+
+	if (entry.Type == "system" && entry.Subtype == "compact_boundary") || entry.IsCompactSummary {
+		if entry.IsCompactSummary {
+			idx.Summaries++
+		} else {
+			idx.Boundaries++
+		}
+		if ts, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil && (idx.LastCompaction == nil || ts.After(*idx.LastCompaction)) {
+			idx.LastCompaction = &ts
 		}
+	}
 
-		for _, block := range entry.Message.Content {
-			if block.Type == "tool_use" && block.Name != "" {
-				if block.Name == "Task" {
-					// Extract agent info
-					subagentType, _ := block.Input["subagent_type"].(string)
-					desc, _ := block.Input["description"].(string)
-					if subagentType != "" {
-						runningAgents[block.ID] = true
-						if desc != "" && len(desc) < 30 {
-							agentNames[block.ID] = desc
-						} else {
-							agentNames[block.ID] = subagentType
-						}
+	msg := entry.Message.ID
+	if msg == "" {
+		msg = "line " + strconv.Itoa(idx.Lines)
+	}
+	var start *time.Time
+	if ts, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+		start = &ts
+	}
+	// Sidechain entries belong to a subagent: its prompts and tool results
+	// neither end the main turn nor start one.
+	prompt := entry.Type == "user" && !entry.IsSidechain
+	var owner string
+	if entry.IsSidechain {
+		owner = idx.sidechainOwner(entry)
+	}
+
+	for _, block := range entry.Message.Content {
+		if block.Type == "tool_result" {
+			prompt = false
+		}
+		if block.Type == "tool_use" && block.Name != "" {
+			if isAgentTool(block.Name) {
+				idx.launchAgent(block, owner, start)
+			} else if block.Name == "TodoWrite" {
+				// Progress, not work: tracked apart from the tool counts.
+				if p := todoProgress(block.Input); p != nil {
+					idx.Todos = p
+				}
+			} else {
+				// Count regular tools
+				name := shortenToolName(block.Name)
+				if idx.Tools == nil {
+					idx.Tools = make(map[string]int)
+				}
+				idx.Tools[name]++
+				if block.ID != "" {
+					if !entry.IsSidechain {
+						idx.Turn = msg
 					}
-				} else if block.Name != "TodoWrite" {
-					// Count regular tools (skip TodoWrite)
-					toolCounts[shortenToolName(block.Name)]++
+					idx.Pending = append(idx.Pending, PendingTool{
+						ID: block.ID, Name: name, Msg: msg, Start: start, Summary: toolSummary(block.Input),
+					})
+					if len(idx.Pending) > maxPendingTools {
+						idx.Pending = idx.Pending[len(idx.Pending)-maxPendingTools:]
+					}
+				}
+			}
+		} else if block.Type == "tool_result" && block.ToolUseID != "" {
+			idx.agentReturned(block)
+			idx.result(block)
+		}
+	}
+	if prompt {
+		idx.Turn = ""
+	}
+}
+
+// isAgentTool reports whether a tool launches a subagent: "Task", renamed
+// "Agent" by newer Claude Code versions.
+func isAgentTool(name string) bool {
+	return name == "Task" || name == "Agent"
+}
+
+// launchAgent records an agent launched by block from the conversation of
+// agent parent ("" for the main one). Calls without a subagent_type are not
+// tracked. The agent is named by its description when short, else its type.
+func (idx *TranscriptIndex) launchAgent(block ContentBlock, parent string, start *time.Time) {
+	subagentType, _ := block.Input["subagent_type"].(string)
+	if subagentType == "" {
+		return
+	}
+	name := subagentType
+	if desc, _ := block.Input["description"].(string); desc != "" && len(desc) < 30 {
+		name = desc
+	}
+	a := TranscriptAgent{ID: block.ID, Name: name, Parent: parent, Start: start}
+	if prompt, _ := block.Input["prompt"].(string); strings.TrimSpace(prompt) != "" {
+		a.PromptSum = promptSum(prompt)
+	}
+	idx.Agents = append(idx.Agents, a)
+	if len(idx.Agents) > maxRunningAgents {
+		idx.Agents = idx.Agents[len(idx.Agents)-maxRunningAgents:]
+	}
+}
+
+// agentReturned ends the agent a tool_result answers, counting it done or
+// failed. Agents it launched that are still listed went with it.
+func (idx *TranscriptIndex) agentReturned(block ContentBlock) {
+	i := slices.IndexFunc(idx.Agents, func(a TranscriptAgent) bool { return a.ID == block.ToolUseID })
+	if i < 0 {
+		return
+	}
+	if block.IsError {
+		idx.AgentsFailed++
+	} else {
+		idx.AgentsDone++
+	}
+	gone := map[string]bool{block.ToolUseID: true}
+	// Children follow their parent in launch order, so one pass finds every
+	// descendant.
+	for _, a := range idx.Agents[i:] {
+		if gone[a.Parent] {
+			gone[a.ID] = true
+		}
+	}
+	idx.Agents = slices.DeleteFunc(idx.Agents, func(a TranscriptAgent) bool { return gone[a.ID] })
+}
+
+// sidechainOwner returns the ID of the running agent whose conversation the
+// sidechain entry continues, and remembers the entry as that agent's latest.
+// The entry's parent is matched against each agent's recent entries; an
+// entry opening a conversation is matched by its prompt. Failing both it
+// falls to the latest agent launched, and to none when no agent runs.
+func (idx *TranscriptIndex) sidechainOwner(entry TranscriptEntry) string {
+	i := -1
+	if entry.ParentUUID != "" {
+		i = slices.IndexFunc(idx.Agents, func(a TranscriptAgent) bool { return slices.Contains(a.Tail, entry.ParentUUID) })
+	} else {
+		for _, block := range entry.Message.Content {
+			if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+				sum := promptSum(block.Text)
+				i = slices.IndexFunc(idx.Agents, func(a TranscriptAgent) bool { return a.PromptSum == sum })
+				break
+			}
+		}
+	}
+	if i < 0 {
+		i = len(idx.Agents) - 1
+	}
+	if i < 0 {
+		return ""
+	}
+	a := &idx.Agents[i]
+	if entry.UUID != "" {
+		a.Tail = append(a.Tail, entry.UUID)
+		if len(a.Tail) > maxAgentTail {
+			a.Tail = a.Tail[len(a.Tail)-maxAgentTail:]
+		}
+	}
+	return a.ID
+}
+
+// promptSum fingerprints an agent prompt, ignoring surrounding whitespace.
+func promptSum(prompt string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(prompt)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// todoProgress summarizes a TodoWrite input. Items that are not objects are
+// skipped; nil means the input holds no todos list at all.
+func todoProgress(input map[string]interface{}) *TodoProgress {
+	todos, ok := input["todos"].([]interface{})
+	if !ok {
+		return nil
+	}
+	p := &TodoProgress{}
+	for _, t := range todos {
+		item, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		p.Total++
+		switch status, _ := item["status"].(string); status {
+		case "completed":
+			p.Done++
+		case "in_progress":
+			if p.Active != "" {
+				break
+			}
+			// activeForm is the present-tense wording ("Running tests").
+			for _, key := range []string{"activeForm", "content"} {
+				if s, _ := item[key].(string); strings.TrimSpace(s) != "" {
+					p.Active = strings.Join(strings.Fields(s), " ")
+					break
 				}
-			} else if block.Type == "tool_result" && block.ToolUseID != "" {
-				// Agent completed
-				delete(runningAgents, block.ToolUseID)
 			}
 		}
 	}
+	return p
+}
+
+// toolSummaryKeys are the tool input fields that best describe a call, in
+// order of preference.
+var toolSummaryKeys = []string{"command", "file_path", "notebook_path", "pattern", "path", "url", "query", "description", "prompt"}
+
+// toolSummary describes a tool call by its input in at most 80 characters:
+// the first line of a command, the name of a file, a search pattern...
+func toolSummary(input map[string]interface{}) string {
+	for _, key := range toolSummaryKeys {
+		v, _ := input[key].(string)
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if key == "file_path" || key == "notebook_path" {
+			v = filepath.Base(v)
+		}
+		if i := strings.IndexByte(v, '\n'); i >= 0 {
+			v = strings.TrimSpace(v[:i]) + " …"
+		}
+		return truncateToolName(v, 80)
+	}
+	return ""
+}
+
+// result matches a tool_result to its pending tool call, counting a failure
+// against the tool and extending or ending the failure streak. Results of
+// calls not counted in Tools (agents, TodoWrite, calls before the index
+// started) are ignored.
+func (idx *TranscriptIndex) result(block ContentBlock) {
+	i := slices.IndexFunc(idx.Pending, func(p PendingTool) bool { return p.ID == block.ToolUseID })
+	if i < 0 {
+		return
+	}
+	name := idx.Pending[i].Name
+	idx.Pending = slices.Delete(idx.Pending, i, i+1)
+	if !block.IsError {
+		idx.Streak = 0
+		return
+	}
+	if idx.Errors == nil {
+		idx.Errors = make(map[string]int)
+	}
+	idx.Errors[name]++
+	idx.Streak++
+}
+
+// agentTree lists the running agents depth first in launch order. An agent
+// whose parent is no longer listed counts as top-level.
+func (idx *TranscriptIndex) agentTree() []RunningAgent {
+	listed := make(map[string]bool, len(idx.Agents))
+	for _, a := range idx.Agents {
+		listed[a.ID] = true
+	}
+	tree := make([]RunningAgent, 0, len(idx.Agents))
+	var walk func(parent string, depth int)
+	walk = func(parent string, depth int) {
+		if depth > maxRunningAgents { // repeated IDs could form a cycle
+			return
+		}
+		for _, a := range idx.Agents {
+			top := a.Parent == "" || !listed[a.Parent] || a.Parent == a.ID
+			if (depth == 0 && top) || (depth > 0 && !top && a.Parent == parent) {
+				tree = append(tree, RunningAgent{Name: a.Name, Depth: depth, Started: a.Start})
+				walk(a.ID, depth+1)
+			}
+		}
+	}
+	walk("", 0)
+	return tree
+}
 
-	// Get top 5 tools
+// toolInfo summarizes the index for rendering: the top 5 tools and the
+// running agents.
+func (idx *TranscriptIndex) toolInfo() *ToolInfo {
 	type toolEntry struct {
 		name  string
 		count int
 	}
-	tools := make([]toolEntry, 0, len(toolCounts))
-	for name, count := range toolCounts {
+	tools := make([]toolEntry, 0, len(idx.Tools))
+	for name, count := range idx.Tools {
 		tools = append(tools, toolEntry{name, count})
 	}
 	sort.Slice(tools, func(i, j int) bool {
-		return tools[i].count > tools[j].count
+		if tools[i].count != tools[j].count {
+			return tools[i].count > tools[j].count
+		}
+		return tools[i].name < tools[j].name
 	})
 	if len(tools) > 5 {
 		tools = tools[:5]
 	}
 
 	topTools := make(map[string]int)
+	var failures map[string]int
 	for _, t := range tools {
 		topTools[t.name] = t.count
-	}
-
-	// Get running agent names
-	agents := make([]string, 0, len(runningAgents))
-	for id := range runningAgents {
-		if name, ok := agentNames[id]; ok {
-			agents = append(agents, name)
+		if n := idx.Errors[t.name]; n > 0 {
+			if failures == nil {
+				failures = make(map[string]int)
+			}
+			failures[t.name] = n
 		}
 	}
-
-	return &ToolInfo{
-		Tools:  topTools,
-		Agents: agents,
+	calls, failed := 0, 0
+	for _, n := range idx.Tools {
+		calls += n
 	}
-}
-
-// tailLines reads at most maxBytes from the end of a file and returns the last maxLines lines.
-func tailLines(path string, maxBytes int64, maxLines int) ([]string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
+	for _, n := range idx.Errors {
+		failed += n
 	}
-	defer func() { _ = file.Close() }()
 
-	stat, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
-	if stat.Size() <= 0 {
-		return nil, nil
+	tree := idx.agentTree()
+	agents := make([]string, 0, len(tree))
+	for _, a := range tree {
+		agents = append(agents, a.Name)
 	}
 
-	start := stat.Size() - maxBytes
-	if start < 0 {
-		start = 0
-	}
-	buf := make([]byte, stat.Size()-start)
-	n, err := file.ReadAt(buf, start)
-	if err != nil && err != io.EOF {
-		return nil, err
-	}
-	buf = buf[:n]
-
-	// If we started mid-line, drop the partial prefix.
-	if start > 0 {
-		if idx := bytes.IndexByte(buf, '\n'); idx >= 0 {
-			buf = buf[idx+1:]
+	var running []RunningTool
+	for _, p := range idx.Pending {
+		if idx.Turn != "" && p.Msg == idx.Turn {
+			running = append(running, RunningTool{Name: p.Name, Summary: p.Summary, Started: p.Start})
 		}
 	}
 
-	lines := strings.Split(string(buf), "\n")
-	if len(lines) > 0 && lines[len(lines)-1] == "" {
-		lines = lines[:len(lines)-1]
-	}
-	if len(lines) > maxLines {
-		lines = lines[len(lines)-maxLines:]
+	return &ToolInfo{
+		Tools:          topTools,
+		Agents:         agents,
+		AgentTree:      tree,
+		AgentsDone:     idx.AgentsDone,
+		AgentsFailed:   idx.AgentsFailed,
+		Errors:         failures,
+		Calls:          calls,
+		Failed:         failed,
+		ErrorStreak:    idx.Streak,
+		Running:        running,
+		Todos:          idx.Todos,
+		Compactions:    max(idx.Boundaries, idx.Summaries),
+		LastCompaction: idx.LastCompaction,
 	}
-	return lines, nil
 }
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // fixture returns the path to a testdata fixture file.
@@ -143,7 +144,7 @@ func TestParseTranscript(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			path := fixture(tt.fixture)
-			got := ParseTranscript(path)
+			got := ParseTranscript("", path)
 
 			if got == nil {
 				t.Fatal("ParseTranscript() returned nil, want non-nil ToolInfo")
@@ -194,20 +195,46 @@ func TestParseTranscript(t *testing.T) {
 	}
 }
 
+func TestParseTranscript_Compactions(t *testing.T) {
+	t.Parallel()
+
+	// One compact_boundary + summary pair, then a summary without a boundary
+	// (older transcript format); string message contents must not break parsing.
+	got := ParseTranscript("", fixture("transcript_compact.jsonl"))
+	if got == nil {
+		t.Fatal("ParseTranscript() returned nil")
+	}
+	if got.Tools["Read"] != 2 {
+		t.Errorf("Read count = %d, want 2", got.Tools["Read"])
+	}
+	if got.Compactions != 2 {
+		t.Errorf("Compactions = %d, want 2", got.Compactions)
+	}
+	want := time.Date(2026, 6, 15, 11, 5, 0, 0, time.UTC)
+	if got.LastCompaction == nil || !got.LastCompaction.Equal(want) {
+		t.Errorf("LastCompaction = %v, want %v", got.LastCompaction, want)
+	}
+
+	plain := ParseTranscript("", fixture("transcript_single_tool.jsonl"))
+	if plain.Compactions != 0 || plain.LastCompaction != nil {
+		t.Errorf("no compactions expected, got %d at %v", plain.Compactions, plain.LastCompaction)
+	}
+}
+
 func TestParseTranscriptEdgeCases(t *testing.T) {
 	t.Run("empty path", func(t *testing.T) {
-		if got := ParseTranscript(""); got != nil {
+		if got := ParseTranscript("", ""); got != nil {
 			t.Errorf("ParseTranscript(\"\") = %v, want nil", got)
 		}
 	})
 
 	t.Run("non-existent file", func(t *testing.T) {
-		if got := ParseTranscript("/nonexistent/path/file.jsonl"); got != nil {
+		if got := ParseTranscript("", "/nonexistent/path/file.jsonl"); got != nil {
 			t.Errorf("ParseTranscript(non-existent) = %v, want nil", got)
 		}
 	})
 
-	t.Run("over 200 lines keeps last ~100", func(t *testing.T) {
+	t.Run("over 200 lines counts the whole session", func(t *testing.T) {
 		// This test needs dynamic generation — too large for a static fixture
 		lines := make([]string, 250)
 		for i := 0; i < 150; i++ {
@@ -218,24 +245,17 @@ func TestParseTranscriptEdgeCases(t *testing.T) {
 		}
 
 		path := writeTempTranscript(t, lines)
-		got := ParseTranscript(path)
+		got := ParseTranscript("", path)
 		if got == nil {
 			t.Fatal("ParseTranscript() returned nil")
 		}
-
-		writeCount := got.Tools["Write"]
-		readCount := got.Tools["Read"]
-		if writeCount == 0 {
-			t.Error("expected Write tools to be counted")
-		}
-		if writeCount < readCount {
-			t.Errorf("expected Write count (%d) >= Read count (%d) when keeping last ~100 lines",
-				writeCount, readCount)
+		if got.Tools["Read"] != 150 || got.Tools["Write"] != 100 {
+			t.Errorf("Tools = %v, want Read 150 and Write 100", got.Tools)
 		}
 	})
 
 	t.Run("tool result for non-existent task", func(t *testing.T) {
-		got := ParseTranscript(fixture("transcript_orphan_result.jsonl"))
+		got := ParseTranscript("", fixture("transcript_orphan_result.jsonl"))
 		if got == nil {
 			t.Fatal("ParseTranscript() returned nil")
 		}
@@ -245,7 +265,7 @@ func TestParseTranscriptEdgeCases(t *testing.T) {
 	})
 
 	t.Run("multiple tool_use in single message", func(t *testing.T) {
-		got := ParseTranscript(fixture("transcript_multi_in_message.jsonl"))
+		got := ParseTranscript("", fixture("transcript_multi_in_message.jsonl"))
 		if got == nil {
 			t.Fatal("ParseTranscript() returned nil")
 		}
@@ -258,6 +278,248 @@ func TestParseTranscriptEdgeCases(t *testing.T) {
 	})
 }
 
+func TestParseTranscript_ToolErrors(t *testing.T) {
+	t.Parallel()
+
+	// Failures are matched to their tool through tool_use_id; a result for
+	// an unknown call counts nowhere.
+	got := ParseTranscript("", fixture("transcript_tool_errors.jsonl"))
+	if got == nil {
+		t.Fatal("ParseTranscript() returned nil")
+	}
+	if got.Tools["Bash"] != 3 || got.Errors["Bash"] != 3 || got.Errors["find_symbol"] != 1 || got.Errors["Read"] != 0 {
+		t.Errorf("Tools = %v, Errors = %v; want Bash 3 of 3 failed, find_symbol 1, Read none", got.Tools, got.Errors)
+	}
+	if got.Calls != 5 || got.Failed != 4 {
+		t.Errorf("Calls/Failed = %d/%d, want 5/4", got.Calls, got.Failed)
+	}
+	// The Read success ended the first streak; three failures followed.
+	if got.ErrorStreak != 3 {
+		t.Errorf("ErrorStreak = %d, want 3", got.ErrorStreak)
+	}
+
+	clean := ParseTranscript("", fixture("transcript_task_completed.jsonl"))
+	if clean.Failed != 0 || clean.ErrorStreak != 0 || clean.Errors != nil {
+		t.Errorf("no failures expected, got %+v", clean)
+	}
+}
+
+func TestParseTranscript_Running(t *testing.T) {
+	t.Parallel()
+
+	// Bash and Grep were called in one response; only Grep returned.
+	got := ParseTranscript("", fixture("transcript_running_tool.jsonl"))
+	if got == nil {
+		t.Fatal("ParseTranscript() returned nil")
+	}
+	started := time.Date(2026, 6, 15, 12, 0, 5, 0, time.UTC)
+	if len(got.Running) != 1 || got.Running[0].Name != "Bash" || got.Running[0].Summary != "npm test …" ||
+		got.Running[0].Started == nil || !got.Running[0].Started.Equal(started) {
+		t.Fatalf("Running = %+v, want Bash \"npm test …\" started %v", got.Running, started)
+	}
+
+	// A typed prompt ends the turn: the unanswered call is not in flight.
+	if got := ParseTranscript("", fixture("transcript_running_interrupted.jsonl")); len(got.Running) != 0 {
+		t.Errorf("interrupted: Running = %+v, want none", got.Running)
+	}
+	if got := ParseTranscript("", fixture("transcript_task_completed.jsonl")); len(got.Running) != 0 {
+		t.Errorf("completed: Running = %+v, want none", got.Running)
+	}
+}
+
+func TestParseTranscript_Agents(t *testing.T) {
+	t.Parallel()
+
+	// Agent and Task both launch agents; sidechain entries are matched to
+	// their agent by prompt and parentUuid, so the Explore agent launched
+	// inside the review nests under it.
+	got := ParseTranscript("", fixture("transcript_agent_sidechain.jsonl"))
+	if got == nil {
+		t.Fatal("ParseTranscript() returned nil")
+	}
+	reviewStart := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+	exploreStart := time.Date(2026, 6, 15, 12, 1, 0, 0, time.UTC)
+	want := []RunningAgent{
+		{Name: "Review the diff", Depth: 0, Started: &reviewStart},
+		{Name: "Explore callers", Depth: 1, Started: &exploreStart},
+	}
+	if len(got.AgentTree) != len(want) {
+		t.Fatalf("AgentTree = %+v, want %+v", got.AgentTree, want)
+	}
+	for i, w := range want {
+		a := got.AgentTree[i]
+		if a.Name != w.Name || a.Depth != w.Depth || a.Started == nil || !a.Started.Equal(*w.Started) {
+			t.Errorf("AgentTree[%d] = %+v, want %+v", i, a, w)
+		}
+	}
+	if strings.Join(got.Agents, ",") != "Review the diff,Explore callers" {
+		t.Errorf("Agents = %v, want the tree's names", got.Agents)
+	}
+	// Plan returned; Run tests returned an error.
+	if got.AgentsDone != 1 || got.AgentsFailed != 1 {
+		t.Errorf("AgentsDone/AgentsFailed = %d/%d, want 1/1", got.AgentsDone, got.AgentsFailed)
+	}
+	// The subagent's calls count as tools, but are not the main turn's.
+	if got.Tools["Bash"] != 1 || got.Errors["Bash"] != 1 || len(got.Running) != 0 {
+		t.Errorf("Tools = %v, Errors = %v, Running = %+v; want one failed Bash, none running", got.Tools, got.Errors, got.Running)
+	}
+
+	// An agent returning takes the agents it launched with it.
+	nested := ParseTranscript("", fixture("transcript_agent_nested_return.jsonl"))
+	if len(nested.AgentTree) != 0 || nested.AgentsDone != 1 {
+		t.Errorf("nested return: AgentTree = %+v, AgentsDone = %d; want none running, 1 done", nested.AgentTree, nested.AgentsDone)
+	}
+
+	// Launches without a subagent_type are not agents.
+	untyped := ParseTranscript("", writeTempTranscript(t, []string{
+		`{"message":{"content":[{"type":"tool_use","name":"Task","id":"t1","input":{"description":"Plan"}}]}}`,
+	}))
+	if len(untyped.Agents) != 0 || len(untyped.Tools) != 0 {
+		t.Errorf("untyped Task: Agents = %v, Tools = %v; want neither", untyped.Agents, untyped.Tools)
+	}
+}
+
+func TestParseTranscript_Todos(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fixture string
+		want    *TodoProgress
+	}{
+		// The latest list replaces the earlier one; whitespace is collapsed.
+		{"latest list wins", "transcript_todos.jsonl", &TodoProgress{Done: 2, Total: 3, Active: "Running tests"}},
+		// Non-object items are skipped, content stands in for a missing
+		// activeForm, and calls without a todos list keep the last one.
+		{"malformed items and calls", "transcript_todos_malformed.jsonl", &TodoProgress{Done: 1, Total: 4, Active: "Build"}},
+		{"TodoWrite without input", "transcript_todowrite_skip.jsonl", nil},
+		{"no TodoWrite", "transcript_single_tool.jsonl", nil},
+	}
+	for _, tt := range tests {
+		got := ParseTranscript("", fixture(tt.fixture))
+		if got == nil {
+			t.Fatalf("%s: ParseTranscript() returned nil", tt.name)
+		}
+		switch {
+		case tt.want == nil && got.Todos != nil:
+			t.Errorf("%s: Todos = %+v, want nil", tt.name, got.Todos)
+		case tt.want != nil && (got.Todos == nil || *got.Todos != *tt.want):
+			t.Errorf("%s: Todos = %+v, want %+v", tt.name, got.Todos, tt.want)
+		}
+		if _, ok := got.Tools["TodoWrite"]; ok {
+			t.Errorf("%s: TodoWrite counted as a tool", tt.name)
+		}
+	}
+}
+
+func TestToolSummary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+		want  string
+	}{
+		{"command", map[string]interface{}{"command": "go test ./...", "description": "Run tests"}, "go test ./..."},
+		{"file name only", map[string]interface{}{"file_path": "/repo/internal/render.go"}, "render.go"},
+		{"multi-line command", map[string]interface{}{"command": "cd web\nnpm ci"}, "cd web …"},
+		{"pattern", map[string]interface{}{"pattern": "func Test", "path": "internal"}, "func Test"},
+		{"long input", map[string]interface{}{"url": "https://example.com/" + strings.Repeat("a", 100)}, "https://example.com/" + strings.Repeat("a", 59) + "…"},
+		{"no known field", map[string]interface{}{"todos": []interface{}{}}, ""},
+		{"nil input", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := toolSummary(tt.input); got != tt.want {
+			t.Errorf("%s: toolSummary = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTranscript_Incremental(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	appendLines := func(lines ...string) {
+		t.Helper()
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(strings.Join(lines, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	task := `{"message":{"content":[{"type":"tool_use","name":"Task","id":"agent_1","input":{"subagent_type":"explorer"}}]}}` + "\n"
+	result := `{"message":{"content":[{"type":"tool_result","tool_use_id":"agent_1"}]}}` + "\n"
+
+	appendLines(task, toolUseLine("Read", "r1")+"\n")
+	got := ParseTranscript(dir, path)
+	if got.Tools["Read"] != 1 || len(got.Agents) != 1 {
+		t.Fatalf("first refresh = %+v, want Read 1 and one agent", got)
+	}
+
+	// A large tool result no longer pushes earlier lines out: the agent's
+	// result is matched and Read keeps its count.
+	big := `{"message":{"content":"` + strings.Repeat("x", 128*1024) + `"}}` + "\n"
+	appendLines(big, result, toolUseLine("Read", "r2"))
+	got = ParseTranscript(dir, path)
+	if got.Tools["Read"] != 2 || len(got.Agents) != 0 {
+		t.Errorf("after append = %+v, want Read 2 and no agents", got)
+	}
+
+	// Only the appended bytes are parsed: the stored offset is at the end.
+	idx := loadTranscriptIndex(dir, path)
+	info, _ := os.Stat(path)
+	if idx.Offset != info.Size() || idx.Lines != 5 {
+		t.Errorf("index offset %d of %d bytes, %d lines; want the whole file, 5 lines", idx.Offset, info.Size(), idx.Lines)
+	}
+
+	// A line still being written waits for the rest.
+	appendLines("\n", `{"message":{"content":[{"type":"tool_use","na`)
+	if got = ParseTranscript(dir, path); got.Tools["Read"] != 2 {
+		t.Errorf("partial line: Read = %d, want 2", got.Tools["Read"])
+	}
+	appendLines(`me":"Edit","id":"e1"}]}}` + "\n")
+	if got = ParseTranscript(dir, path); got.Tools["Edit"] != 1 {
+		t.Errorf("completed line: Tools = %v, want Edit 1", got.Tools)
+	}
+
+	// A truncated or replaced transcript is indexed from scratch.
+	if err := os.WriteFile(path, []byte(toolUseLine("Grep", "g1")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got = ParseTranscript(dir, path); got.Tools["Grep"] != 1 || got.Tools["Read"] != 0 {
+		t.Errorf("after truncation = %v, want only Grep", got.Tools)
+	}
+	replaced := toolUseLine("Bash", "b1") + "\n" + strings.Repeat(toolUseLine("Bash", "b2")+"\n", 40)
+	if err := os.WriteFile(path, []byte(replaced), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got = ParseTranscript(dir, path); got.Tools["Bash"] != 41 || got.Tools["Grep"] != 0 {
+		t.Errorf("after replacement = %v, want only Bash 41", got.Tools)
+	}
+}
+
+func TestParseTranscript_CorruptIndex(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := fixture("transcript_single_tool.jsonl")
+	if err := os.WriteFile(filepath.Join(dir, transcriptIndexName(path)), []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	want := ParseTranscript("", path)
+	got := ParseTranscript(dir, path)
+	if got == nil || len(got.Tools) != len(want.Tools) {
+		t.Errorf("corrupt index: got %+v, want %+v", got, want)
+	}
+	if idx := loadTranscriptIndex(dir, path); idx.Offset == 0 {
+		t.Error("corrupt index should be replaced")
+	}
+}
+
 func TestShortenToolName(t *testing.T) {
 	t.Parallel()
 
@@ -142,6 +142,18 @@ type RenderContext struct {
 	Tools   *ToolInfo
 	Account *AccountInfo
 	Config  Config
+	// ConfigProblems from LoadConfig; any but unknown keys show the ⚙! warning segment.
+	ConfigProblems []ConfigProblem
+	// History is the session's recent samples (nil when unavailable), for
+	// trend-based metrics.
+	History *SessionState
+	// Ledger is the spend across all sessions (nil unless the ledger
+	// feature is on).
+	Ledger *LedgerTotals
+	// CostEstimate is the token-based session cost shown in place of
+	// Data.Cost.TotalCostUSD, which keeps the reported cost (see
+	// EstimateCost); nil shows the reported cost.
+	CostEstimate *float64
 }
 
 // ModelTier classifies a model by its performance/cost tier.
@@ -171,3 +183,16 @@ func classifyModel(m Model) ModelTier {
 	}
 	return TierUnknown
 }
+
+// modelProvider returns "BR" for Bedrock and "VX" for Vertex model IDs, ""
+// for the Anthropic API.
+func modelProvider(m Model) string {
+	lowerID := strings.ToLower(m.ID)
+	switch {
+	case strings.Contains(lowerID, "anthropic.claude-"):
+		return "BR"
+	case strings.Contains(lowerID, "publishers/anthropic"):
+		return "VX"
+	}
+	return ""
+}
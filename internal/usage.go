@@ -7,6 +7,10 @@ import "time"
 type UsageWindow struct {
 	RemainingPercent float64
 	ResetsAt         time.Time
+	// EmptyAt is when the window is projected to run out at the recent burn
+	// rate (see ProjectQuota); zero when it lasts until ResetsAt or no rate
+	// is known.
+	EmptyAt time.Time
 }
 
 // UsageData holds quota for display. Each window is independently optional,